@@ -0,0 +1,269 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command vwo-analyzer analyzes the impact of VWO settings files changes offline.
+//
+// Usage:
+//
+//	vwo-analyzer rebucket -current settings.json -proposed new_settings.json [-users users.txt | -sample 10000] [-sticky] [-json]
+//	vwo-analyzer simulate -settings settings.json [-campaign campaignKey] [-users users.txt | -sample 10000] [-sticky] [-threshold 0.001] [-json]
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/core"
+	"github.com/wingify/vwo-go-sdk/pkg/logger"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/service"
+)
+
+const usage = `Usage: vwo-analyzer <command> [flags]
+
+Commands:
+  rebucket  report per campaign the users who keep, gain, lose or change variation between two settings files
+  simulate  report per campaign the split of users between variations with a sample ratio mismatch check
+
+Run vwo-analyzer <command> -h for the flags of a command.
+`
+
+// rebucketReport struct is the JSON output of the rebucket command for a campaign
+type rebucketReport struct {
+	CampaignKey   string  `json:"campaignKey"`
+	Users         int     `json:"users"`
+	KeepPercent   float64 `json:"keepPercent"`
+	GainPercent   float64 `json:"gainPercent"`
+	LosePercent   float64 `json:"losePercent"`
+	ChangePercent float64 `json:"changePercent"`
+}
+
+// simulateReport struct is the JSON output of the simulate command for a campaign
+type simulateReport struct {
+	CampaignKey         string                `json:"campaignKey"`
+	Users               int                   `json:"users"`
+	Included            int                   `json:"included"`
+	ExpectedIncluded    float64               `json:"expectedIncluded"`
+	Unbucketed          int                   `json:"unbucketed"`
+	Variations          []core.VariationSplit `json:"variations"`
+	ChiSquare           *float64              `json:"chiSquare"`
+	PValue              float64               `json:"pValue"`
+	SampleRatioMismatch bool                  `json:"sampleRatioMismatch"`
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "rebucket":
+		err = rebucket(os.Args[2:], os.Stdout)
+	case "simulate":
+		err = simulate(os.Args[2:], os.Stdout)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// rebucket runs the rebucket command with the given arguments and writes the report to out
+func rebucket(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("rebucket", flag.ContinueOnError)
+	currentPath := flags.String("current", "", "path of the settings file in use")
+	proposedPath := flags.String("proposed", "", "path of the settings file with the proposed changes")
+	usersPath := flags.String("users", "", "path of a file with one user ID per line, a synthetic sample is used if not set")
+	sampleSize := flags.Int("sample", constants.BucketingChangeSampleSize, "number of synthetic users")
+	sticky := flags.Bool("sticky", false, "bucket users with sticky bucketing")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *currentPath == "" || *proposedPath == "" {
+		return fmt.Errorf("both -current and -proposed settings files are required")
+	}
+
+	current, err := readSettingsFile(*currentPath)
+	if err != nil {
+		return err
+	}
+	proposed, err := readSettingsFile(*proposedPath)
+	if err != nil {
+		return err
+	}
+	var userIDs []string
+	if *usersPath != "" {
+		if userIDs, err = readUserIDs(*usersPath); err != nil {
+			return err
+		}
+	}
+
+	vwoInstance := newVWOInstance()
+	vwoInstance.IsStickyBucketingEnabled = *sticky
+	impacts := core.AnalyzeRebucketing(vwoInstance, current, proposed, userIDs, *sampleSize)
+
+	reports := make([]rebucketReport, len(impacts))
+	for i, impact := range impacts {
+		reports[i] = rebucketReport{
+			CampaignKey:   impact.CampaignKey,
+			Users:         impact.Users,
+			KeepPercent:   impact.KeepPercent(),
+			GainPercent:   impact.GainPercent(),
+			LosePercent:   impact.LosePercent(),
+			ChangePercent: impact.ChangePercent(),
+		}
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "CAMPAIGN\tUSERS\tKEEP %\tGAIN %\tLOSE %\tCHANGE %")
+	for _, report := range reports {
+		fmt.Fprintf(writer, "%s\t%d\t%.2f\t%.2f\t%.2f\t%.2f\n", report.CampaignKey, report.Users, report.KeepPercent, report.GainPercent, report.LosePercent, report.ChangePercent)
+	}
+	return writer.Flush()
+}
+
+// simulate runs the simulate command with the given arguments and writes the report to out
+func simulate(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	settingsPath := flags.String("settings", "", "path of the settings file")
+	campaignKey := flags.String("campaign", "", "key of the campaign to simulate, every campaign if not set")
+	usersPath := flags.String("users", "", "path of a file with one user ID per line, a synthetic sample is used if not set")
+	sampleSize := flags.Int("sample", constants.BucketingChangeSampleSize, "number of synthetic users")
+	sticky := flags.Bool("sticky", false, "bucket users with sticky bucketing")
+	threshold := flags.Float64("threshold", constants.SRMPValueThreshold, "p-value below which the split is reported as a sample ratio mismatch")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *settingsPath == "" {
+		return fmt.Errorf("-settings file is required")
+	}
+
+	settingsFile, err := readSettingsFile(*settingsPath)
+	if err != nil {
+		return err
+	}
+	var userIDs []string
+	if *usersPath != "" {
+		if userIDs, err = readUserIDs(*usersPath); err != nil {
+			return err
+		}
+	}
+
+	vwoInstance := newVWOInstance()
+	vwoInstance.IsStickyBucketingEnabled = *sticky
+	var reports []simulateReport
+	for _, campaign := range settingsFile.Campaigns {
+		if *campaignKey != "" && campaign.Key != *campaignKey {
+			continue
+		}
+		simulation := core.SimulateTraffic(vwoInstance, campaign, userIDs, *sampleSize)
+		// JSON has no infinity, the statistic of a variation with no weight getting users is reported as null
+		var chiSquare *float64
+		if !math.IsInf(simulation.ChiSquare, 0) {
+			chiSquare = &simulation.ChiSquare
+		}
+		reports = append(reports, simulateReport{
+			CampaignKey:         simulation.CampaignKey,
+			Users:               simulation.Users,
+			Included:            simulation.Included,
+			ExpectedIncluded:    simulation.ExpectedIncluded,
+			Unbucketed:          simulation.Unbucketed,
+			Variations:          simulation.Variations,
+			ChiSquare:           chiSquare,
+			PValue:              simulation.PValue,
+			SampleRatioMismatch: simulation.IsSampleRatioMismatch(*threshold),
+		})
+	}
+	if len(reports) == 0 {
+		return fmt.Errorf("campaign %v not found in %v", *campaignKey, *settingsPath)
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	}
+
+	for _, report := range reports {
+		fmt.Fprintf(out, "%s users: %d included: %d (expected %.0f) unbucketed: %d p-value: %.4g SRM: %v\n",
+			report.CampaignKey, report.Users, report.Included, report.ExpectedIncluded, report.Unbucketed, report.PValue, report.SampleRatioMismatch)
+		writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "  VARIATION\tWEIGHT\tUSERS\tEXPECTED\tOBSERVED %")
+		for _, split := range report.Variations {
+			fmt.Fprintf(writer, "  %s\t%.2f\t%d\t%.1f\t%.2f\n", split.VariationName, split.Weight, split.Users, split.ExpectedUsers, split.ObservedPercent)
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newVWOInstance returns an instance with a logger discarding the logs of the bucketing
+func newVWOInstance() schema.VwoInstance {
+	return schema.VwoInstance{
+		Logger: logger.Init(constants.SDKName, false, false, ioutil.Discard),
+		API:    "vwo-analyzer",
+	}
+}
+
+// readSettingsFile reads and processes the settings file at the given path
+func readSettingsFile(path string) (schema.SettingsFile, error) {
+	settingsFileManager := service.SettingsFileManager{}
+	if err := settingsFileManager.ProcessSettingsFile(path); err != nil {
+		return schema.SettingsFile{}, fmt.Errorf("%v : %v", path, err)
+	}
+	settingsFileManager.Process()
+	return settingsFileManager.GetSettingsFile(), nil
+}
+
+// readUserIDs reads the non empty lines of the file at the given path
+func readUserIDs(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var userIDs []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if userID := strings.TrimSpace(scanner.Text()); userID != "" {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, scanner.Err()
+}
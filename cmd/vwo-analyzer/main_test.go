@@ -0,0 +1,77 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const settingsFile = "../../pkg/testdata/dummy_settings_file.json"
+
+func TestRebucket(t *testing.T) {
+	assertOutput := assert.New(t)
+
+	var out bytes.Buffer
+	err := rebucket([]string{"-current", settingsFile, "-proposed", settingsFile, "-sample", "100"}, &out)
+	assertOutput.Nil(err)
+	assertOutput.Contains(out.String(), "AB_T_50_W_50_50  100")
+
+	usersFile, _ := ioutil.TempFile("", "users")
+	defer os.Remove(usersFile.Name())
+	usersFile.WriteString("DummyUser\n\nUserInvalid\n")
+	usersFile.Close()
+
+	out.Reset()
+	err = rebucket([]string{"-current", settingsFile, "-proposed", settingsFile, "-users", usersFile.Name(), "-json"}, &out)
+	assertOutput.Nil(err)
+	var reports []rebucketReport
+	assertOutput.Nil(json.Unmarshal(out.Bytes(), &reports))
+	assertOutput.Len(reports, 1)
+	assertOutput.Equal(2, reports[0].Users)
+	assertOutput.Equal(0.0, reports[0].ChangePercent)
+
+	assertOutput.NotNil(rebucket([]string{"-current", settingsFile}, &out), "Proposed settings file is required")
+	assertOutput.NotNil(rebucket([]string{"-current", settingsFile, "-proposed", "missing.json"}, &out), "Settings file does not exist")
+}
+
+func TestSimulate(t *testing.T) {
+	assertOutput := assert.New(t)
+
+	var out bytes.Buffer
+	err := simulate([]string{"-settings", settingsFile, "-sample", "1000"}, &out)
+	assertOutput.Nil(err)
+	assertOutput.Contains(out.String(), "AB_T_50_W_50_50 users: 1000")
+	assertOutput.Contains(out.String(), "Variation-1")
+
+	out.Reset()
+	err = simulate([]string{"-settings", settingsFile, "-campaign", "AB_T_50_W_50_50", "-sample", "1000", "-json"}, &out)
+	assertOutput.Nil(err)
+	var reports []simulateReport
+	assertOutput.Nil(json.Unmarshal(out.Bytes(), &reports))
+	assertOutput.Len(reports, 1)
+	assertOutput.Len(reports[0].Variations, 2)
+	assertOutput.False(reports[0].SampleRatioMismatch)
+
+	assertOutput.NotNil(simulate([]string{"-settings", settingsFile, "-campaign", "notPresent"}, &out), "Campaign does not exist")
+	assertOutput.NotNil(simulate([]string{}, &out), "Settings file is required")
+}
@@ -7,4 +7,5 @@ require (
 	github.com/satori/go.uuid v1.2.0
 	github.com/spaolacci/murmur3 v1.1.0
 	github.com/stretchr/testify v1.5.1
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -21,7 +21,6 @@ import (
 
 	"github.com/wingify/vwo-go-sdk/pkg/constants"
 	"github.com/wingify/vwo-go-sdk/pkg/core"
-	"github.com/wingify/vwo-go-sdk/pkg/event"
 	"github.com/wingify/vwo-go-sdk/pkg/schema"
 	"github.com/wingify/vwo-go-sdk/pkg/utils"
 )
@@ -37,7 +36,7 @@ This API method: Gets the variation assigned for the user for the campaign and s
 4. Validates the Campaign Type
 5. Assigns the determinitic variation to the user(based on userId), if user becomes part of campaign
    If userStorageService is used, it will look into it for the variation and if found, no further processing is done
-6. Sends an impression call to VWO server to track user
+6. Sends an impression call to VWO server to track user, unless the user is in the holdout group or did not consent to tracking
 */
 func (vwo *VWOInstance) Activate(campaignKey, userID string, option interface{}) string {
 	/*
@@ -46,6 +45,10 @@ func (vwo *VWOInstance) Activate(campaignKey, userID string, option interface{})
 			userID: Unique identification of user
 			customVariables(In option): variables for pre-segmentation
 			variationTargetingVariables(In option): variables for variation targeting
+			bucketingKey(In option): identity hashed instead of the user ID to bucket the user
+			alwaysCheckSegment(In option): re-evaluate the segments for users with a stored variation
+			context(In option): context passed to the user storage
+			trackingConsent(In option): false to evaluate the campaign without sending any impression
 			revenueValue(In option): Value of revenue for the goal if the goal is revenue tracking
 
 		Returns:
@@ -54,12 +57,18 @@ func (vwo *VWOInstance) Activate(campaignKey, userID string, option interface{})
 	options := utils.ParseOptions(option)
 
 	vwoInstance := schema.VwoInstance{
-		SettingsFile:      vwo.SettingsFile,
-		UserStorage:       vwo.UserStorage,
-		Logger:            vwo.Logger,
-		IsDevelopmentMode: vwo.IsDevelopmentMode,
-		API:               "Activate",
-		Integrations:      vwo.Integrations,
+		SettingsFile:             vwo.SettingsFile,
+		UserStorage:              vwo.UserStorage,
+		Logger:                   vwo.Logger,
+		IsDevelopmentMode:        vwo.IsDevelopmentMode,
+		API:                      "Activate",
+		Integrations:             vwo.Integrations,
+		ForcedVariations:         vwo.ForcedVariations,
+		Overrides:                vwo.Overrides,
+		Holdout:                  vwo.Holdout,
+		Clock:                    vwo.Clock,
+		IsStickyBucketingEnabled: vwo.IsStickyBucketingEnabled,
+		AssignmentRecorder:       vwo.AssignmentRecorder,
 	}
 
 	if !utils.ValidateActivate(campaignKey, userID) {
@@ -74,6 +83,7 @@ func (vwo *VWOInstance) Activate(campaignKey, userID string, option interface{})
 		utils.LogMessage(vwo.Logger, constants.Error, activate, message)
 		return ""
 	}
+	campaign = vwoInstance.Overrides.ApplyToCampaign(campaign)
 
 	if campaign.Status != constants.StatusRunning {
 		message := fmt.Sprintf(constants.ErrorMessageCampaignNotRunning, vwoInstance.API, campaignKey)
@@ -95,13 +105,19 @@ func (vwo *VWOInstance) Activate(campaignKey, userID string, option interface{})
 		return ""
 	}
 
-	impression := utils.CreateImpressionTrackingUser(vwoInstance, campaign.ID, variation.ID, userID)
+	if core.IsUserInCampaignHoldout(vwoInstance, userID, campaign, options) {
+		return variation.Name
+	}
 
-	if vwo.IsBatchingEnabled {
-		vwo.AddToBatch(impression)
-	} else {
-		go event.Dispatch(vwoInstance, impression)
+	if options.NoTrackingConsent {
+		message := fmt.Sprintf(constants.InfoMessageNoTrackingConsent, vwoInstance.API, userID)
+		utils.LogMessage(vwo.Logger, constants.Info, activate, message)
+		return variation.Name
 	}
+
+	impression := utils.CreateImpressionTrackingUser(vwoInstance, campaign.ID, variation.ID, userID)
+
+	vwo.dispatchEvent(vwoInstance.API, userID, "", impression)
 	message := fmt.Sprintf(constants.InfoMessageMainKeysForImpression, vwoInstance.API, vwoInstance.SettingsFile.AccountID, vwoInstance.UserID, campaign.ID, variation.ID)
 	utils.LogMessage(vwo.Logger, constants.Info, activate, message)
 
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/event"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+const fileEventDispatcher = "event_dispatcher.go"
+
+// WithEventDispatcher sets the dispatcher sending the track user, track goal and push events instead of the VWO endpoints,
+// the batched events are sent with it too
+func WithEventDispatcher(dispatcher schema.EventDispatcher) VWOOption {
+	return func(vwo *VWOInstance) {
+		vwo.EventDispatcher = dispatcher
+	}
+}
+
+// WithEventRetry retries the events failing with a network error, a 5xx or a 429 response following the policy,
+// the events still not sent are given to the DeadLetterCallBack of the policy
+func WithEventRetry(policy schema.RetryPolicy) VWOOption {
+	return func(vwo *VWOInstance) {
+		vwo.EventRetryPolicy = &policy
+	}
+}
+
+// withEventRetry function wraps the dispatcher to retry its events if the instance has a retry policy
+func (vwo *VWOInstance) withEventRetry(dispatcher schema.EventDispatcher) schema.EventDispatcher {
+	if vwo.EventRetryPolicy == nil {
+		return dispatcher
+	}
+	if _, ok := dispatcher.(*event.RetryingDispatcher); ok {
+		return dispatcher
+	}
+	return event.NewRetryingDispatcher(dispatcher, *vwo.EventRetryPolicy, vwo.Logger)
+}
+
+// getEventDispatcher function returns the dispatcher of the instance, the one published by the last settings file
+// update if any, or one sending the events to the VWO endpoints
+func (vwo *VWOInstance) getEventDispatcher() schema.EventDispatcher {
+	if vwo.ActiveEventDispatcher != nil {
+		if dispatcher := vwo.ActiveEventDispatcher.Load(); dispatcher != nil {
+			return dispatcher
+		}
+	}
+	if vwo.EventDispatcher != nil {
+		return vwo.EventDispatcher
+	}
+	return vwo.newHTTPDispatcher()
+}
+
+// newHTTPDispatcher function returns a dispatcher sending the events to the VWO endpoints of the settings file
+func (vwo *VWOInstance) newHTTPDispatcher() schema.EventDispatcher {
+	return vwo.withEventRetry(event.NewHTTPDispatcher(schema.VwoInstance{
+		SettingsFile:             vwo.SettingsFile,
+		Logger:                   vwo.Logger,
+		IsDevelopmentMode:        vwo.IsDevelopmentMode,
+		UserStorage:              vwo.UserStorage,
+		IsBatchingEnabled:        vwo.IsBatchingEnabled,
+		Integrations:             vwo.Integrations,
+		GoalTypeToTrack:          vwo.GoalTypeToTrack,
+		ShouldTrackReturningUser: vwo.ShouldTrackReturningUser,
+	}))
+}
+
+// WithWorkerPool dispatches the events of the non batched calls with the given number of workers taking them from
+// a queue of queueSize events, overflowPolicy is constants.WorkerPoolOverflowBlock to wait for room in the full queue,
+// constants.WorkerPoolOverflowDropOldest or constants.WorkerPoolOverflowDropNewest to drop an event instead.
+// A pool of 10 workers with a queue of 10000 events dropping the newest event when full is used by default
+func WithWorkerPool(workers, queueSize int, overflowPolicy string) VWOOption {
+	return func(vwo *VWOInstance) {
+		vwo.EventWorkerPool = &schema.WorkerPool{
+			Workers:        workers,
+			QueueSize:      queueSize,
+			OverflowPolicy: overflowPolicy,
+		}
+	}
+}
+
+// dispatchEvent function queues the impression if batching is enabled, or submits it to the worker pool,
+// it is dispatched in the background by an instance not created with Init
+func (vwo *VWOInstance) dispatchEvent(api, userID, goalType string, impression schema.Impression) {
+	if vwo.IsBatchingEnabled {
+		vwo.AddToBatch(impression)
+		return
+	}
+
+	dispatchedEvent := schema.Event{
+		Type:       impression.EventType,
+		API:        api,
+		UserID:     userID,
+		GoalType:   goalType,
+		Impression: impression,
+	}
+	if vwo.EventWorkerPool != nil {
+		if !vwo.EventWorkerPool.Submit(dispatchedEvent) {
+			message := fmt.Sprintf(constants.ErrorMessageImpressionDropped, api, userID)
+			utils.LogMessage(vwo.Logger, constants.Error, fileEventDispatcher, message)
+		}
+		return
+	}
+
+	dispatcher := vwo.getEventDispatcher()
+	logger := vwo.Logger
+	go func() {
+		if err := dispatcher.Dispatch(context.Background(), dispatchedEvent); err != nil {
+			message := fmt.Sprintf(constants.ErrorMessageImpressionFailed, api, err)
+			utils.LogMessage(logger, constants.Error, fileEventDispatcher, message)
+		}
+	}()
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/event"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+type recordingDispatcher struct {
+	mu     sync.Mutex
+	events []schema.Event
+}
+
+func (dispatcher *recordingDispatcher) Dispatch(ctx context.Context, event schema.Event) error {
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+	dispatcher.events = append(dispatcher.events, event)
+	return nil
+}
+
+func (dispatcher *recordingDispatcher) waitForEvents(count int) []schema.Event {
+	for i := 0; i < 100; i++ {
+		dispatcher.mu.Lock()
+		events := append([]schema.Event(nil), dispatcher.events...)
+		dispatcher.mu.Unlock()
+		if len(events) >= count {
+			return events
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
+func getDispatcherInstance(t *testing.T, vwoOption ...VWOOption) (*VWOInstance, schema.Campaign) {
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, campaign.Variations)
+	instance := VWOInstance{}
+	instance.SettingsFile = schema.SettingsFile{AccountID: 12345, SDKKey: "sdkKey", Campaigns: []schema.Campaign{campaign}}
+	instance.Logger = vwoInstance.Logger
+	vwo, err := instance.Init(vwoOption...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vwo, campaign
+}
+
+func TestWithEventDispatcher(t *testing.T) {
+	assertOutput := assert.New(t)
+	dispatcher := &recordingDispatcher{}
+	vwo, campaign := getDispatcherInstance(t, WithEventDispatcher(dispatcher))
+	userID := testdata.GetRandomUser()
+
+	assertOutput.NotEmpty(vwo.Activate(campaign.Key, userID, nil))
+	events := dispatcher.waitForEvents(1)
+	assertOutput.Len(events, 1)
+	assertOutput.Equal(constants.EventsTrackUser, events[0].Type)
+	assertOutput.Equal("Activate", events[0].API)
+	assertOutput.Equal(userID, events[0].UserID)
+	assertOutput.Equal(campaign.ID, events[0].Impression.ExperimentID)
+
+	assertOutput.True(vwo.Track(campaign.Key, userID, campaign.Goals[0].Identifier, map[string]interface{}{"revenueValue": 10})[0].TrackValue)
+	events = dispatcher.waitForEvents(2)
+	assertOutput.Len(events, 2)
+	assertOutput.Equal(constants.EventsTrackGoal, events[1].Type)
+	assertOutput.Equal(campaign.Goals[0].Type, events[1].GoalType)
+
+	assertOutput.True(vwo.Push(testdata.ValidTagKey, testdata.ValidTagValue, userID))
+	events = dispatcher.waitForEvents(3)
+	assertOutput.Len(events, 3)
+	assertOutput.Equal(constants.EventsPush, events[2].Type)
+}
+
+func TestWithEventDispatcherBatching(t *testing.T) {
+	assertOutput := assert.New(t)
+	dispatcher := &recordingDispatcher{}
+	flushed := make(chan error, 1)
+	vwo, campaign := getDispatcherInstance(t, WithEventDispatcher(dispatcher), WithBatchEventQueue(BatchConfig{EventsPerRequest: 2, RequestTimeInterval: 60}, func(err error, events []map[string]interface{}) {
+		flushed <- err
+	}))
+	userID := testdata.GetRandomUser()
+
+	vwo.Activate(campaign.Key, userID, nil)
+	vwo.Track(campaign.Key, userID, campaign.Goals[0].Identifier, map[string]interface{}{"revenueValue": 10})
+	assertOutput.Nil(<-flushed)
+	events := dispatcher.waitForEvents(2)
+	assertOutput.Len(events, 2, "Events should be dispatched one by one by a dispatcher not implementing DispatchBatch")
+	assertOutput.Equal(constants.EventsTrackUser, events[0].Type)
+	assertOutput.Equal(constants.EventsTrackGoal, events[1].Type)
+	assertOutput.Equal(constants.GoalTypeRevenue, events[1].GoalType)
+	assertOutput.Equal(userID, events[1].UserID)
+}
+
+func TestDefaultEventDispatcher(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwo, _ := getDispatcherInstance(t, WithDevelopmentMode())
+	dispatcher, ok := vwo.EventDispatcher.(*event.HTTPDispatcher)
+	assertOutput.True(ok, "Events should be sent to the VWO endpoints by default")
+	assertOutput.True(dispatcher.IsDevelopmentMode)
+	assertOutput.Equal("sdkKey", dispatcher.SettingsFile.SDKKey)
+}
+
+func TestWithEventRetry(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwo, _ := getDispatcherInstance(t, WithDevelopmentMode(), WithEventRetry(schema.RetryPolicy{MaxRetries: 5}))
+	retryingDispatcher, ok := vwo.EventDispatcher.(*event.RetryingDispatcher)
+	assertOutput.True(ok)
+	assertOutput.Equal(5, retryingDispatcher.Policy.MaxRetries)
+	assertOutput.Equal(constants.EventDefaultInitialBackoff*time.Second, retryingDispatcher.Policy.InitialBackoff)
+	_, ok = retryingDispatcher.Dispatcher.(*event.HTTPDispatcher)
+	assertOutput.True(ok)
+
+	dispatcher := &recordingDispatcher{}
+	vwo, _ = getDispatcherInstance(t, WithEventDispatcher(dispatcher), WithEventRetry(schema.RetryPolicy{}), WithBatchEventQueue(BatchConfig{EventsPerRequest: 2, RequestTimeInterval: 60}, nil))
+	retryingDispatcher, ok = vwo.EventDispatcher.(*event.RetryingDispatcher)
+	assertOutput.True(ok, "A custom dispatcher should be retried too")
+	assertOutput.Equal(dispatcher, retryingDispatcher.Dispatcher)
+	assertOutput.Equal(vwo.EventDispatcher, vwo.BatchEventQueue.Dispatcher)
+}
+
+func TestWithWorkerPool(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwo, _ := getDispatcherInstance(t, WithDevelopmentMode())
+	assertOutput.Equal(constants.WorkerPoolDefaultWorkers, vwo.EventWorkerPool.Workers, "Events should be dispatched by a worker pool by default")
+	assertOutput.Equal(constants.WorkerPoolOverflowDropNewest, vwo.EventWorkerPool.OverflowPolicy)
+
+	dispatcher := &recordingDispatcher{}
+	vwo, campaign := getDispatcherInstance(t, WithEventDispatcher(dispatcher), WithWorkerPool(2, 100, constants.WorkerPoolOverflowDropNewest))
+	assertOutput.Equal(2, vwo.EventWorkerPool.Workers)
+	assertOutput.NotEmpty(vwo.Activate(campaign.Key, testdata.GetRandomUser(), nil))
+	assertOutput.Len(dispatcher.waitForEvents(1), 1)
+	assertOutput.Nil(vwo.EventWorkerPool.Close(context.Background()))
+	assertOutput.Equal(int64(1), vwo.EventWorkerPool.Stats().Dispatched)
+
+	vwo, _ = getDispatcherInstance(t, WithBatchEventQueue(BatchConfig{EventsPerRequest: 2, RequestTimeInterval: 60}, nil))
+	assertOutput.Nil(vwo.EventWorkerPool, "Batched events should not use the worker pool")
+
+	instance := VWOInstance{}
+	_, err := instance.Init(WithWorkerPool(2, 100, "drop-all"))
+	assertOutput.NotNil(err)
+}
+
+// stalledDispatcher never returns before it is released
+type stalledDispatcher struct {
+	release chan struct{}
+}
+
+func (dispatcher stalledDispatcher) Dispatch(ctx context.Context, event schema.Event) error {
+	<-dispatcher.release
+	return nil
+}
+
+func TestWorkerPoolDropsWhenFull(t *testing.T) {
+	assertOutput := assert.New(t)
+	dispatcher := stalledDispatcher{release: make(chan struct{})}
+	vwo, campaign := getDispatcherInstance(t, WithEventDispatcher(dispatcher), WithWorkerPool(1, 1, ""))
+
+	activated := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			vwo.Activate(campaign.Key, testdata.GetRandomUser(), nil)
+		}
+		close(activated)
+	}()
+	select {
+	case <-activated:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Activate should not wait for a stalled dispatcher")
+	}
+	assertOutput.True(vwo.EventWorkerPool.Stats().Dropped >= 8, "The events should be dropped while the queue is full")
+	close(dispatcher.release)
+	assertOutput.Nil(vwo.Close(context.Background()))
+}
+
+func TestClose(t *testing.T) {
+	assertOutput := assert.New(t)
+	dispatcher := &recordingDispatcher{}
+	vwo, campaign := getDispatcherInstance(t, WithEventDispatcher(dispatcher), WithSRMMonitor(SRMConfig{}, nil))
+
+	for i := 0; i < 5; i++ {
+		vwo.Activate(campaign.Key, testdata.GetRandomUser(), nil)
+	}
+	assertOutput.Nil(vwo.Close(context.Background()))
+	assertOutput.Len(dispatcher.events, 5, "Events being dispatched should be waited for")
+	assertOutput.False(vwo.EventWorkerPool.Submit(schema.Event{}), "Closed instance should not dispatch events")
+	assertOutput.Nil(vwo.Close(context.Background()))
+}
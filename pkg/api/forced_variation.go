@@ -0,0 +1,140 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+const forcedVariation = "forced_variation.go"
+
+// SetForcedVariation function
+/*
+This API method: Forces a variation for the user in the campaign, mainly for QA
+1. Validates the arguments being passed
+2. Finds the corresponding Campaign and Variation
+3. Stores the forced variation, which takes precedence over whitelisting and user storage
+*/
+func (vwo *VWOInstance) SetForcedVariation(userID, campaignKey, variationName string) error {
+	/*
+		Args:
+			userID: Unique identification of user
+			campaignKey: Key of the campaign
+			variationName: Name of the variation to be forced
+
+		Returns:
+			error: if the campaign or the variation is not found, else nil
+	*/
+
+	API := "SetForcedVariation"
+	if err := validateForcedVariation(API, vwo.SettingsFile, userID, campaignKey, variationName); err != nil {
+		utils.LogMessage(vwo.Logger, constants.Error, forcedVariation, err.Error())
+		return err
+	}
+
+	if vwo.ForcedVariations == nil {
+		vwo.ForcedVariations = &schema.ForcedVariations{}
+	}
+	vwo.ForcedVariations.Set(userID, campaignKey, variationName)
+
+	message := fmt.Sprintf(constants.InfoMessageForcedVariationSet, API, variationName, userID, campaignKey)
+	utils.LogMessage(vwo.Logger, constants.Info, forcedVariation, message)
+	return nil
+}
+
+// ClearForcedVariation function removes the variation forced for the user in the campaign
+func (vwo *VWOInstance) ClearForcedVariation(userID, campaignKey string) {
+	/*
+		Args:
+			userID: Unique identification of user
+			campaignKey: Key of the campaign
+	*/
+
+	vwo.ForcedVariations.Clear(userID, campaignKey)
+
+	message := fmt.Sprintf(constants.InfoMessageForcedVariationCleared, "ClearForcedVariation", userID, campaignKey)
+	utils.LogMessage(vwo.Logger, constants.Info, forcedVariation, message)
+}
+
+// LoadForcedVariations function loads forced variations from a local JSON file
+/*
+The file maps campaign keys to user IDs and their forced variation names:
+	{"campaignKey": {"userID": "variationName"}}
+All entries are validated before any of them is applied.
+*/
+func (vwo *VWOInstance) LoadForcedVariations(path string) error {
+	/*
+		Args:
+			path: Location of the forced variations file on system
+
+		Returns:
+			error: if the file could not be read or has an invalid entry, else nil
+	*/
+
+	API := "LoadForcedVariations"
+	var forcedVariations map[string]map[string]string
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &forcedVariations)
+	}
+	if err == nil {
+		for campaignKey, users := range forcedVariations {
+			for userID, variationName := range users {
+				if err = validateForcedVariation(API, vwo.SettingsFile, userID, campaignKey, variationName); err != nil {
+					break
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf(constants.ErrorMessageForcedVariationsFileInvalid, API, path, err.Error())
+		utils.LogMessage(vwo.Logger, constants.Error, forcedVariation, err.Error())
+		return err
+	}
+
+	if vwo.ForcedVariations == nil {
+		vwo.ForcedVariations = &schema.ForcedVariations{}
+	}
+	for campaignKey, users := range forcedVariations {
+		for userID, variationName := range users {
+			vwo.ForcedVariations.Set(userID, campaignKey, variationName)
+		}
+	}
+	return nil
+}
+
+// validateForcedVariation checks that the campaign and the variation to be forced exist
+func validateForcedVariation(API string, settingsFile schema.SettingsFile, userID, campaignKey, variationName string) error {
+	if userID == "" || campaignKey == "" || variationName == "" {
+		return fmt.Errorf(constants.ErrorMessageForcedVariationMissingParams, API)
+	}
+	campaign, err := utils.GetCampaign(API, settingsFile, campaignKey)
+	if err != nil {
+		return err
+	}
+	_, err = utils.GetCampaignVariation(API, campaign, variationName)
+	return err
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/storage"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+func getForcedVariationInstance() *VWOInstance {
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	vwoInstance.SettingsFile.Campaigns[0].Variations = utils.GetVariationAllocationRanges(vwoInstance, vwoInstance.SettingsFile.Campaigns[0].Variations)
+
+	instance := VWOInstance{}
+	instance.SettingsFile = vwoInstance.SettingsFile
+	instance.Logger = vwoInstance.Logger
+	return &instance
+}
+
+func TestSetForcedVariation(t *testing.T) {
+	assertOutput := assert.New(t)
+	instance := getForcedVariationInstance()
+	campaignKey := testdata.ValidCampaignKey
+
+	assertOutput.Empty(instance.GetVariationName(campaignKey, testdata.InvalidUser, nil), "User should not be part of the campaign")
+
+	err := instance.SetForcedVariation(testdata.InvalidUser, campaignKey, "Variation-1")
+	assertOutput.Nil(err)
+	assertOutput.Equal("Variation-1", instance.GetVariationName(campaignKey, testdata.InvalidUser, nil))
+	assertOutput.Equal("Variation-1", instance.Activate(campaignKey, testdata.InvalidUser, nil))
+
+	instance.ClearForcedVariation(testdata.InvalidUser, campaignKey)
+	assertOutput.Empty(instance.GetVariationName(campaignKey, testdata.InvalidUser, nil), "Forced variation should be cleared")
+
+	err = instance.SetForcedVariation(testdata.InvalidUser, testdata.NonExistingCampaign, "Variation-1")
+	assertOutput.NotNil(err, "Campaign does not exist")
+
+	err = instance.SetForcedVariation(testdata.InvalidUser, campaignKey, testdata.InvalidVariationName)
+	assertOutput.NotNil(err, "Variation does not exist")
+
+	err = instance.SetForcedVariation("", campaignKey, "Variation-1")
+	assertOutput.NotNil(err, "Invalid params")
+}
+
+func TestForcedVariationNotStored(t *testing.T) {
+	assertOutput := assert.New(t)
+	instance := getForcedVariationInstance()
+	instance.IsDevelopmentMode = true
+	userStorage := storage.NewMemoryUserStorage(0, 0)
+	instance.UserStorage = userStorage
+	campaignKey := testdata.ValidCampaignKey
+
+	assertOutput.Nil(instance.SetForcedVariation(testdata.InvalidUser, campaignKey, "Variation-1"))
+	result := instance.Track(campaignKey, testdata.InvalidUser, testdata.ValidGoal, nil)
+	assertOutput.True(result[0].TrackValue)
+	userData, _ := userStorage.Get(context.Background(), testdata.InvalidUser, campaignKey)
+	assertOutput.Empty(userData.VariationName, "Forced variation should not be stored")
+
+	instance.ClearForcedVariation(testdata.InvalidUser, campaignKey)
+	assertOutput.Empty(instance.GetVariationName(campaignKey, testdata.InvalidUser, nil), "User should not keep the forced variation")
+}
+
+func TestLoadForcedVariations(t *testing.T) {
+	assertOutput := assert.New(t)
+	instance := getForcedVariationInstance()
+	campaignKey := testdata.ValidCampaignKey
+
+	file, _ := ioutil.TempFile("", "forced_variations")
+	defer os.Remove(file.Name())
+	file.WriteString(`{"AB_T_50_W_50_50": {"UserInvalid": "Control"}}`)
+	file.Close()
+
+	assertOutput.Nil(instance.LoadForcedVariations(file.Name()))
+	assertOutput.Equal("Control", instance.GetVariationName(campaignKey, testdata.InvalidUser, nil))
+
+	invalidFile, _ := ioutil.TempFile("", "forced_variations")
+	defer os.Remove(invalidFile.Name())
+	invalidFile.WriteString(`{"AB_T_50_W_50_50": {"UserInvalid": "Variation-1", "DummyUser": "NoVaritionInCampaign"}}`)
+	invalidFile.Close()
+
+	assertOutput.NotNil(instance.LoadForcedVariations(invalidFile.Name()))
+	assertOutput.Equal("Control", instance.GetVariationName(campaignKey, testdata.InvalidUser, nil), "Invalid file should not be applied")
+
+	assertOutput.NotNil(instance.LoadForcedVariations(testdata.InvalidSettingsFile))
+}
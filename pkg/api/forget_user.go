@@ -0,0 +1,89 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+const forgetUser = "forget_user.go"
+
+// ForgetUser function
+/*
+This API method: Deletes the data kept for a particular user to honor a data deletion request
+1. Validates the arguments being passed
+2. Deletes the data of the user from the UserStorage and the ConversionStore, they must implement schema.DeletableStorage
+3. Removes the impressions of the user waiting in the batch event queue
+*/
+func (vwo *VWOInstance) ForgetUser(userID string) error {
+	/*
+		Args:
+			userID: Unique identification of user
+
+		Returns:
+			error: the first error met, every step is attempted anyway
+	*/
+	api := "ForgetUser"
+	if userID == "" {
+		message := fmt.Sprintf(constants.ErrorMessageForgetUserAPIMissingParams, api)
+		utils.LogMessage(vwo.Logger, constants.Error, forgetUser, message)
+		return errors.New(message)
+	}
+
+	var err error
+	if vwo.UserStorage != nil {
+		err = deleteUserData(vwo, api, "UserStorage", vwo.UserStorage, userID)
+	}
+	if vwo.ConversionStore != nil {
+		if deleteErr := deleteUserData(vwo, api, "ConversionStore", vwo.ConversionStore, userID); err == nil {
+			err = deleteErr
+		}
+	}
+
+	removed := 0
+	if vwo.IsBatchingEnabled {
+		removed = vwo.BatchEventQueue.RemoveUserImpressions(userID)
+	}
+
+	if err == nil {
+		message := fmt.Sprintf(constants.InfoMessageUserForgotten, api, userID, removed)
+		utils.LogMessage(vwo.Logger, constants.Info, forgetUser, message)
+	}
+	return err
+}
+
+// deleteUserData function deletes the data of the user from the storage, an error is returned if the storage can not delete data
+func deleteUserData(vwo *VWOInstance, api, storageName string, storage interface{}, userID string) error {
+	deletableStorage, ok := storage.(schema.DeletableStorage)
+	if !ok {
+		message := fmt.Sprintf(constants.ErrorMessageStorageNotDeletable, api, storageName, userID)
+		utils.LogMessage(vwo.Logger, constants.Error, forgetUser, message)
+		return errors.New(message)
+	}
+	if err := deletableStorage.Delete(context.Background(), userID); err != nil {
+		message := fmt.Sprintf(constants.ErrorMessageStorageDeleteFailed, api, userID, storageName, err.Error())
+		utils.LogMessage(vwo.Logger, constants.Error, forgetUser, message)
+		return err
+	}
+	return nil
+}
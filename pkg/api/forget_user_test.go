@@ -0,0 +1,63 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/storage"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+func TestForgetUser(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, campaign.Variations)
+	ctx := context.Background()
+
+	userStorage := storage.NewMemoryUserStorage(0, 0)
+	conversionStore := storage.NewMemoryConversionStore(0, 0)
+	instance := VWOInstance{}
+	instance.SettingsFile = schema.SettingsFile{Campaigns: []schema.Campaign{campaign}}
+	instance.Logger = vwoInstance.Logger
+	vwo, err := instance.Init(WithStorage(userStorage), WithConversionStore(conversionStore), WithDevelopmentMode())
+	assertOutput.Nil(err)
+
+	userID := testdata.GetRandomUser()
+	result := vwo.Track(campaign.Key, userID, testdata.ValidGoal, nil)
+	assertOutput.True(result[0].TrackValue)
+	assertOutput.NotNil(vwo.ForgetUser(""), "User ID is required")
+
+	assertOutput.Nil(vwo.ForgetUser(userID))
+	userData, _ := userStorage.Get(ctx, userID, campaign.Key)
+	assertOutput.Empty(userData.VariationName)
+	_, ok, _ := conversionStore.GetConversion(ctx, userID, campaign.Key, testdata.ValidGoal)
+	assertOutput.False(ok)
+	result = vwo.Track(campaign.Key, userID, testdata.ValidGoal, nil)
+	assertOutput.True(result[0].TrackValue, "Forgotten user should be tracked as a new user")
+
+	// the storage can not delete data
+	vwo.UserStorage = &countingBatchUserStorage{records: make(map[string]schema.UserData)}
+	assertOutput.NotNil(vwo.ForgetUser(userID))
+	_, ok, _ = conversionStore.GetConversion(ctx, userID, campaign.Key, testdata.ValidGoal)
+	assertOutput.False(ok, "Data of the other storages should be deleted anyway")
+}
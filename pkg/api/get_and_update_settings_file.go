@@ -19,6 +19,7 @@ package api
 import (
 	"fmt"
 	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/event"
 	"github.com/wingify/vwo-go-sdk/pkg/logger"
 	"github.com/wingify/vwo-go-sdk/pkg/service"
 	"strconv"
@@ -36,5 +37,25 @@ func (vwoInstance *VWOInstance) GetAndUpdateSettingsFile() {
 	}
 	settingsFileManager.Process()
 	vwoInstance.SettingsFile = settingsFileManager.GetSettingsFile()
+	dispatcher := vwoInstance.getEventDispatcher()
+	if retryingDispatcher, ok := dispatcher.(*event.RetryingDispatcher); ok {
+		dispatcher = retryingDispatcher.Dispatcher
+	}
+	if _, ok := dispatcher.(*event.HTTPDispatcher); ok {
+		// the collection prefix of the endpoints may have changed, the new dispatcher is published at once
+		// as the events are dispatched meanwhile
+		dispatcher = vwoInstance.newHTTPDispatcher()
+		if vwoInstance.ActiveEventDispatcher != nil {
+			vwoInstance.ActiveEventDispatcher.Store(dispatcher)
+		} else {
+			vwoInstance.EventDispatcher = dispatcher
+		}
+		if vwoInstance.IsBatchingEnabled {
+			vwoInstance.BatchEventQueue.SetDispatcher(dispatcher)
+		}
+		if vwoInstance.EventWorkerPool != nil {
+			vwoInstance.EventWorkerPool.SetDispatcher(dispatcher)
+		}
+	}
 	log.Info(fmt.Sprintf(constants.InfoSDKInstanceUpdated, accountId))
 }
@@ -47,6 +47,9 @@ func (vwo *VWOInstance) GetFeatureVariableValue(campaignKey, variableKey, userID
 			userID: Unique identification of user
 			customVariables(In option): variables for pre-segmentation
 			variationTargetingVariables(In option): variables for variation targeting
+			bucketingKey(In option): identity hashed instead of the user ID to bucket the user
+			alwaysCheckSegment(In option): re-evaluate the segments for users with a stored variation
+			context(In option): context passed to the user storage
 			revenueValue(In option): Value of revenue for the goal if the goal is revenue tracking
 
 		Returns:
@@ -54,12 +57,18 @@ func (vwo *VWOInstance) GetFeatureVariableValue(campaignKey, variableKey, userID
 	*/
 
 	vwoInstance := schema.VwoInstance{
-		SettingsFile:      vwo.SettingsFile,
-		UserStorage:       vwo.UserStorage,
-		Logger:            vwo.Logger,
-		IsDevelopmentMode: vwo.IsDevelopmentMode,
-		API:               "GetFeatureVariableValue",
-		Integrations:      vwo.Integrations,
+		SettingsFile:             vwo.SettingsFile,
+		UserStorage:              vwo.UserStorage,
+		Logger:                   vwo.Logger,
+		IsDevelopmentMode:        vwo.IsDevelopmentMode,
+		API:                      "GetFeatureVariableValue",
+		Integrations:             vwo.Integrations,
+		ForcedVariations:         vwo.ForcedVariations,
+		Overrides:                vwo.Overrides,
+		Holdout:                  vwo.Holdout,
+		Clock:                    vwo.Clock,
+		IsStickyBucketingEnabled: vwo.IsStickyBucketingEnabled,
+		AssignmentRecorder:       vwo.AssignmentRecorder,
 	}
 
 	if !utils.ValidateGetFeatureVariableValue(campaignKey, variableKey, userID) {
@@ -76,6 +85,7 @@ func (vwo *VWOInstance) GetFeatureVariableValue(campaignKey, variableKey, userID
 		utils.LogMessage(vwo.Logger, constants.Error, getFeatureVariableValue, message)
 		return nil
 	}
+	campaign = vwoInstance.Overrides.ApplyToCampaign(campaign)
 
 	if campaign.Status != constants.StatusRunning {
 		message := fmt.Sprintf(constants.ErrorMessageCampaignNotRunning, vwoInstance.API, campaignKey)
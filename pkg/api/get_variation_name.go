@@ -44,6 +44,9 @@ func (vwo *VWOInstance) GetVariationName(campaignKey, userID string, option inte
 			userID: Unique identification of user
 			customVariables(In option): variables for pre-segmentation
 			variationTargetingVariables(In option): variables for variation targeting
+			bucketingKey(In option): identity hashed instead of the user ID to bucket the user
+			alwaysCheckSegment(In option): re-evaluate the segments for users with a stored variation
+			context(In option): context passed to the user storage
 			revenueValue(In option): Value of revenue for the goal if the goal is revenue tracking
 
 		Returns:
@@ -51,12 +54,18 @@ func (vwo *VWOInstance) GetVariationName(campaignKey, userID string, option inte
 	*/
 
 	vwoInstance := schema.VwoInstance{
-		SettingsFile:      vwo.SettingsFile,
-		UserStorage:       vwo.UserStorage,
-		Logger:            vwo.Logger,
-		IsDevelopmentMode: vwo.IsDevelopmentMode,
-		API:               "GetVariationName",
-		Integrations:      vwo.Integrations,
+		SettingsFile:             vwo.SettingsFile,
+		UserStorage:              vwo.UserStorage,
+		Logger:                   vwo.Logger,
+		IsDevelopmentMode:        vwo.IsDevelopmentMode,
+		API:                      "GetVariationName",
+		Integrations:             vwo.Integrations,
+		ForcedVariations:         vwo.ForcedVariations,
+		Overrides:                vwo.Overrides,
+		Holdout:                  vwo.Holdout,
+		Clock:                    vwo.Clock,
+		IsStickyBucketingEnabled: vwo.IsStickyBucketingEnabled,
+		AssignmentRecorder:       vwo.AssignmentRecorder,
 	}
 
 	if !utils.ValidateGetVariationName(campaignKey, userID) {
@@ -73,6 +82,7 @@ func (vwo *VWOInstance) GetVariationName(campaignKey, userID string, option inte
 		utils.LogMessage(vwo.Logger, constants.Error, getVariationName, message)
 		return ""
 	}
+	campaign = vwoInstance.Overrides.ApplyToCampaign(campaign)
 
 	if campaign.Status != constants.StatusRunning {
 		message := fmt.Sprintf(constants.ErrorMessageCampaignNotRunning, vwoInstance.API, campaignKey)
@@ -21,7 +21,6 @@ import (
 
 	"github.com/wingify/vwo-go-sdk/pkg/constants"
 	"github.com/wingify/vwo-go-sdk/pkg/core"
-	"github.com/wingify/vwo-go-sdk/pkg/event"
 	"github.com/wingify/vwo-go-sdk/pkg/schema"
 	"github.com/wingify/vwo-go-sdk/pkg/utils"
 )
@@ -46,6 +45,10 @@ func (vwo *VWOInstance) IsFeatureEnabled(campaignKey, userID string, option inte
 			userID: Unique identification of user
 			customVariables(In option): variables for pre-segmentation
 			variationTargetingVariables(In option): variables for variation targeting
+			bucketingKey(In option): identity hashed instead of the user ID to bucket the user
+			alwaysCheckSegment(In option): re-evaluate the segments for users with a stored variation
+			context(In option): context passed to the user storage
+			trackingConsent(In option): false to evaluate the campaign without sending any impression
 			revenueValue(In option): Value of revenue for the goal if the goal is revenue tracking
 
 		Returns:
@@ -53,12 +56,18 @@ func (vwo *VWOInstance) IsFeatureEnabled(campaignKey, userID string, option inte
 	*/
 
 	vwoInstance := schema.VwoInstance{
-		SettingsFile:      vwo.SettingsFile,
-		UserStorage:       vwo.UserStorage,
-		Logger:            vwo.Logger,
-		IsDevelopmentMode: vwo.IsDevelopmentMode,
-		API:               "IsFeatureEnabled",
-		Integrations:      vwo.Integrations,
+		SettingsFile:             vwo.SettingsFile,
+		UserStorage:              vwo.UserStorage,
+		Logger:                   vwo.Logger,
+		IsDevelopmentMode:        vwo.IsDevelopmentMode,
+		API:                      "IsFeatureEnabled",
+		Integrations:             vwo.Integrations,
+		ForcedVariations:         vwo.ForcedVariations,
+		Overrides:                vwo.Overrides,
+		Holdout:                  vwo.Holdout,
+		Clock:                    vwo.Clock,
+		IsStickyBucketingEnabled: vwo.IsStickyBucketingEnabled,
+		AssignmentRecorder:       vwo.AssignmentRecorder,
 	}
 
 	if !utils.ValidateIsFeatureEnabled(campaignKey, userID) {
@@ -75,6 +84,7 @@ func (vwo *VWOInstance) IsFeatureEnabled(campaignKey, userID string, option inte
 		utils.LogMessage(vwo.Logger, constants.Error, fileIsFeatureEnabled, message)
 		return false
 	}
+	campaign = vwoInstance.Overrides.ApplyToCampaign(campaign)
 
 	if campaign.Status != constants.StatusRunning {
 		message := fmt.Sprintf(constants.ErrorMessageCampaignNotRunning, vwoInstance.API, campaignKey)
@@ -97,11 +107,12 @@ func (vwo *VWOInstance) IsFeatureEnabled(campaignKey, userID string, option inte
 	isFeatureEnabled := false
 	if utils.CheckCampaignType(campaign, constants.CampaignTypeFeatureTest) {
 		isFeatureEnabled = variation.IsFeatureEnabled
-		impression := utils.CreateImpressionTrackingUser(vwoInstance, campaign.ID, variation.ID, userID)
-		if vwo.IsBatchingEnabled {
-			vwo.AddToBatch(impression)
-		} else {
-			go event.Dispatch(vwoInstance, impression)
+		if options.NoTrackingConsent {
+			message := fmt.Sprintf(constants.InfoMessageNoTrackingConsent, vwoInstance.API, userID)
+			utils.LogMessage(vwo.Logger, constants.Info, fileIsFeatureEnabled, message)
+		} else if !core.IsUserInCampaignHoldout(vwoInstance, userID, campaign, options) {
+			impression := utils.CreateImpressionTrackingUser(vwoInstance, campaign.ID, variation.ID, userID)
+			vwo.dispatchEvent(vwoInstance.API, userID, "", impression)
 		}
 	} else if utils.CheckCampaignType(campaign, constants.CampaignTypeFeatureRollout) {
 		isFeatureEnabled = true
@@ -20,7 +20,6 @@ import (
 	"fmt"
 
 	"github.com/wingify/vwo-go-sdk/pkg/constants"
-	"github.com/wingify/vwo-go-sdk/pkg/event"
 	"github.com/wingify/vwo-go-sdk/pkg/schema"
 	"github.com/wingify/vwo-go-sdk/pkg/utils"
 )
@@ -32,14 +31,15 @@ const push = "push.go"
 This API method: Pushes the key-value tag pair for a particular user
 1. Validates the arguments being passed
 2. Checks the length of tag Key and Value
-3. Sends a call to VWO push api
+3. Sends a call to VWO push api, unless the user did not consent to tracking
 */
-func (vwo *VWOInstance) Push(tagKey, tagValue, userID string) bool {
+func (vwo *VWOInstance) Push(tagKey, tagValue, userID string, option ...interface{}) bool {
 	/*
 		Args:
 			tagKey: Key of the corresponding tag
 			tagValue: Value of the corresponding tag
 			userID: Unique identification of user
+			trackingConsent(In option): false to not send the tag
 
 		Returns:
 			bool: true if the push api call is done, else false
@@ -71,13 +71,19 @@ func (vwo *VWOInstance) Push(tagKey, tagValue, userID string) bool {
 		return false
 	}
 
-	impression := utils.CreateImpressionForPush(vwoInstance, tagKey, tagValue, userID)
-	if vwo.IsBatchingEnabled {
-		vwo.AddToBatch(impression)
-	} else {
-		go event.Dispatch(vwoInstance, impression)
+	var options schema.Options
+	if len(option) > 0 {
+		options = utils.ParseOptions(option[0])
+	}
+	if options.NoTrackingConsent {
+		message := fmt.Sprintf(constants.InfoMessageNoTrackingConsent, vwoInstance.API, userID)
+		utils.LogMessage(vwo.Logger, constants.Info, push, message)
+		return false
 	}
 
+	impression := utils.CreateImpressionForPush(vwoInstance, tagKey, tagValue, userID)
+	vwo.dispatchEvent(vwoInstance.API, userID, "", impression)
+
 	message := fmt.Sprintf(constants.InfoMessageMainKeysForPushAPI, vwoInstance.API, vwoInstance.SettingsFile.AccountID, userID, impression.U, impression.URL)
 	utils.LogMessage(vwo.Logger, constants.Info, push, message)
 
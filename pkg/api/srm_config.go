@@ -0,0 +1,41 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+)
+
+// SRMConfig struct configures the runtime sample ratio mismatch monitoring
+type SRMConfig struct {
+	CheckInterval   int
+	PValueThreshold float64
+	MinSampleSize   int
+}
+
+// SetDefaults sets the default value of every field which is not set
+func (config *SRMConfig) SetDefaults() {
+	if config.CheckInterval < 1 {
+		config.CheckInterval = constants.SRMDefaultCheckInterval
+	}
+	if config.PValueThreshold <= 0 || config.PValueThreshold >= 1 {
+		config.PValueThreshold = constants.SRMPValueThreshold
+	}
+	if config.MinSampleSize < 1 {
+		config.MinSampleSize = constants.SRMDefaultMinSampleSize
+	}
+}
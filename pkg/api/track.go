@@ -22,7 +22,6 @@ import (
 
 	"github.com/wingify/vwo-go-sdk/pkg/constants"
 	"github.com/wingify/vwo-go-sdk/pkg/core"
-	"github.com/wingify/vwo-go-sdk/pkg/event"
 	"github.com/wingify/vwo-go-sdk/pkg/schema"
 	"github.com/wingify/vwo-go-sdk/pkg/utils"
 )
@@ -40,6 +39,7 @@ This API method: Marks the conversion of the campaign for a particular goal
 6. Validates Revenue and Goal type
 7. Assigns the determinitic variation to the user(based on userId), if user becomes part of campaign
    If userStorageService is used, it will look into it for the variation and if found, no further processing is done
+   If several campaigns are tracked and the storage implements schema.BatchUserStorage, the user's data is fetched at once
 8. If feature enabled, sends a call to VWO server for tracking visitor
 */
 func (vwo *VWOInstance) Track(campaignKeys interface{}, userID, goalIdentifier string, option interface{}) []schema.TrackResult {
@@ -50,6 +50,10 @@ func (vwo *VWOInstance) Track(campaignKeys interface{}, userID, goalIdentifier s
 			goalIdentifier: Unique identification of corresponding goal
 			customVariables(In option): variables for pre-segmentation
 			variationTargetingVariables(In option): variables for variation targeting
+			bucketingKey(In option): identity hashed instead of the user ID to bucket the user
+			alwaysCheckSegment(In option): re-evaluate the segments for users with a stored variation
+			context(In option): context passed to the user storage
+			trackingConsent(In option): false to evaluate the campaign without sending any impression
 			revenueValue(In option): Value of revenue for the goal if the goal is revenue tracking
 
 		Returns:
@@ -65,6 +69,13 @@ func (vwo *VWOInstance) Track(campaignKeys interface{}, userID, goalIdentifier s
 		GoalTypeToTrack:          vwo.GoalTypeToTrack,
 		ShouldTrackReturningUser: vwo.ShouldTrackReturningUser,
 		Integrations:             vwo.Integrations,
+		ForcedVariations:         vwo.ForcedVariations,
+		Overrides:                vwo.Overrides,
+		Holdout:                  vwo.Holdout,
+		Clock:                    vwo.Clock,
+		IsStickyBucketingEnabled: vwo.IsStickyBucketingEnabled,
+		AssignmentRecorder:       vwo.AssignmentRecorder,
+		ConversionStore:          vwo.ConversionStore,
 	}
 
 	options := utils.ParseOptions(option)
@@ -137,6 +148,13 @@ func (vwo *VWOInstance) Track(campaignKeys interface{}, userID, goalIdentifier s
 		}
 	}
 
+	if len(Campaigns) > 1 {
+		if storage, ok := core.PrefetchUserStorage(vwoInstance, userID, Campaigns, options); ok {
+			vwoInstance.UserStorage = storage
+			defer storage.Flush(options)
+		}
+	}
+
 	var result []schema.TrackResult
 
 	for _, campaign := range Campaigns {
@@ -164,12 +182,18 @@ func trackCampaignGoal(vwo *VWOInstance, vwoInstance schema.VwoInstance, campaig
 			goalIdentifier: Unique identification of corresponding goal
 			customVariables(In option): variables for pre-segmentation
 			variationTargetingVariables(In option): variables for variation targeting
+			bucketingKey(In option): identity hashed instead of the user ID to bucket the user
+			alwaysCheckSegment(In option): re-evaluate the segments for users with a stored variation
+			context(In option): context passed to the user storage
+			trackingConsent(In option): false to evaluate the campaign without sending any impression
 			revenueValue(In option): Value of revenue for the goal if the goal is revenue tracking
 
 		Returns:
 			bool: True if the track is successfull else false
 	*/
 
+	campaign = vwoInstance.Overrides.ApplyToCampaign(campaign)
+
 	if campaign.Status != constants.StatusRunning {
 		message := fmt.Sprintf(constants.ErrorMessageCampaignNotRunning, vwoInstance.API, campaign.Key)
 		utils.LogMessage(vwoInstance.Logger, constants.Error, track, message)
@@ -201,15 +225,50 @@ func trackCampaignGoal(vwo *VWOInstance, vwoInstance schema.VwoInstance, campaig
 		return false
 	}
 
-	variation, storedGoalIdentifier, err := core.GetVariation(vwoInstance, userID, campaign, goalIdentifier, options)
+	if core.IsUserInCampaignHoldout(vwoInstance, userID, campaign, options) {
+		message := fmt.Sprintf(constants.InfoMessageUserInHoldout, vwoInstance.API, userID, campaign.Key)
+		utils.LogMessage(vwoInstance.Logger, constants.Info, track, message)
+		return false
+	}
+
+	variation, userData, isForced, err := core.GetVariationAndUserData(vwoInstance, userID, campaign, goalIdentifier, options)
 	if err != nil {
 		message := fmt.Sprintf(constants.InfoMessageInvalidVariationKey, vwoInstance.API, userID, campaign.Key, err.Error())
 		utils.LogMessage(vwoInstance.Logger, constants.Info, track, message)
 		return false
 	}
 
+	if variation.Name != "" && options.NoTrackingConsent {
+		// the conversion is not kept, the goal is tracked once the user consents
+		message := fmt.Sprintf(constants.InfoMessageNoTrackingConsent, vwoInstance.API, userID)
+		utils.LogMessage(vwoInstance.Logger, constants.Info, track, message)
+		return false
+	}
+
 	if variation.Name != "" {
-		if storedGoalIdentifier != "" {
+		// a forced variation is never stored, the user gets the bucketed one back once it is no longer forced
+		isStored := userData.VariationName != "" || isForced
+		if !isStored {
+			userData = core.NewUserData(vwoInstance, userID, campaign, variation, "")
+		}
+		storedGoalIdentifier := userData.GoalIdentifier
+		if vwoInstance.ConversionStore != nil {
+			// the goals tracked before the conversion store was set are still in the user storage data,
+			// they are moved to the store when the user converts on them again
+			isTracked := hasGoalIdentifier(storedGoalIdentifier, goalIdentifier)
+			if (!core.AddConversion(vwoInstance, userID, campaign, goalIdentifier, options) || isTracked) && !shouldTrackReturningUser {
+				message := fmt.Sprintf(constants.InfoMessagesGoalAlreadyTracked, vwoInstance.API, goalIdentifier, campaign.Key, userID)
+				utils.LogMessage(vwoInstance.Logger, constants.Info, track, message)
+				return false
+			}
+			if !isStored {
+				core.SetUserStorageData(vwoInstance, userData, options)
+			}
+		} else if isForced {
+			// the goals of a forced user are tracked on every call as they are not stored
+			message := fmt.Sprintf(constants.DebugMessageForcedVariationNotStored, vwoInstance.API, variation.Name, userID, campaign.Key)
+			utils.LogMessage(vwoInstance.Logger, constants.Debug, track, message)
+		} else if storedGoalIdentifier != "" {
 			identifiers := strings.Split(storedGoalIdentifier, constants.GoalIdentifierSeperator)
 			flag := false
 
@@ -222,41 +281,20 @@ func trackCampaignGoal(vwo *VWOInstance, vwoInstance schema.VwoInstance, campaig
 			if flag == false {
 				storedGoalIdentifier = storedGoalIdentifier + constants.GoalIdentifierSeperator + goalIdentifier
 
-				if storage, ok := vwoInstance.UserStorage.(interface{ Set(a, b, c, d string) }); ok {
-					storage.Set(userID, campaign.Key, variation.Name, storedGoalIdentifier)
-					message := fmt.Sprintf(constants.InfoMessageSettingDataUserStorageService, vwoInstance.API, userID)
-					utils.LogMessage(vwoInstance.Logger, constants.Info, track, message)
-				} else {
-					message := fmt.Sprintf(constants.ErrorMessageSetUserStorageServiceFailed, vwoInstance.API, userID)
-					utils.LogMessage(vwoInstance.Logger, constants.Debug, track, message)
-				}
+				userData.GoalIdentifier = storedGoalIdentifier
+				core.SetUserStorageData(vwoInstance, userData, options)
 			} else if shouldTrackReturningUser == false {
 				message := fmt.Sprintf(constants.InfoMessagesGoalAlreadyTracked, vwoInstance.API, goalIdentifier, campaign.Key, userID)
 				utils.LogMessage(vwoInstance.Logger, constants.Info, track, message)
 				return false
 			}
 		} else {
-			if vwoInstance.UserStorage == nil {
-				message := fmt.Sprintf(constants.DebugMessageNoUserStorageServiceSet, vwoInstance.API)
-				utils.LogMessage(vwoInstance.Logger, constants.Warning, track, message)
-			} else {
-				if storage, ok := vwoInstance.UserStorage.(interface{ Set(a, b, c, d string) }); ok {
-					storage.Set(userID, campaign.Key, variation.Name, goalIdentifier)
-					message := fmt.Sprintf(constants.InfoMessageSettingDataUserStorageService, vwoInstance.API, userID)
-					utils.LogMessage(vwoInstance.Logger, constants.Info, track, message)
-				} else {
-					message := fmt.Sprintf(constants.ErrorMessageSetUserStorageServiceFailed, vwoInstance.API, userID)
-					utils.LogMessage(vwoInstance.Logger, constants.Debug, track, message)
-				}
-			}
+			userData.GoalIdentifier = goalIdentifier
+			core.SetUserStorageData(vwoInstance, userData, options)
 		}
 
 		impression := utils.CreateImpressionTrackingGoal(vwoInstance, variation.ID, userID, goal.Type, campaign.ID, goal.ID, options.RevenueValue)
-		if vwo.IsBatchingEnabled {
-			vwo.AddToBatch(impression)
-		} else {
-			go event.DispatchTrackingGoal(vwoInstance, goal.Type, impression)
-		}
+		vwo.dispatchEvent(vwoInstance.API, userID, goal.Type, impression)
 
 		message := fmt.Sprintf(constants.InfoMessageMainKeysForImpression, vwoInstance.API, vwoInstance.SettingsFile.AccountID, vwoInstance.UserID, campaign.ID, variation.ID)
 		utils.LogMessage(vwoInstance.Logger, constants.Info, activate, message)
@@ -266,3 +304,13 @@ func trackCampaignGoal(vwo *VWOInstance, vwoInstance schema.VwoInstance, campaig
 
 	return false
 }
+
+// hasGoalIdentifier function returns true if the goal is one of the goals stored in the user storage data
+func hasGoalIdentifier(storedGoalIdentifier, goalIdentifier string) bool {
+	for _, identifier := range strings.Split(storedGoalIdentifier, constants.GoalIdentifierSeperator) {
+		if identifier == goalIdentifier {
+			return true
+		}
+	}
+	return false
+}
@@ -17,6 +17,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"log"
@@ -26,6 +27,7 @@ import (
 	"github.com/wingify/vwo-go-sdk/pkg/constants"
 	"github.com/wingify/vwo-go-sdk/pkg/logger"
 	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/storage"
 	"github.com/wingify/vwo-go-sdk/pkg/utils"
 	"github.com/stretchr/testify/assert"
 )
@@ -358,3 +360,126 @@ func TestTrack(t *testing.T) {
 	}
 	assertOutput.Equal(expected, value, "Incorrect Track Result Value")
 }
+
+func TestTrackHoldout(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	vwoInstance.SettingsFile.Campaigns[0].Variations = utils.GetVariationAllocationRanges(vwoInstance, vwoInstance.SettingsFile.Campaigns[0].Variations)
+	vwoInstance.SettingsFile.Holdout = &schema.Holdout{PercentTraffic: 100}
+
+	instance := VWOInstance{}
+	instance.SettingsFile = vwoInstance.SettingsFile
+	instance.Logger = vwoInstance.Logger
+
+	userID, campaignKey := testdata.GetRandomUser(), "AB_T_100_W_50_50"
+	assertOutput.Equal("Control", instance.Activate(campaignKey, userID, nil))
+	result := instance.Track(campaignKey, userID, testdata.ValidGoal, nil)
+	assertOutput.False(result[0].TrackValue, "Held out users should not be tracked")
+
+	assertOutput.Nil(instance.SetForcedVariation(userID, campaignKey, "Variation-1"))
+	assertOutput.Equal("Variation-1", instance.Activate(campaignKey, userID, nil), "Forced variation should take precedence over the holdout")
+	result = instance.Track(campaignKey, userID, testdata.ValidGoal, nil)
+	assertOutput.True(result[0].TrackValue, "Users with a forced variation should be tracked")
+
+	_, err := instance.Init(WithHoldout(101))
+	assertOutput.NotNil(err, "Invalid holdout")
+}
+
+type countingBatchUserStorage struct {
+	records  map[string]schema.UserData
+	gets     int
+	sets     int
+	getManys int
+	setManys int
+}
+
+func (storage *countingBatchUserStorage) Get(ctx context.Context, userID, campaignKey string) (schema.UserData, error) {
+	storage.gets++
+	return storage.records[campaignKey], nil
+}
+
+func (storage *countingBatchUserStorage) Set(ctx context.Context, userData schema.UserData) error {
+	storage.sets++
+	storage.records[userData.CampaignKey] = userData
+	return nil
+}
+
+func (storage *countingBatchUserStorage) GetMany(ctx context.Context, userID string, campaignKeys []string) (map[string]schema.UserData, error) {
+	storage.getManys++
+	return storage.records, nil
+}
+
+func (storage *countingBatchUserStorage) SetMany(ctx context.Context, userDatas []schema.UserData) error {
+	storage.setManys++
+	for _, userData := range userDatas {
+		storage.records[userData.CampaignKey] = userData
+	}
+	return nil
+}
+
+func TestTrackBatchUserStorage(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	first := vwoInstance.SettingsFile.Campaigns[0]
+	first.Variations = utils.GetVariationAllocationRanges(vwoInstance, first.Variations)
+	second := first
+	second.Key, second.ID = "AB_T_100_W_50_50_COPY", first.ID+1
+
+	storage := &countingBatchUserStorage{records: make(map[string]schema.UserData)}
+	instance := VWOInstance{}
+	instance.SettingsFile = schema.SettingsFile{Campaigns: []schema.Campaign{first, second}}
+	instance.Logger = vwoInstance.Logger
+	instance.UserStorage = storage
+
+	userID := testdata.GetRandomUser()
+	result := instance.Track([]string{first.Key, second.Key}, userID, testdata.ValidGoal, nil)
+	assertOutput.Len(result, 2)
+	assertOutput.True(result[0].TrackValue)
+	assertOutput.True(result[1].TrackValue)
+	assertOutput.Equal(1, storage.getManys)
+	assertOutput.Equal(1, storage.setManys)
+	assertOutput.Equal(0, storage.gets)
+	assertOutput.Equal(0, storage.sets)
+	assertOutput.Len(storage.records, 2)
+
+	result = instance.Track(first.Key, userID, testdata.ValidGoal, nil)
+	assertOutput.Len(result, 1)
+	assertOutput.Equal(1, storage.getManys, "A single campaign is fetched with Get")
+	assertOutput.Equal(1, storage.gets)
+}
+
+func TestTrackConversionStore(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, campaign.Variations)
+	goalIdentifier := testdata.ValidGoal
+
+	userStorage := &countingBatchUserStorage{records: make(map[string]schema.UserData)}
+	instance := VWOInstance{}
+	instance.SettingsFile = schema.SettingsFile{Campaigns: []schema.Campaign{campaign}}
+	instance.Logger = vwoInstance.Logger
+	vwo, err := instance.Init(WithStorage(userStorage), WithConversionStore(storage.NewMemoryConversionStore(0, 0)))
+	assertOutput.Nil(err)
+
+	userID := testdata.GetRandomUser()
+	result := vwo.Track(campaign.Key, userID, goalIdentifier, nil)
+	assertOutput.True(result[0].TrackValue)
+	assertOutput.Empty(userStorage.records[campaign.Key].GoalIdentifier, "Goals should not be appended to the user storage data")
+	assertOutput.NotEmpty(userStorage.records[campaign.Key].VariationName)
+
+	result = vwo.Track(campaign.Key, userID, goalIdentifier, nil)
+	assertOutput.False(result[0].TrackValue, "Returning user should not be tracked")
+
+	result = vwo.Track(campaign.Key, userID, goalIdentifier, map[string]interface{}{"shouldTrackReturningUser": true})
+	assertOutput.True(result[0].TrackValue)
+
+	// the goals tracked before the conversion store was set are kept
+	userID = testdata.GetRandomUser() + "-converted"
+	variation := campaign.Variations[0]
+	userStorage.records[campaign.Key] = schema.UserData{UserID: userID, CampaignKey: campaign.Key, VariationName: variation.Name, GoalIdentifier: "other" + constants.GoalIdentifierSeperator + goalIdentifier}
+	result = vwo.Track(campaign.Key, userID, goalIdentifier, nil)
+	assertOutput.False(result[0].TrackValue, "User converted before the conversion store was set should not be tracked")
+	_, ok, _ := vwo.ConversionStore.GetConversion(context.Background(), userID, campaign.Key, goalIdentifier)
+	assertOutput.True(ok, "Conversion should be moved to the conversion store")
+}
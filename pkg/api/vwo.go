@@ -17,13 +17,17 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"time"
 
 	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/core"
 	"github.com/wingify/vwo-go-sdk/pkg/logger"
 	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/service"
 	"github.com/wingify/vwo-go-sdk/pkg/utils"
 )
 
@@ -64,12 +68,85 @@ func (vwo VWOInstance) Init(vwoOption ...VWOOption) (*VWOInstance, error) {
 	if !utils.ValidateStorage(vwo.UserStorage) {
 		return &vwo, fmt.Errorf(constants.ErrorMessageInvalidLoggerStorage, "")
 	}
+	if _, ok := vwo.UserStorage.(schema.LegacyUserStorage); ok && schema.IsLegacyUserStorage(vwo.UserStorage) {
+		utils.LogMessage(vwo.Logger, constants.Warning, fileVWO, constants.WarningMessageLegacyUserStorage)
+	}
+	// the storage is kept as is and adapted where it is used, so that its optional methods are still found
+
+	if vwo.Holdout != nil && (vwo.Holdout.PercentTraffic < 0 || vwo.Holdout.PercentTraffic > constants.MaxTrafficPercent) {
+		return &vwo, fmt.Errorf(constants.ErrorMessageInvalidHoldout, vwo.Holdout.PercentTraffic)
+	}
+
+	if vwo.ForcedVariations == nil {
+		vwo.ForcedVariations = &schema.ForcedVariations{}
+	}
+	if vwo.ForcedVariationsFile != "" {
+		if err := vwo.LoadForcedVariations(vwo.ForcedVariationsFile); err != nil {
+			return &vwo, err
+		}
+	}
+
+	var overridesFileManager *service.OverridesFileManager
+	if vwo.OverridesFile != "" {
+		vwo.Overrides = &schema.Overrides{}
+		overridesFileManager = &service.OverridesFileManager{
+			Path:      vwo.OverridesFile,
+			Overrides: vwo.Overrides,
+			Logger:    vwo.Logger,
+		}
+		if err := overridesFileManager.Load(); err != nil {
+			return &vwo, err
+		}
+	}
+
+	if vwo.EventDispatcher == nil {
+		vwo.EventDispatcher = vwo.getEventDispatcher()
+	} else {
+		vwo.EventDispatcher = vwo.withEventRetry(vwo.EventDispatcher)
+	}
+	vwo.ActiveEventDispatcher = &schema.EventDispatcherHolder{}
+	vwo.ActiveEventDispatcher.Store(vwo.EventDispatcher)
 
 	if vwo.IsBatchingEnabled {
+		if vwo.BatchEventQueue == nil {
+			vwo.BatchEventQueue = &schema.BatchEventQueue{}
+		}
+		vwo.BatchEventQueue.Dispatcher = vwo.EventDispatcher
 		vwo.BatchEventQueue.AccountID = vwo.SettingsFile.AccountID
 		vwo.BatchEventQueue.SDKKey = vwo.SettingsFile.SDKKey
 		vwo.BatchEventQueue.IsDevelopmentMode = vwo.IsDevelopmentMode
 		vwo.BatchEventQueue.Logger = vwo.Logger
+		vwo.BatchEventQueue.EventLog = vwo.EventLog
+		if err := vwo.BatchEventQueue.Start(vwo.EventDispatcher); err != nil {
+			return &vwo, err
+		}
+	}
+
+	// the worker pool fails before starting its workers, it is started last so that no later error leaves it running
+	if !vwo.IsBatchingEnabled {
+		if vwo.EventWorkerPool == nil {
+			vwo.EventWorkerPool = &schema.WorkerPool{}
+		}
+		vwo.EventWorkerPool.Logger = vwo.Logger
+		if err := vwo.EventWorkerPool.Start(vwo.EventDispatcher); err != nil {
+			return &vwo, err
+		}
+	}
+
+	// the background goroutines are started once nothing can fail anymore, so a failed Init does not leak them
+	if overridesFileManager != nil {
+		pollInterval := vwo.OverridesPollInterval
+		if pollInterval < 1 {
+			pollInterval = constants.OverridesDefaultPollInterval
+		}
+		go overridesFileManager.Watch(time.Duration(pollInterval) * time.Second)
+	}
+
+	if monitor, ok := vwo.AssignmentRecorder.(*core.SRMMonitor); ok {
+		if monitor.Logger == nil {
+			monitor.Logger = vwo.Logger
+		}
+		go monitor.Start(time.Duration(vwo.SRMCheckInterval) * time.Second)
 	}
 
 	message := fmt.Sprintf(constants.DebugMessageDevelopmentMode+constants.DebugMessageSDKInitialized, vwo.IsDevelopmentMode)
@@ -78,13 +155,30 @@ func (vwo VWOInstance) Init(vwoOption ...VWOOption) (*VWOInstance, error) {
 	return &vwo, nil
 }
 
-// WithStorage sets user storage
+// WithStorage sets user storage, it must implement schema.UserStorage or the deprecated schema.LegacyUserStorage
 func WithStorage(storage interface{}) VWOOption {
 	return func(vwo *VWOInstance) {
 		vwo.UserStorage = storage
 	}
 }
 
+// WithConversionStore sets the store remembering the goals every user converted on, the goals are then no longer
+// appended to the GoalIdentifier of the user storage data. No conversion store is set by default, as an in-memory one
+// would lose the conversions kept by a persistent user storage
+func WithConversionStore(store schema.ConversionStore) VWOOption {
+	return func(vwo *VWOInstance) {
+		vwo.ConversionStore = store
+	}
+}
+
+// WithEventLog persists the impressions of the batch event queue to the log until they are sent, the impressions
+// not sent before the last shutdown are replayed on launch. It is only used along with WithBatchEventQueue
+func WithEventLog(eventLog schema.EventLog) VWOOption {
+	return func(vwo *VWOInstance) {
+		vwo.EventLog = eventLog
+	}
+}
+
 // WithLogger sets user custom logger
 func WithLogger(logger interface{}) VWOOption {
 	return func(vwo *VWOInstance) {
@@ -107,12 +201,118 @@ func WithGoalAttributes(goalTypeToTrack interface{}, shouldTrackReturningUser in
 	}
 }
 
+// WithForcedVariationsFile loads forced variations from the given local JSON file on launch
+func WithForcedVariationsFile(path string) VWOOption {
+	return func(vwo *VWOInstance) {
+		vwo.ForcedVariationsFile = path
+	}
+}
+
+// WithOverridesFile applies campaign overrides from the given local JSON or YAML file and reloads it when it changes,
+// checking for changes every pollInterval seconds
+func WithOverridesFile(path string, pollInterval int) VWOOption {
+	return func(vwo *VWOInstance) {
+		vwo.OverridesFile = path
+		vwo.OverridesPollInterval = pollInterval
+	}
+}
+
+// WithHoldout keeps the given percentage of users out of every campaign, it takes precedence over the holdout in the settings file
+func WithHoldout(percentTraffic int) VWOOption {
+	return func(vwo *VWOInstance) {
+		vwo.Holdout = &schema.Holdout{PercentTraffic: percentTraffic}
+	}
+}
+
+// WithClock sets the clock used to evaluate campaign schedules and traffic ramps, the system time is used by default
+func WithClock(clock func() time.Time) VWOOption {
+	return func(vwo *VWOInstance) {
+		vwo.Clock = clock
+	}
+}
+
+// WithStickyBucketing buckets users into variations independently of the campaign traffic,
+// so users already part of a campaign keep their variation when its percentTraffic changes
+func WithStickyBucketing() VWOOption {
+	return func(vwo *VWOInstance) {
+		vwo.IsStickyBucketingEnabled = true
+	}
+}
+
+// WithSRMMonitor counts the live bucketing decisions and checks them for sample ratio mismatch every config.CheckInterval seconds,
+// callBack is called for every mismatch found, a warning is logged if it is nil
+func WithSRMMonitor(config SRMConfig, callBack func(core.SRMAlert)) VWOOption {
+	return func(vwo *VWOInstance) {
+		config.SetDefaults()
+		vwo.AssignmentRecorder = core.NewSRMMonitor(config.PValueThreshold, config.MinSampleSize, callBack, nil)
+		vwo.SRMCheckInterval = config.CheckInterval
+	}
+}
+
+// StopSRMMonitor stops the periodic sample ratio mismatch checks
+func (vwo *VWOInstance) StopSRMMonitor() {
+	if monitor, ok := vwo.AssignmentRecorder.(*core.SRMMonitor); ok {
+		monitor.Stop()
+	}
+}
+
+// StopOverrides stops reloading the overrides file, the overrides loaded last keep being applied
+func (vwo *VWOInstance) StopOverrides() {
+	vwo.Overrides.Stop()
+}
+
+// Close stops reloading the overrides file and monitoring the sample ratio mismatch, flushes the batched events
+// and waits for the events being dispatched until the context is done. An *schema.UndeliveredEventsError reports
+// the events which could not be delivered, the instance must not be used anymore
+func (vwo *VWOInstance) Close(ctx context.Context) error {
+	vwo.StopOverrides()
+	vwo.StopSRMMonitor()
+
+	var errs []error
+	if vwo.IsBatchingEnabled {
+		errs = append(errs, vwo.BatchEventQueue.Close(ctx))
+	}
+	if vwo.EventWorkerPool != nil {
+		errs = append(errs, vwo.EventWorkerPool.Close(ctx))
+	}
+
+	var undelivered *schema.UndeliveredEventsError
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if undelivered == nil {
+			undelivered = &schema.UndeliveredEventsError{}
+		}
+		if undeliveredErr, ok := err.(*schema.UndeliveredEventsError); ok {
+			undelivered.Events = append(undelivered.Events, undeliveredErr.Events...)
+			undelivered.InFlight += undeliveredErr.InFlight
+			err = undeliveredErr.Err
+		}
+		if undelivered.Err == nil {
+			undelivered.Err = err
+		}
+	}
+	if undelivered != nil {
+		utils.LogMessage(vwo.Logger, constants.Error, fileVWO, undelivered.Error())
+		return undelivered
+	}
+	utils.LogMessage(vwo.Logger, constants.Info, fileVWO, constants.InfoMessageSDKClosed)
+	return nil
+}
+
+// AddToBatch queues the impression in the batch event queue, the queue of an instance not created with Init
+// is started by its first impression
 func (vwoInstance *VWOInstance) AddToBatch(impression schema.Impression) {
-	vwoInstance.BatchEventQueue.AddToBatch(impression, schema.VwoInstance(*vwoInstance))
+	if err := vwoInstance.BatchEventQueue.Start(vwoInstance.getEventDispatcher()); err != nil {
+		utils.LogMessage(vwoInstance.Logger, constants.Error, fileVWO, err.Error())
+	}
+	vwoInstance.BatchEventQueue.AddToBatch(impression)
 }
 
+// FlushEvents sends the queued impressions and waits for their dispatch
 func (vwoInstance *VWOInstance) FlushEvents() {
-	vwoInstance.BatchEventQueue.FlushBatch(schema.VwoInstance(*vwoInstance))
+	vwoInstance.BatchEventQueue.Flush()
 }
 
 func WithBatchEventQueue(batchConfig BatchConfig, flushCallBack func(error, []map[string]interface{})) VWOOption {
@@ -126,7 +326,7 @@ func WithBatchEventQueue(batchConfig BatchConfig, flushCallBack func(error, []ma
 			log.Println(fmt.Sprintf(constants.DebugMessageInvalidRequestTimeInterval, constants.BatchMinRequestInterval, constants.BatchMaxEventsPerRequest, constants.BatchDefaultRequestInterval))
 			batchConfig.SetDefaults()
 		}
-		vwo.BatchEventQueue = schema.BatchEventQueue{
+		vwo.BatchEventQueue = &schema.BatchEventQueue{
 			RequestTimeInterval: utils.Max(batchConfig.RequestTimeInterval, constants.BatchMinRequestInterval),
 			EventsPerRequest:    utils.Min(batchConfig.EventsPerRequest, constants.BatchMaxEventsPerRequest),
 			FlushCallBack:       flushCallBack,
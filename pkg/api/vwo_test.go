@@ -17,14 +17,22 @@
 package api
 
 import (
+	"context"
 	"io/ioutil"
 	"log"
+	"os"
+	"runtime"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/core"
 	"github.com/wingify/vwo-go-sdk/pkg/logger"
 	"github.com/wingify/vwo-go-sdk/pkg/schema"
 	"github.com/wingify/vwo-go-sdk/pkg/service"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -73,3 +81,148 @@ func TestInit(t *testing.T) {
 	_, err = vwoInstance.Init(WithLogger(logs))
 	assert.Nil(t, err)
 }
+
+func TestWithOverridesFile(t *testing.T) {
+	assertOutput := assert.New(t)
+
+	file, _ := ioutil.TempFile("", "overrides*.json")
+	defer os.Remove(file.Name())
+	file.WriteString(`{"campaigns": {
+		"FT_T_100_W_10_20_30_40": {"forcedVariation": "Variation-1", "variables": {"STRING_VARIABLE": "overridden"}},
+		"AB_T_100_W_50_50": {"status": "PAUSED"},
+		"AB_T_50_W_50_50": {"percentTraffic": 0}
+	}}`)
+	file.Close()
+
+	settingsFile := schema.SettingsFile{}
+	for _, campaignKey := range []string{"FT_T_100_W_10_20_30_40", "AB_T_100_W_50_50", "AB_T_50_W_50_50"} {
+		vwoInstance := testdata.GetInstanceWithSettings(campaignKey)
+		campaign := vwoInstance.SettingsFile.Campaigns[0]
+		campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, campaign.Variations)
+		settingsFile.Campaigns = append(settingsFile.Campaigns, campaign)
+	}
+
+	instance := VWOInstance{}
+	instance.SettingsFile = settingsFile
+	vwo, err := instance.Init(WithOverridesFile(file.Name(), 1))
+	assertOutput.Nil(err)
+	defer vwo.StopOverrides()
+
+	for i := 0; i < 10; i++ {
+		userID := testdata.GetRandomUser()
+		assertOutput.Equal("overridden", vwo.GetFeatureVariableValue("FT_T_100_W_10_20_30_40", "STRING_VARIABLE", userID, nil))
+		assertOutput.Equal(float64(456), vwo.GetFeatureVariableValue("FT_T_100_W_10_20_30_40", "INTEGER_VARIABLE", userID, nil), "Variation-1 is forced")
+		assertOutput.Empty(vwo.Activate("AB_T_100_W_50_50", userID, nil), "Campaign is paused")
+		assertOutput.Empty(vwo.Activate("AB_T_50_W_50_50", userID, nil), "Campaign traffic is capped to 0")
+	}
+	assertOutput.Equal(100, settingsFile.Campaigns[1].PercentTraffic, "Settings file should not be modified")
+
+	instance = VWOInstance{}
+	_, err = instance.Init(WithOverridesFile(testdata.InvalidSettingsFile, 1))
+	assertOutput.NotNil(err, "Invalid overrides file")
+}
+
+func TestWithClock(t *testing.T) {
+	assertOutput := assert.New(t)
+
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, campaign.Variations)
+	endTime := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+	campaign.EndTime = &endTime
+
+	currentTime := endTime.Add(-time.Hour)
+	instance := VWOInstance{}
+	instance.SettingsFile = schema.SettingsFile{Campaigns: []schema.Campaign{campaign}}
+	vwo, err := instance.Init(WithClock(func() time.Time { return currentTime }))
+	assertOutput.Nil(err)
+
+	userID := testdata.GetRandomUser()
+	assertOutput.NotEmpty(vwo.GetVariationName(campaign.Key, userID, nil))
+
+	currentTime = endTime
+	assertOutput.Empty(vwo.GetVariationName(campaign.Key, userID, nil), "Campaign has ended")
+}
+
+func TestWithSRMMonitor(t *testing.T) {
+	assertOutput := assert.New(t)
+
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, campaign.Variations)
+	// the users are bucketed 50/50 while the monitor expects 90/10
+	campaign.Variations[0].Weight = 90
+	campaign.Variations[1].Weight = 10
+
+	instance := VWOInstance{}
+	instance.SettingsFile = schema.SettingsFile{Campaigns: []schema.Campaign{campaign}}
+	instance.Logger = vwoInstance.Logger
+	vwo, err := instance.Init(WithSRMMonitor(SRMConfig{CheckInterval: 3600, MinSampleSize: 10}, nil))
+	assertOutput.Nil(err)
+	defer vwo.StopSRMMonitor()
+
+	monitor, ok := vwo.AssignmentRecorder.(*core.SRMMonitor)
+	assertOutput.True(ok)
+	assertOutput.Equal(constants.SRMPValueThreshold, monitor.PValueThreshold)
+	assertOutput.Equal(3600, vwo.SRMCheckInterval)
+
+	// the same user is counted once
+	for i := 0; i < 100; i++ {
+		assertOutput.NotEmpty(vwo.GetVariationName(campaign.Key, testdata.ValidUser, nil))
+	}
+	assertOutput.Empty(monitor.Check())
+
+	for i := 0; i < 100; i++ {
+		assertOutput.NotEmpty(vwo.GetVariationName(campaign.Key, "user-"+strconv.Itoa(i), nil))
+	}
+	alerts := monitor.Check()
+	assertOutput.Len(alerts, 1)
+	assertOutput.Equal(constants.SRMTypeVariations, alerts[0].Type)
+}
+
+func TestInitFailureStartsNoGoroutine(t *testing.T) {
+	assertOutput := assert.New(t)
+
+	file, _ := ioutil.TempFile("", "overrides*.json")
+	defer os.Remove(file.Name())
+	file.WriteString(`{"campaigns": {}}`)
+	file.Close()
+
+	goroutines := runtime.NumGoroutine()
+	instance := VWOInstance{}
+	_, err := instance.Init(
+		WithOverridesFile(file.Name(), 1),
+		WithSRMMonitor(SRMConfig{CheckInterval: 3600}, nil),
+		WithWorkerPool(1, 1, "invalid"),
+	)
+	assertOutput.NotNil(err, "Invalid overflow policy")
+	assertOutput.True(runtime.NumGoroutine() <= goroutines, "Failed Init should not leave the overrides watcher or the SRM monitor running")
+}
+
+// deletableLegacyStorage is a LegacyUserStorage able to delete the data of a user
+type deletableLegacyStorage struct {
+	testdata.UserStorageData
+	deleted []string
+}
+
+func (storage *deletableLegacyStorage) Delete(ctx context.Context, userID string) error {
+	storage.deleted = append(storage.deleted, userID)
+	return nil
+}
+
+func TestInitWithLegacyStorage(t *testing.T) {
+	assertOutput := assert.New(t)
+
+	instance := VWOInstance{}
+	instance.Logger = testdata.GetInstanceWithSettings("AB_T_100_W_50_50").Logger
+	legacyStorage := &deletableLegacyStorage{}
+	vwo, err := instance.Init(WithStorage(legacyStorage))
+	assertOutput.Nil(err)
+	assertOutput.Equal(legacyStorage, vwo.UserStorage, "Legacy storage should be kept as is")
+	assertOutput.Nil(vwo.ForgetUser("user"))
+	assertOutput.Equal([]string{"user"}, legacyStorage.deleted, "Optional methods of the legacy storage should be used")
+
+	instance = VWOInstance{}
+	_, err = instance.Init(WithStorage(&testdata.IncorrectUserStorageData{}))
+	assertOutput.NotNil(err)
+}
@@ -25,6 +25,8 @@ const (
 	SDKName           = "vwo-go-sdk"
 	Platform          = "server"
 	SeedValue         = 1
+	HoldoutSeedValue  = 7919
+	StickySeedValue   = 104729
 
 	CampaignTypeVisualAB       = "VISUAL_AB"
 	CampaignTypeFeatureTest    = "FEATURE_TEST"
@@ -87,7 +89,9 @@ const (
 	EventsTrackGoal = "EVENTS_TRACK_GOAL"
 	EventsPush      = "EVENTS_PUSH"
 
-	HttpPostMethod = "POST"
+	HttpPostMethod     = "POST"
+	HttpGetMethod      = "GET"
+	HttpDefaultTimeout = 10
 
 	BatchMinEventsPerRequest = 1
 	BatchMaxEventsPerRequest = 5000
@@ -95,8 +99,48 @@ const (
 
 	BatchDefaultEventsPerRequest = 100
 	BatchDefaultRequestInterval  = 600
+	BatchMaxPendingBatches       = 10
+	BatchMaxQueuedEvents         = 10000
 
 	CampaignDecisionType = "CAMPAIGN_DECISION"
+
+	OverridesDefaultPollInterval = 2
+
+	BucketingChangeSampleSize = 10000
+	SRMPValueThreshold        = 0.001
+	SRMDefaultCheckInterval   = 60
+	SRMDefaultMinSampleSize   = 1000
+	SRMTypeTraffic            = "TRAFFIC"
+	SRMTypeVariations         = "VARIATIONS"
+
+	MemoryStorageShards = 16
+
+	FileStorageSyncAlways                = "always"
+	FileStorageSyncInterval              = "interval"
+	FileStorageSyncNone                  = "none"
+	FileStorageDefaultSyncInterval       = 1
+	FileStorageDefaultCompactionInterval = 300
+	FileStorageCompactionRatio           = 2
+
+	RedisDefaultAddress     = "localhost:6379"
+	RedisDefaultPoolSize    = 10
+	RedisDefaultDialTimeout = 5
+	RedisKeyPrefix          = "vwo"
+	RedisDefaultIOTimeout   = 5
+
+	EventDefaultMaxRetries     = 3
+	EventDefaultInitialBackoff = 1
+	EventDefaultMaxBackoff     = 30
+
+	EventLogDefaultMaxBytes        = 64 << 20
+	EventLogDefaultMaxSegmentBytes = 4 << 20
+	EventLogSegmentExtension       = ".wal"
+
+	WorkerPoolDefaultWorkers     = 10
+	WorkerPoolDefaultQueueSize   = 10000
+	WorkerPoolOverflowBlock      = "block"
+	WorkerPoolOverflowDropOldest = "drop-oldest"
+	WorkerPoolOverflowDropNewest = "drop-newest"
 )
 
 var EventTypeMapping = map[string]int{
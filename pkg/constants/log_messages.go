@@ -19,6 +19,10 @@ package constants
 // constants for logger
 const (
 	//Debug Messages
+	DebugMessageBucketingKeyUsed                = "[%v] User ID: %v of CampaignKey: %v is bucketed with bucketingKey: %v "
+	DebugMessageUserStoragePrefetched           = "[%v] Prefetched %v stored variations of User ID: %v for %v campaigns from UserStorageService"
+	DebugMessageUserAlreadyConverted            = "[%v] User ID: %v already converted on goal: %v of CampaignKey: %v at %v"
+	DebugMessageForcedVariationNotStored        = "[%v] Forced variation: %v of User ID: %v for CampaignKey: %v is not stored "
 	DebugMessageCustomLoggerUsed                = "Custom logger used"
 	DebugMessageDevelopmentMode                 = "Development mode is : %v "
 	DebugMessageGettingStoredVariation          = "[%v] Got User Storage, Checking stored variation for User ID: %v of Campaign: %v "
@@ -27,6 +31,7 @@ const (
 	DebugMessageImpressionForTrackCustomGoal    = "[%v] impression built for track goal -  AccountID: %v, UserID: %v, SID: %v, URL: %v, ExperimentID: %v, Combination: %v, GoalID: %v "
 	DebugMessageImpressionForTrackRevenueGoal   = "[%v] impression built for track goal -  AccountID: %v, UserID: %v, SID: %v, URL: %v, ExperimentID: %v, Combination: %v, GoalID: %v, RevenueValue: %v "
 	DebugMessageImpressionForTrackUser          = "[%v] impression built for track user - AccountID: %v, UserID: %v, SID: %v, URL: %v, ExperimentID: %v, Combination: %v, ED: %v"
+	DebugMessageRampStepReached                 = "[%v] CampaignKey: %v reached ramp step of time: %v with percent traffic: %v "
 	DebugMessageSDKInitialized                  = "SDK properly initialized"
 	DebugMessageNoCustomLoggerFound             = "No custom logger found, using pre-defined google logger "
 	DebugMessageNoStoredVariation               = "[%v] No stored variation for User ID: %v for Campaign: %v found in UserStorageService"
@@ -35,11 +40,13 @@ const (
 	DebugMessageSegmentationSkippedForVariation = "[%v] For User ID: %v of CampaignKey: %v Variation Targeting variables are missing, hence skipping segmentation for variation %v "
 	DebugMessageSegmentationStatusForVariation  = "[%v] For User ID: %v of Campaign: %v with Variation Targeting variables: %v, Segments: %v, %v, %v for variation %v "
 	DebugMessageUserHashBucketValue             = "[%v] User ID: %v having hash: %v got bucketValue: %v "
+	DebugMessageUserHoldoutBucketValue          = "[%v] User ID: %v having holdout hash: %v got bucketValue: %v "
 	DebugMessageUserNotPartOfCampaign           = "[%v] User ID: %v for CampaignKey: %v type: %v did not become part of campaign method: %v "
 	DebugMessageUUIDForUser                     = "[%v] Uuid generated for User ID: %v and accountId: %v is %v "
 	DebugMessageVariationHashBucketValue        = "[%v] User ID: %v for CampaignKey: %v having percent traffic: %v got bucket value: %v "
 	DebugBeforeBatchFlush                       = "Flushing events queue having length : %v for account: %v"
 	DebugAfterBatchFlush                        = "Events queue having %v events has been flushed "
+	DebugMessageEventRetry                      = "Retrying %v events in %v, retry %v of %v, Error: %v"
 	DebugMessagePayloadTooLarge                 = "Impression event - %v failed due to exceeding payload size. Parameter eventsPerRequest in batchEvents config in launch API has value: %v. Please read the official documentation for knowing the size limits."
 	DebugMessageInvalidRequestTimeInterval      = "requestTimeInterval hould be > %v and <= %v. Assigning it the default value i.e %v seconds"
 	DebugMessageInvalidEventsPerRequest         = "eventsPerRequest should be >= %v and <= %v. Assigning it the default value i.e %v"
@@ -55,15 +62,27 @@ const (
 	ErrorMessageCustomLoggerMisconfigured               = "Custom logger is provided but seems to have misconfigured. Please check the API Docs. Using default logger."
 	ErrorMessageGetFeatureVariableMissingParams         = "[%v] getFeatureVariableValue API got bad parameters. It expects campaignKey(String) as first, variableKey(String) as second, User ID(String) as third, and options as fourth argument"
 	ErrorMessageGetUserStorageServiceFailed             = "[%v] Getting data from UserStorageService failed for User ID: %v "
+	ErrorMessageGetUserStorageServiceError              = "[%v] Getting data from UserStorageService failed for User ID: %v and CampaignKey: %v, Error: %v "
 	ErrorMessageGetVariationAPIMissingParams            = "[%v] getVariation API got bad parameters. It expects campaignKey(String) as first, User ID(String) as second and options(Optional) as third argument"
 	ErrorMessageImpressionFailed                        = "[%v] Impression event could not be sent to VWO endpoint: %v "
+	ErrorMessageImpressionDropped                       = "[%v] Impression event of user: %v dropped, the event worker pool queue is full or closed "
 	ErrorMessageInvalidAPI                              = "[%v] API is not valid for Campaign: %v of type: %v for User ID: %v "
 	ErrorMessageIsFeatureEnabledAPIMissingParams        = "[%v] isFeatureEnabled API got bad parameters. It expects Campaign(String) as first, User ID(String) as second and options(Optional) as third argument"
 	ErrorMessageNoCampaignInCampaignList                = "[%v] No campaign found as per the required attributes : %v %v "
 	ErrorMessageNoCampaignFoundForGoal                  = "[%v] No campaign found for Goal Identifier: %v with goal type to track : %v. Please verify from VWO app."
 	ErrorMessagePushAPIMissingParams                    = "[%v] push API got bad parameters. It expects tagKey(String) as first, tagKey(String) as second and User ID(String) as third argument"
+	ErrorMessageForgetUserAPIMissingParams              = "[%v] forgetUser API got bad parameters. It expects User ID(String) as argument"
 	ErrorMessageSettingsFileCorrupted                   = "[%v] Settings file is corrupted. Please contact VWO Support for help : %v "
 	ErrorMessageSetUserStorageServiceFailed             = "[%v] Error while saving data into UserStorage for User ID: %v."
+	ErrorMessageSetUserStorageServiceError              = "[%v] Error while saving data into UserStorage for User ID: %v and CampaignKey: %v, Error: %v "
+	ErrorMessageConversionStoreFailed                   = "[%v] Saving the conversion of User ID: %v for CampaignKey: %v and goal: %v into the ConversionStore failed, Error: %v "
+	ErrorMessageStorageDeleteFailed                     = "[%v] Deleting the data of User ID: %v from the %v failed, Error: %v "
+	ErrorMessageStorageNotDeletable                     = "[%v] %v does not implement Delete(ctx, userID), the data of User ID: %v can not be deleted"
+	ErrorMessageFileStorageFailed                       = "Syncing or compacting the user storage file: %v failed, Error: %v "
+	ErrorMessageFileStorageClosed                       = "User storage file: %v is closed"
+	ErrorMessageInvalidFileStorageSyncMode              = "Invalid user storage file sync mode: %v, it must be always, interval or none"
+	ErrorMessageRedisInvalidReply                       = "Invalid reply received from Redis"
+	ErrorMessageRedisUnexpectedReply                    = "Unexpected reply: %v received from Redis for command: %v"
 	ErrorMessageTagKeyLengthExceeded                    = "[%v] Length of tagKey: %v for User ID: %v can not be greater than 255"
 	ErrorMessageTagValueLengthExceeded                  = "[%v] Length of value: %v of tagKey: %v for User ID: %v can not be greater than 255"
 	ErrorMessageTrackAPIGoalNotFound                    = "[%v] Goal: %v not found for Campaign: %v and User ID: %v : %v "
@@ -81,11 +100,13 @@ const (
 	ErrorMessageInvalidCampaignKeyType                    = "[%v] Campaign key should only be of type nil, array of strings or string not : %T"
 	ErrorMessageInvalidGoalType                           = "[%v] Invalid goal type, Goal type to track is : %v but recieved goal type : %v"
 	ErrorMessageInvalidLoggerStorage                      = "[%v] Invalid storage object/Logger given. Refer documentation on how to pass custom storage."
+	ErrorMessageInvalidHoldout                            = "Holdout percentTraffic: %v is invalid, it must be between 0 and 100"
 	ErrorMessageInvalidSDKKey                             = "[%v] SDKKey is required for fetching account settings. Aborting"
 	ErrorMessageInvalidSettingsFile                       = "[%v] Settings-file fetched is not proper : %v "
 	ErrorMessageNoVariationAlloted                        = "[%v] User ID: %v of CampaignKey: %v type: %v did not get any variation "
 	ErrorMessageNoVariationForBucketValue                 = "[%v] No variation found for user ID %v in campaignKey: %v having bucket value: %v "
 	ErrorMessageNoVariationInCampaign                     = "[%v] No variations in campaign: %v "
+	ErrorMessageNoTrafficAllotted                         = "[%v] No traffic allotted to CampaignKey: %v at the current time"
 	ErrorMessageResponseNotParsed                         = "[%v] Error parsing response for URL: %v "
 	ErrorMessageTrackAPIEmptyParam                        = "Empty %v"
 	ErrorMessageTrackAPIIncorrectParamType                = "Incorrect data type for %v"
@@ -95,32 +116,65 @@ const (
 	ErrorMessageVariationNotFound                         = "[%v] Variation : %v not found in campaign : %v "
 	ErrorMessageBatchImpressionFailed                     = "Impression event could not be sent to VWO endpoint - %v. Status code: %v"
 	ErrorMessageBatchFlushError                           = "Error encountered in batch flush: %v"
+	ErrorMessageNoEventDispatcher                         = "no EventDispatcher set, the events are dropped"
+	ErrorMessageBatchQueueClosed                          = "the batch event queue is closed, the event is dropped"
+	ErrorMessageBatchDropped                              = "Impression event dropped, %v events are already waiting to be sent"
+	ErrorMessageEventsDeadLettered                        = "%v events could not be sent after %v retries, Error: %v"
+	ErrorMessageEventLogFull                              = "Event log: %v is full, its %v bytes cap is reached"
+	ErrorMessageEventLogClosed                            = "Event log: %v is closed"
+	ErrorMessageEventLogFailed                            = "Event log %v failed, Error: %v"
+	ErrorMessageInvalidOverflowPolicy                     = "Invalid event worker pool overflow policy: %v, it must be block, drop-oldest or drop-newest"
+	ErrorMessageEventsUndelivered                         = "%v events could not be delivered and %v were still being sent on close, Error: %v"
+	ErrorMessageSegmentExpressionInvalid                  = "Invalid segment expression at position %v : %v"
+	ErrorMessageSegmentFormatFailed                       = "Segments could not be formatted as an expression : %v"
+	ErrorMessageForcedVariationMissingParams              = "[%v] forced variation API got bad parameters. It expects User ID(String), campaignKey(String) and variationName(String)"
+	ErrorMessageForcedVariationsFileInvalid               = "[%v] Forced variations file: %v could not be loaded : %v "
+	ErrorMessageOverridesFileInvalid                      = "Overrides file: %v could not be loaded, previous overrides are kept : %v "
 
 	//Info Messages
+	InfoMessageCampaignNotScheduled             = "[%v] CampaignKey: %v is scheduled from startTime: %v to endTime: %v, it is not running at: %v "
 	InfoMessageFeatureEnabledForUser            = "[%v] Campaign: %v for user ID: %v is enabled"
 	InfoMessageFeatureNotEnabledForUser         = "[%v] Campaign: %v for user ID: %v is not enabled"
 	InfoMessageForcedvariationAllocated         = "[%v] User ID: %v of CampaignKey: %v type: %v got forced-variation: %v "
+	InfoMessageForcedVariationSet               = "[%v] Variation: %v forced for User ID: %v of CampaignKey: %v "
+	InfoMessageForcedVariationCleared           = "[%v] Forced variation cleared for User ID: %v of CampaignKey: %v "
+	InfoMessageOverridesFileLoaded              = "Overrides file: %v loaded with overrides for %v campaigns"
+	InfoMessageOverridesFileMissing             = "Overrides file: %v not found, no overrides are applied"
 	InfoMessagesGoalAlreadyTracked              = "[%v] Goal: %v of Campaign: %v for User ID:%v has already been tracked earlier. Skipping now."
 	InfoMessageGettingDataUserStorageService    = "[%v] Getting data into UserStorageService for User ID: %v successful"
 	InfoMessageGotStoredVariation               = "[%v] Got stored variation: %v of CampaignKey: %v for User ID: %v from UserStorage"
+	InfoMessageUserDataMigrated                 = "[%v] Stored data of User ID: %v for CampaignKey: %v migrated to variation: %v with ID: %v"
+	InfoMessageStoredUserFailedSegmentation     = "[%v] User ID: %v no longer satisfies the segments of CampaignKey: %v, stored variation not returned"
 	InfoMessageGotVariationForUser              = "[%v] User ID: %v for CampaignKey: %v type: %v got variation_name: %v "
 	InfoMessageImpressionSuccess                = "[%v] Impression event - %v was successfully received by VWO having keys: %v "
 	InfoMessageIncorrectCampaignKeyType         = "[%v] Incorrect CampaignKey type passed : %T is incorrect, should be of type string, array of string or nil"
 	InfoMessageInvalidVariationKey              = "[%v] Variation was not assigned to User ID: %v for Campaign: %v : %v "
 	InfoMessageMainKeysForFeatureTestImpression = "[%v] Having main keys AccountID: %v, UserID: %v, CampaignID: %v, VariationID: %v"
 	InfoMessageMainKeysForPushAPI               = "[%v] Having main keys: AccountID: %v User ID: %v U: %v and tags: %v "
+	InfoMessageUserForgotten                    = "[%v] Data of User ID: %v deleted and %v queued impressions removed"
+	InfoMessageNoTrackingConsent                = "[%v] User ID: %v did not consent to tracking, no impression sent"
 	InfoMessageMainKeysForImpression            = "[%v] Having main keys: AccountID: %v User ID: %v campaignId: %v and VariationID: %v "
 	InfoMessageNoUserStorageServiceGet          = "[%v] No UserStorageService to get stored data"
 	InfoMessageSegmentationStatus               = "[%v] For User ID: %v of Campaign: %v with Segments: %v, Custom Variables: %v, %v, %v "
 	InfoMessageSegmentationStatusForVariation   = "[%v] For User ID: %v of Campaign: %v with Segments: %v, Variation targeting Variables: %v, %v, %v for variation %v "
 	InfoMessageSettingDataUserStorageService    = "[%v] Setting data into UserStorageService for User ID: %v successful"
+	InfoMessageFileStorageCompacted             = "User storage file: %v compacted from %v to %v records"
+	InfoMessageEventsReplayed                   = "%v events not sent before the last shutdown are replayed from the event log"
+	InfoMessageEventsKeptInEventLog             = "%v events could not be sent, they are kept in the event log and replayed on the next launch"
+	InfoMessageSDKClosed                        = "SDK instance closed, all the events were delivered"
 	InfoMessageUserEligibilityForCampaign       = "[%v] Is User ID: %v part of campaign ? %v "
+	InfoMessageUserInHoldout                    = "[%v] User ID: %v is in the holdout group, CampaignKey: %v is not evaluated and no impression is sent"
 	InfoMessageUserGotNoVariation               = "[%v] User ID: %v for Campaign: %v did not allot any variation : %v "
 	InfoMessageVariationAllocated               = "[%v] User ID: %v of Campaign: %v got variation: %v "
 	InfoMessageVariationRangeAllocation         = "[%v] Variation: %v with weight: %v got range as: ( %v - %v )"
 	InfoMessageWhitelistingSkipped              = "[%v] For User ID: %v of Campaign: %v, whitelisting was skipped"
 	InfoSDKInstanceUpdated                      = "vwo-sdk instance is updated with the latest settings-file for the accountId: %v"
 	InfoBatchImpressionSuccess                  = "Impression event - %v was successfully received by VWO"
+
+	//Warning Messages
+	WarningMessageSampleRatioMismatch = "Sample ratio mismatch detected for CampaignKey: %v type: %v with p-value: %v, observed: %v expected: %v "
+	WarningMessageLegacyUserStorage   = "UserStorage implements the deprecated Get(userID, campaignKey) and Set(userID, campaignKey, variationName, goalIdentifier) methods, implement schema.UserStorage to report storage errors "
+
 	/*Extras*/
 	InfoMessageNoTargettedVariation      = "[%v] No targetted variation found : %v "
 	InfoMessageNoWhitelistedVariation    = "[%v] No whitelisting variation found in campaign: %v "
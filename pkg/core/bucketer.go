@@ -67,12 +67,28 @@ func GetBucketValueForUser(vwoInstance schema.VwoInstance, userID string, maxVal
 		Returns:
 			int: the bucket value allotted to User (between 1 to MAX_TRAFFIC_PERCENT)
 	*/
+	return GetBucketValueForUserWithSeed(vwoInstance, userID, maxValue, multiplier, campaign, constants.SeedValue)
+}
+
+// GetBucketValueForUserWithSeed returns Bucket Value of the user by hashing the userId with murmur hash using the given seed
+func GetBucketValueForUserWithSeed(vwoInstance schema.VwoInstance, userID string, maxValue,
+	multiplier float64, campaign schema.Campaign, seed uint32) (uint32, int) {
+	/*
+		Args:
+			userID: the unique ID assigned to User
+			maxValue: maximum value that can be alloted to the bucket value
+			multiplier: value for distributing ranges slightly
+			seed: seed of the murmur hash
+
+		Returns:
+			int: the bucket value allotted to User (between 1 to maxValue)
+	*/
 	if campaign.IsBucketingSeedEnabled {
 		var campaignId = strconv.Itoa(campaign.ID) //to convert campaign Id to string to append to userId
 		userID = campaignId + "_" + userID
 	}
 
-	hashValue := hash(userID) & umax32Bit
+	hashValue := hashWithSeed(userID, seed) & umax32Bit
 	ratio := float64(hashValue) / math.Pow(2, 32)
 	multipliedValue := (maxValue*ratio + 1) * multiplier
 	bucketValue := int(math.Floor(multipliedValue))
@@ -85,7 +101,23 @@ func IsUserPartOfCampaign(vwoInstance schema.VwoInstance, userID string, campaig
 	/*
 		Args:
 			userID: the unique ID assigned to a user
-			campaign: for getting traffic allotted to the campaign
+			campaign: for getting traffic allotted to the campaign at the current time
+
+		Returns:
+			bool: if User is a part of Campaign or not
+	*/
+
+	return IsUserPartOfCampaignWithTraffic(vwoInstance, userID, campaign, GetEffectivePercentTraffic(vwoInstance, campaign))
+}
+
+// IsUserPartOfCampaignWithTraffic calculates if the provided userID should become part of the campaign or not
+// for the given traffic allotted to the campaign
+func IsUserPartOfCampaignWithTraffic(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign, percentTraffic int) bool {
+	/*
+		Args:
+			userID: the unique ID assigned to a user
+			campaign: the campaign the user may become part of
+			percentTraffic: traffic allotted to the campaign, see GetEffectivePercentTraffic
 
 		Returns:
 			bool: if User is a part of Campaign or not
@@ -99,7 +131,7 @@ func IsUserPartOfCampaign(vwoInstance schema.VwoInstance, userID string, campaig
 	message := fmt.Sprintf(constants.DebugMessageUserHashBucketValue, vwoInstance.API, userID, hashValue, valueAssignedToUser)
 	utils.LogMessage(vwoInstance.Logger, constants.Debug, bucketer, message)
 
-	isUserPart := valueAssignedToUser != 0 && valueAssignedToUser <= campaign.PercentTraffic
+	isUserPart := valueAssignedToUser != 0 && valueAssignedToUser <= percentTraffic
 
 	message = fmt.Sprintf(constants.InfoMessageUserEligibilityForCampaign, vwoInstance.API, userID, isUserPart)
 	utils.LogMessage(vwoInstance.Logger, constants.Info, bucketer, message)
@@ -119,21 +151,78 @@ func BucketUserToVariation(vwoInstance schema.VwoInstance, userID string, campai
 			error: if no variation found, else nil
 	*/
 
+	return BucketUserToVariationWithTraffic(vwoInstance, userID, campaign, GetEffectivePercentTraffic(vwoInstance, campaign))
+}
+
+// BucketUserToVariationWithTraffic returns the Variation into which the User is bucketed in for the given traffic
+// allotted to the campaign, which must be the one the User was found part of the campaign with
+func BucketUserToVariationWithTraffic(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign, percentTraffic int) (schema.Variation, error) {
+	/*
+		Args:
+		    userID: the unique ID assigned to User
+		    campaign: the Campaign of which User is a part of
+		    percentTraffic: traffic allotted to the campaign, see GetEffectivePercentTraffic
+
+		Returns:
+			schema.Variation: variation data into which user is bucketed in
+			error: if no variation found or no traffic is allotted, else nil
+	*/
+
 	if len(campaign.Variations) == 0 {
 		return schema.Variation{}, fmt.Errorf(constants.ErrorMessageNoVariationInCampaign, vwoInstance.API, campaign.Key)
 	}
-	multiplier := (float64(constants.MaxTrafficValue) / float64(campaign.PercentTraffic)) / 100
-	_, bucketValue := GetBucketValueForUser(vwoInstance, userID, constants.MaxTrafficValue, multiplier, campaign)
+	if percentTraffic <= 0 {
+		return schema.Variation{}, fmt.Errorf(constants.ErrorMessageNoTrafficAllotted, vwoInstance.API, campaign.Key)
+	}
+	var bucketValue int
+	if vwoInstance.IsStickyBucketingEnabled {
+		// hashing with an independent seed keeps the variation of the user the same whatever the traffic is
+		_, bucketValue = GetBucketValueForUserWithSeed(vwoInstance, userID, constants.MaxTrafficValue, 1, campaign, constants.StickySeedValue)
+	} else {
+		multiplier := (float64(constants.MaxTrafficValue) / float64(percentTraffic)) / 100
+		_, bucketValue = GetBucketValueForUser(vwoInstance, userID, constants.MaxTrafficValue, multiplier, campaign)
+	}
 
-	message := fmt.Sprintf(constants.DebugMessageVariationHashBucketValue, vwoInstance.API, userID, campaign.Key, campaign.PercentTraffic, bucketValue)
+	message := fmt.Sprintf(constants.DebugMessageVariationHashBucketValue, vwoInstance.API, userID, campaign.Key, percentTraffic, bucketValue)
 	utils.LogMessage(vwoInstance.Logger, constants.Debug, bucketer, message)
 
 	return GetBucketerVariation(vwoInstance, campaign.Variations, bucketValue, userID, campaign.Key)
 }
 
+// IsUserInHoldout calculates if the provided bucketingKey is part of the global holdout group and must be kept out of every campaign
+func IsUserInHoldout(vwoInstance schema.VwoInstance, bucketingKey string) bool {
+	/*
+		Args:
+			bucketingKey: the identity hashed to bucket the user, see GetBucketingKey
+
+		Returns:
+			bool: if User is in the holdout group or not
+	*/
+
+	holdout := vwoInstance.Holdout
+	if holdout == nil {
+		holdout = vwoInstance.SettingsFile.Holdout
+	}
+	if holdout == nil || holdout.PercentTraffic <= 0 {
+		return false
+	}
+
+	hashValue, valueAssignedToUser := GetBucketValueForUserWithSeed(vwoInstance, bucketingKey, constants.MaxTrafficPercent, 1, schema.Campaign{}, constants.HoldoutSeedValue)
+
+	message := fmt.Sprintf(constants.DebugMessageUserHoldoutBucketValue, vwoInstance.API, bucketingKey, hashValue, valueAssignedToUser)
+	utils.LogMessage(vwoInstance.Logger, constants.Debug, bucketer, message)
+
+	return valueAssignedToUser <= holdout.PercentTraffic
+}
+
 // hash function generates hash value for given string using murmur hash
 func hash(s string) uint32 {
-	hasher := murmur3.New32WithSeed(uint32(constants.SeedValue))
+	return hashWithSeed(s, constants.SeedValue)
+}
+
+// hashWithSeed function generates hash value for given string using murmur hash with the given seed
+func hashWithSeed(s string, seed uint32) uint32 {
+	hasher := murmur3.New32WithSeed(seed)
 	hasher.Write([]byte(s))
 	return hasher.Sum32()
 }
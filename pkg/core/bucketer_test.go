@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"log"
+	"strconv"
 	"testing"
 
 	"github.com/wingify/vwo-go-sdk/pkg/constants"
@@ -58,6 +59,23 @@ func TestBucketUserToVariation(t *testing.T) {
 	assertOutput.Empty(actual, "Variation expected to be empty")
 }
 
+func TestBucketUserToVariationWithTraffic(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	vwoInstance.SettingsFile.Campaigns[0].Variations = utils.GetVariationAllocationRanges(vwoInstance, vwoInstance.SettingsFile.Campaigns[0].Variations)
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	assertOutput.True(IsUserPartOfCampaignWithTraffic(vwoInstance, testdata.InvalidUser, campaign, 100), "Given traffic should be used")
+	actual, err := BucketUserToVariationWithTraffic(vwoInstance, testdata.InvalidUser, campaign, 100)
+	assertOutput.Nil(err)
+	assertOutput.NotEmpty(actual.Name)
+
+	assertOutput.False(IsUserPartOfCampaignWithTraffic(vwoInstance, testdata.ValidUser, campaign, 0))
+	actual, err = BucketUserToVariationWithTraffic(vwoInstance, testdata.ValidUser, campaign, 0)
+	assertOutput.NotNil(err, "No user should be bucketed without traffic")
+	assertOutput.Empty(actual)
+}
+
 func TestGetBucketerVariation(t *testing.T) {
 	assertOutput := assert.New(t)
 	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_33_33_33")
@@ -164,3 +182,26 @@ func TestHash(t *testing.T) {
 	actual := hash(testdata.GetRandomUser())
 	assert.NotNil(t, actual, "Hash values do not match")
 }
+
+func TestIsUserInHoldout(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	userID := testdata.GetRandomUser()
+
+	assertOutput.False(IsUserInHoldout(vwoInstance, userID), "No holdout is configured")
+
+	vwoInstance.SettingsFile.Holdout = &schema.Holdout{PercentTraffic: 100}
+	assertOutput.True(IsUserInHoldout(vwoInstance, userID), "Every user is held out")
+
+	vwoInstance.Holdout = &schema.Holdout{PercentTraffic: 0}
+	assertOutput.False(IsUserInHoldout(vwoInstance, userID), "Instance holdout should take precedence")
+
+	vwoInstance.Holdout = &schema.Holdout{PercentTraffic: 20}
+	heldOut := 0
+	for i := 0; i < 1000; i++ {
+		if IsUserInHoldout(vwoInstance, "user-"+strconv.Itoa(i)) {
+			heldOut++
+		}
+	}
+	assertOutput.InDelta(200, heldOut, 50)
+}
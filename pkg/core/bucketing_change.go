@@ -0,0 +1,54 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import "github.com/wingify/vwo-go-sdk/pkg/schema"
+
+// BucketingChange struct reports how users would be reassigned by a change of the campaign settings
+type BucketingChange struct {
+	Users            int
+	Included         int
+	Switched         int
+	Joined           int
+	SwitchedFraction float64
+}
+
+// GetBucketingChange function buckets users with the current and proposed settings of a campaign and reports
+// the fraction of users part of the current campaign who would get a different variation or leave the campaign
+func GetBucketingChange(vwoInstance schema.VwoInstance, current, proposed schema.Campaign, userIDs []string) BucketingChange {
+	/*
+		Args:
+			current: campaign with the settings in use
+			proposed: campaign with the proposed settings
+			userIDs: users to bucket, a synthetic sample is used if empty
+
+		Returns:
+			BucketingChange: counts of users included, switched and joined, segments are not evaluated
+	*/
+
+	impact := analyzeCampaign(vwoInstance, current, proposed, getSampleUserIDs(userIDs, 0))
+	change := BucketingChange{
+		Users:    impact.Users,
+		Included: impact.Keep + impact.Lose + impact.Change,
+		Switched: impact.Lose + impact.Change,
+		Joined:   impact.Gain,
+	}
+	if change.Included > 0 {
+		change.SwitchedFraction = float64(change.Switched) / float64(change.Included)
+	}
+	return change
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+func TestGetBucketingChange(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	current := vwoInstance.SettingsFile.Campaigns[0]
+	proposed := current
+	proposed.PercentTraffic = 100
+
+	change := GetBucketingChange(vwoInstance, current, proposed, nil)
+	assertOutput.Equal(10000, change.Users)
+	assertOutput.InDelta(5000, change.Included, 200)
+	assertOutput.InDelta(5000, change.Joined, 200)
+	assertOutput.True(change.SwitchedFraction > 0.2, "Raising traffic shifts bucket values of users")
+
+	vwoInstance.IsStickyBucketingEnabled = true
+	change = GetBucketingChange(vwoInstance, current, proposed, nil)
+	assertOutput.Equal(0, change.Switched, "Sticky bucketing keeps variations when raising traffic")
+	assertOutput.Equal(0.0, change.SwitchedFraction)
+	assertOutput.InDelta(5000, change.Joined, 200)
+
+	change = GetBucketingChange(vwoInstance, proposed, current, nil)
+	assertOutput.InDelta(0.5, change.SwitchedFraction, 0.03, "Lowering traffic removes users")
+	assertOutput.Equal(0, change.Joined)
+
+	userIDs := []string{testdata.ValidUser, testdata.InvalidUser}
+	change = GetBucketingChange(vwoInstance, current, current, userIDs)
+	assertOutput.Equal(2, change.Users)
+	assertOutput.Equal(0, change.Switched)
+}
+
+func TestStickyBucketUserToVariation(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_20_80")
+	vwoInstance.IsStickyBucketingEnabled = true
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, campaign.Variations)
+
+	counts := make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		counts[getBucketedVariationName(vwoInstance, "user-"+strconv.Itoa(i), campaign)]++
+	}
+	assertOutput.InDelta(400, counts["Control"], 80)
+	assertOutput.InDelta(1600, counts["Variation-1"], 80)
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+const conversion = "conversion.go"
+
+// AddConversion function saves the conversion of the user on the goal in the conversion store, it returns false if the user
+// already converted on it. A failing store is logged and the conversion is considered the first one
+func AddConversion(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign, goalIdentifier string, options schema.Options) bool {
+	/*
+		Args:
+			userID: the unique ID assigned to User
+			campaign: campaign in which user converted
+			goalIdentifier: goal the user converted on
+			options: the context(In option) is passed to the conversion store
+
+		Returns:
+			bool: true if it is the first conversion of the user on the goal
+	*/
+
+	first, isFirst, err := vwoInstance.ConversionStore.AddConversion(getContext(options), schema.Conversion{
+		UserID:           userID,
+		CampaignKey:      campaign.Key,
+		GoalIdentifier:   goalIdentifier,
+		FirstConvertedAt: now(vwoInstance),
+	})
+	if err != nil {
+		message := fmt.Sprintf(constants.ErrorMessageConversionStoreFailed, vwoInstance.API, userID, campaign.Key, goalIdentifier, err.Error())
+		utils.LogMessage(vwoInstance.Logger, constants.Error, conversion, message)
+		return true
+	}
+	if !isFirst {
+		message := fmt.Sprintf(constants.DebugMessageUserAlreadyConverted, vwoInstance.API, userID, goalIdentifier, campaign.Key, first.FirstConvertedAt)
+		utils.LogMessage(vwoInstance.Logger, constants.Debug, conversion, message)
+	}
+	return isFirst
+}
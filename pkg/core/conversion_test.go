@@ -0,0 +1,71 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+)
+
+type conversionStore struct {
+	conversions map[string]schema.Conversion
+	err         error
+}
+
+func (store *conversionStore) GetConversion(ctx context.Context, userID, campaignKey, goalIdentifier string) (schema.Conversion, bool, error) {
+	conversion, ok := store.conversions[userID+campaignKey+goalIdentifier]
+	return conversion, ok, store.err
+}
+
+func (store *conversionStore) AddConversion(ctx context.Context, conversion schema.Conversion) (schema.Conversion, bool, error) {
+	if store.err != nil {
+		return schema.Conversion{}, false, store.err
+	}
+	key := conversion.UserID + conversion.CampaignKey + conversion.GoalIdentifier
+	if first, ok := store.conversions[key]; ok {
+		return first, false, nil
+	}
+	store.conversions[key] = conversion
+	return conversion, true, nil
+}
+
+func TestAddConversion(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	currentTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	vwoInstance.Clock = func() time.Time { return currentTime }
+	store := &conversionStore{conversions: make(map[string]schema.Conversion)}
+	vwoInstance.ConversionStore = store
+
+	assertOutput.True(AddConversion(vwoInstance, "user", campaign, "goal", schema.Options{}))
+	assertOutput.Equal(currentTime, store.conversions["user"+campaign.Key+"goal"].FirstConvertedAt)
+
+	currentTime = currentTime.Add(time.Hour)
+	assertOutput.False(AddConversion(vwoInstance, "user", campaign, "goal", schema.Options{}))
+	assertOutput.Equal(currentTime.Add(-time.Hour), store.conversions["user"+campaign.Key+"goal"].FirstConvertedAt)
+	assertOutput.True(AddConversion(vwoInstance, "user", campaign, "other goal", schema.Options{}))
+
+	store.err = errors.New("store unavailable")
+	assertOutput.True(AddConversion(vwoInstance, "user", campaign, "goal", schema.Options{}), "Failing store should not drop conversions")
+}
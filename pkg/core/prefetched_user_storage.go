@@ -0,0 +1,140 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+const prefetchedUserStorage = "prefetched_user_storage.go"
+
+// PrefetchedUserStorage decorates a BatchUserStorage with the data of a user for several campaigns fetched in one GetMany,
+// the data saved for the user is kept until Flush saves it in one SetMany
+type PrefetchedUserStorage struct {
+	vwoInstance  schema.VwoInstance
+	storage      schema.UserStorage
+	batchStorage schema.BatchUserStorage
+	userID       string
+
+	mu      sync.Mutex
+	records map[string]schema.UserData
+	pending map[string]schema.UserData
+}
+
+// PrefetchUserStorage function fetches the stored data of the user for all the campaigns at once, ok is false
+// if the storage does not implement schema.BatchUserStorage or the fetch failed, the storage is then used as is
+func PrefetchUserStorage(vwoInstance schema.VwoInstance, userID string, campaigns []schema.Campaign, options schema.Options) (*PrefetchedUserStorage, bool) {
+	/*
+		Args:
+			userID: the unique ID assigned to User
+			campaigns: campaigns which will be evaluated for the user
+			options: the context(In option) is passed to the user storage
+
+		Returns:
+			*PrefetchedUserStorage: storage to use for the user
+			bool: false if the stored data could not be prefetched
+	*/
+
+	userStorage, ok := schema.ToUserStorage(vwoInstance.UserStorage)
+	if !ok {
+		return nil, false
+	}
+	// an adapted LegacyUserStorage hides the optional methods of the storage
+	batchStorage, ok := vwoInstance.UserStorage.(schema.BatchUserStorage)
+	if !ok {
+		return nil, false
+	}
+
+	campaignKeys := make([]string, len(campaigns))
+	for i, campaign := range campaigns {
+		campaignKeys[i] = campaign.Key
+	}
+	records, err := batchStorage.GetMany(getContext(options), userID, campaignKeys)
+	if err != nil {
+		message := fmt.Sprintf(constants.ErrorMessageGetUserStorageServiceError, vwoInstance.API, userID, campaignKeys, err.Error())
+		utils.LogMessage(vwoInstance.Logger, constants.Error, prefetchedUserStorage, message)
+		return nil, false
+	}
+	if records == nil {
+		records = make(map[string]schema.UserData)
+	}
+	message := fmt.Sprintf(constants.DebugMessageUserStoragePrefetched, vwoInstance.API, len(records), userID, len(campaignKeys))
+	utils.LogMessage(vwoInstance.Logger, constants.Debug, prefetchedUserStorage, message)
+
+	return &PrefetchedUserStorage{
+		vwoInstance:  vwoInstance,
+		storage:      userStorage,
+		batchStorage: batchStorage,
+		userID:       userID,
+		records:      records,
+		pending:      make(map[string]schema.UserData),
+	}, true
+}
+
+// Get function returns the prefetched data, the data of other users is fetched from the storage
+func (storage *PrefetchedUserStorage) Get(ctx context.Context, userID, campaignKey string) (schema.UserData, error) {
+	if userID != storage.userID {
+		return storage.storage.Get(ctx, userID, campaignKey)
+	}
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	return storage.records[campaignKey], nil
+}
+
+// Set function keeps the data of the user until Flush, the data of other users is saved in the storage
+func (storage *PrefetchedUserStorage) Set(ctx context.Context, userData schema.UserData) error {
+	if userData.UserID != storage.userID {
+		return storage.storage.Set(ctx, userData)
+	}
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	storage.records[userData.CampaignKey] = userData
+	storage.pending[userData.CampaignKey] = userData
+	return nil
+}
+
+// Flush function saves the data set for the user in one SetMany, it returns false if it could not be saved
+func (storage *PrefetchedUserStorage) Flush(options schema.Options) bool {
+	storage.mu.Lock()
+	userDatas := make([]schema.UserData, 0, len(storage.pending))
+	campaignKeys := make([]string, 0, len(storage.pending))
+	for campaignKey, userData := range storage.pending {
+		userDatas = append(userDatas, userData)
+		campaignKeys = append(campaignKeys, campaignKey)
+	}
+	storage.pending = make(map[string]schema.UserData)
+	storage.mu.Unlock()
+	if len(userDatas) == 0 {
+		return true
+	}
+
+	vwoInstance := storage.vwoInstance
+	if err := storage.batchStorage.SetMany(getContext(options), userDatas); err != nil {
+		message := fmt.Sprintf(constants.ErrorMessageSetUserStorageServiceError, vwoInstance.API, storage.userID, campaignKeys, err.Error())
+		utils.LogMessage(vwoInstance.Logger, constants.Error, prefetchedUserStorage, message)
+		return false
+	}
+	message := fmt.Sprintf(constants.InfoMessageSettingDataUserStorageService, vwoInstance.API, storage.userID)
+	utils.LogMessage(vwoInstance.Logger, constants.Info, prefetchedUserStorage, message)
+	return true
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+)
+
+type batchUserStorage struct {
+	records  map[string]schema.UserData
+	getErr   error
+	gets     int
+	sets     int
+	getManys [][]string
+	setManys [][]schema.UserData
+}
+
+func (storage *batchUserStorage) Get(ctx context.Context, userID, campaignKey string) (schema.UserData, error) {
+	storage.gets++
+	return storage.records[campaignKey+userID], nil
+}
+
+func (storage *batchUserStorage) Set(ctx context.Context, userData schema.UserData) error {
+	storage.sets++
+	storage.records[userData.CampaignKey+userData.UserID] = userData
+	return nil
+}
+
+func (storage *batchUserStorage) GetMany(ctx context.Context, userID string, campaignKeys []string) (map[string]schema.UserData, error) {
+	storage.getManys = append(storage.getManys, campaignKeys)
+	if storage.getErr != nil {
+		return nil, storage.getErr
+	}
+	userDatas := make(map[string]schema.UserData)
+	for _, campaignKey := range campaignKeys {
+		if userData, ok := storage.records[campaignKey+userID]; ok {
+			userDatas[campaignKey] = userData
+		}
+	}
+	return userDatas, nil
+}
+
+func (storage *batchUserStorage) SetMany(ctx context.Context, userDatas []schema.UserData) error {
+	storage.setManys = append(storage.setManys, userDatas)
+	for _, userData := range userDatas {
+		storage.records[userData.CampaignKey+userData.UserID] = userData
+	}
+	return nil
+}
+
+func TestPrefetchUserStorage(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaigns := []schema.Campaign{{Key: "first"}, {Key: "second"}}
+	ctx := context.Background()
+
+	storage := &batchUserStorage{records: map[string]schema.UserData{
+		"firstuser":  {UserID: "user", CampaignKey: "first", VariationName: "Control"},
+		"firstother": {UserID: "other", CampaignKey: "first", VariationName: "Variation-1"},
+	}}
+	vwoInstance.UserStorage = storage
+	prefetched, ok := PrefetchUserStorage(vwoInstance, "user", campaigns, schema.Options{})
+	assertOutput.True(ok)
+	assertOutput.Equal([][]string{{"first", "second"}}, storage.getManys)
+
+	userData, err := prefetched.Get(ctx, "user", "first")
+	assertOutput.Nil(err)
+	assertOutput.Equal("Control", userData.VariationName)
+	userData, _ = prefetched.Get(ctx, "user", "second")
+	assertOutput.Empty(userData.VariationName)
+	assertOutput.Equal(0, storage.gets, "Prefetched data should not be fetched again")
+
+	// other users are not prefetched
+	userData, _ = prefetched.Get(ctx, "other", "first")
+	assertOutput.Equal("Variation-1", userData.VariationName)
+	assertOutput.Equal(1, storage.gets)
+
+	assertOutput.Nil(prefetched.Set(ctx, schema.UserData{UserID: "user", CampaignKey: "second", VariationName: "Control"}))
+	userData, _ = prefetched.Get(ctx, "user", "second")
+	assertOutput.Equal("Control", userData.VariationName)
+	assertOutput.Empty(storage.setManys)
+
+	assertOutput.True(prefetched.Flush(schema.Options{}))
+	assertOutput.Equal([][]schema.UserData{{{UserID: "user", CampaignKey: "second", VariationName: "Control"}}}, storage.setManys)
+	assertOutput.True(prefetched.Flush(schema.Options{}))
+	assertOutput.Len(storage.setManys, 1, "Nothing left to save")
+	assertOutput.Equal(0, storage.sets)
+
+	storage.getErr = errors.New("storage unavailable")
+	_, ok = PrefetchUserStorage(vwoInstance, "user", campaigns, schema.Options{})
+	assertOutput.False(ok)
+
+	vwoInstance = testdata.GetInstanceWithStorage("AB_T_100_W_50_50")
+	_, ok = PrefetchUserStorage(vwoInstance, "user", campaigns, schema.Options{})
+	assertOutput.False(ok, "Storage without GetMany can not prefetch")
+}
+
+// legacyBatchUserStorage is a LegacyUserStorage able to get and set the data of a user for several campaigns at once
+type legacyBatchUserStorage struct {
+	batchUserStorage
+}
+
+func (storage *legacyBatchUserStorage) Get(userID, campaignKey string) schema.UserData {
+	userData, _ := storage.batchUserStorage.Get(context.Background(), userID, campaignKey)
+	return userData
+}
+
+func (storage *legacyBatchUserStorage) Set(userID, campaignKey, variationName, goalIdentifier string) {
+	storage.batchUserStorage.Set(context.Background(), schema.UserData{UserID: userID, CampaignKey: campaignKey, VariationName: variationName, GoalIdentifier: goalIdentifier})
+}
+
+func TestPrefetchLegacyUserStorage(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	storage := &legacyBatchUserStorage{batchUserStorage: batchUserStorage{records: map[string]schema.UserData{
+		"firstuser": {UserID: "user", CampaignKey: "first", VariationName: "Control"},
+	}}}
+	vwoInstance.UserStorage = storage
+
+	prefetched, ok := PrefetchUserStorage(vwoInstance, "user", []schema.Campaign{{Key: "first"}, {Key: "second"}}, schema.Options{})
+	if !assertOutput.True(ok, "Optional methods of the legacy storage should be used") {
+		return
+	}
+	userData, _ := prefetched.Get(context.Background(), "user", "first")
+	assertOutput.Equal("Control", userData.VariationName)
+	assertOutput.Equal([][]string{{"first", "second"}}, storage.getManys)
+}
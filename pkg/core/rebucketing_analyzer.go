@@ -0,0 +1,159 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"strconv"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+// CampaignImpact struct reports how the users of a campaign are reassigned between two settings files
+type CampaignImpact struct {
+	CampaignKey string
+	Users       int
+	// Keep counts users getting the same variation, Gain users joining the campaign,
+	// Lose users leaving the campaign and Change users moving to another variation
+	Keep   int
+	Gain   int
+	Lose   int
+	Change int
+}
+
+// KeepPercent returns the percentage of users getting the same variation
+func (impact CampaignImpact) KeepPercent() float64 {
+	return impact.percent(impact.Keep)
+}
+
+// GainPercent returns the percentage of users joining the campaign
+func (impact CampaignImpact) GainPercent() float64 {
+	return impact.percent(impact.Gain)
+}
+
+// LosePercent returns the percentage of users leaving the campaign
+func (impact CampaignImpact) LosePercent() float64 {
+	return impact.percent(impact.Lose)
+}
+
+// ChangePercent returns the percentage of users moving to another variation
+func (impact CampaignImpact) ChangePercent() float64 {
+	return impact.percent(impact.Change)
+}
+
+func (impact CampaignImpact) percent(count int) float64 {
+	if impact.Users == 0 {
+		return 0
+	}
+	return float64(count) * 100 / float64(impact.Users)
+}
+
+// AnalyzeRebucketing function buckets users with the current and proposed settings files and reports,
+// for every campaign of either file, how many users keep, gain, lose or change variation
+func AnalyzeRebucketing(vwoInstance schema.VwoInstance, current, proposed schema.SettingsFile, userIDs []string, sampleSize int) []CampaignImpact {
+	/*
+		Args:
+			current: settings file in use
+			proposed: settings file with the proposed changes
+			userIDs: users to bucket, a synthetic sample of sampleSize users is used if empty
+			sampleSize: number of synthetic users, constants.BucketingChangeSampleSize if less than 1
+
+		Returns:
+			[]CampaignImpact: impact per campaign key, campaigns of the current file first.
+			Segments and whitelisting are not evaluated as they depend on per-user variables
+	*/
+
+	userIDs = getSampleUserIDs(userIDs, sampleSize)
+
+	var keys []string
+	currentCampaigns := make(map[string]schema.Campaign)
+	proposedCampaigns := make(map[string]schema.Campaign)
+	for _, campaign := range current.Campaigns {
+		keys = append(keys, campaign.Key)
+		currentCampaigns[campaign.Key] = campaign
+	}
+	for _, campaign := range proposed.Campaigns {
+		if _, ok := currentCampaigns[campaign.Key]; !ok {
+			keys = append(keys, campaign.Key)
+		}
+		proposedCampaigns[campaign.Key] = campaign
+	}
+
+	impacts := make([]CampaignImpact, 0, len(keys))
+	for _, key := range keys {
+		impact := analyzeCampaign(vwoInstance, currentCampaigns[key], proposedCampaigns[key], userIDs)
+		impact.CampaignKey = key
+		impacts = append(impacts, impact)
+	}
+	return impacts
+}
+
+// analyzeCampaign function buckets the users with the current and proposed settings of a campaign,
+// a campaign which is missing or not running gives no variation to any user
+func analyzeCampaign(vwoInstance schema.VwoInstance, current, proposed schema.Campaign, userIDs []string) CampaignImpact {
+	current.Variations = utils.GetVariationAllocationRanges(vwoInstance, current.Variations)
+	proposed.Variations = utils.GetVariationAllocationRanges(vwoInstance, proposed.Variations)
+
+	impact := CampaignImpact{CampaignKey: current.Key, Users: len(userIDs)}
+	for _, userID := range userIDs {
+		currentVariation := getBucketedVariationName(vwoInstance, userID, current)
+		proposedVariation := getBucketedVariationName(vwoInstance, userID, proposed)
+		switch {
+		case currentVariation == "" && proposedVariation != "":
+			impact.Gain++
+		case currentVariation != "" && proposedVariation == "":
+			impact.Lose++
+		case currentVariation != proposedVariation:
+			impact.Change++
+		case currentVariation != "":
+			impact.Keep++
+		}
+	}
+	return impact
+}
+
+// getSampleUserIDs function returns the given users, or synthetic user IDs if there are none
+func getSampleUserIDs(userIDs []string, sampleSize int) []string {
+	if len(userIDs) > 0 {
+		return userIDs
+	}
+	if sampleSize < 1 {
+		sampleSize = constants.BucketingChangeSampleSize
+	}
+	userIDs = make([]string, sampleSize)
+	for i := range userIDs {
+		userIDs[i] = "user-" + strconv.Itoa(i)
+	}
+	return userIDs
+}
+
+// getBucketedVariationName function returns the name of the variation the user is bucketed into, empty if the user is not part of the campaign
+func getBucketedVariationName(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign) string {
+	if campaign.Status != constants.StatusRunning {
+		return ""
+	}
+	percentTraffic := GetEffectivePercentTraffic(vwoInstance, campaign)
+	if !IsUserPartOfCampaignWithTraffic(vwoInstance, userID, campaign, percentTraffic) {
+		return ""
+	}
+	variation, err := BucketUserToVariationWithTraffic(vwoInstance, userID, campaign, percentTraffic)
+	if err != nil {
+		return ""
+	}
+	return variation.Name
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+)
+
+func TestAnalyzeRebucketing(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_20_80")
+	unchanged := vwoInstance.SettingsFile.Campaigns[0]
+	reweighted := unchanged
+	reweighted.Variations = []schema.Variation{unchanged.Variations[0], unchanged.Variations[1]}
+	reweighted.Variations[0].Weight = 50
+	reweighted.Variations[1].Weight = 50
+	removed := testdata.GetInstanceWithSettings("AB_T_50_W_50_50").SettingsFile.Campaigns[0]
+	added := testdata.GetInstanceWithSettings("AB_T_100_W_50_50").SettingsFile.Campaigns[0]
+	paused := testdata.GetInstanceWithSettings("AB_T_100_W_33_33_33").SettingsFile.Campaigns[0]
+	pausedProposed := paused
+	pausedProposed.Status = "PAUSED"
+
+	current := schema.SettingsFile{Campaigns: []schema.Campaign{unchanged, removed, paused}}
+	proposed := schema.SettingsFile{Campaigns: []schema.Campaign{reweighted, added, pausedProposed}}
+	impacts := AnalyzeRebucketing(vwoInstance, current, proposed, nil, 5000)
+
+	assertOutput.Len(impacts, 4)
+	assertOutput.Equal([]string{"AB_T_100_W_20_80", "AB_T_50_W_50_50", "AB_T_100_W_33_33_33", "AB_T_100_W_50_50"},
+		[]string{impacts[0].CampaignKey, impacts[1].CampaignKey, impacts[2].CampaignKey, impacts[3].CampaignKey})
+
+	reweightedImpact := impacts[0]
+	assertOutput.Equal(5000, reweightedImpact.Users)
+	assertOutput.Equal(0, reweightedImpact.Gain+reweightedImpact.Lose)
+	assertOutput.InDelta(30, reweightedImpact.ChangePercent(), 2, "Moving 30% of the weight moves 30% of the users")
+	assertOutput.InDelta(100, reweightedImpact.KeepPercent()+reweightedImpact.ChangePercent(), 0.001)
+
+	assertOutput.InDelta(50, impacts[1].LosePercent(), 3, "Users of a removed campaign lose their variation")
+	assertOutput.Equal(100.0, impacts[2].LosePercent(), "Users of a paused campaign lose their variation")
+	assertOutput.Equal(100.0, impacts[3].GainPercent(), "Users of an added campaign gain a variation")
+
+	impacts = AnalyzeRebucketing(vwoInstance, current, current, []string{testdata.ValidUser, testdata.InvalidUser}, 0)
+	for _, impact := range impacts {
+		assertOutput.Equal(2, impact.Users)
+		assertOutput.Equal(0, impact.Gain+impact.Lose+impact.Change)
+	}
+
+	assertOutput.Len(getSampleUserIDs(nil, 0), constants.BucketingChangeSampleSize)
+}
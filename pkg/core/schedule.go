@@ -0,0 +1,87 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+const schedule = "schedule.go"
+
+// IsCampaignScheduled function checks if the current time lies between the optional startTime and endTime of the campaign
+func IsCampaignScheduled(vwoInstance schema.VwoInstance, campaign schema.Campaign) bool {
+	/*
+		Args:
+			campaign: campaign whose schedule is checked
+
+		Returns:
+			bool: true if the campaign has started and not ended yet
+	*/
+
+	currentTime := now(vwoInstance)
+	if (campaign.StartTime != nil && currentTime.Before(*campaign.StartTime)) ||
+		(campaign.EndTime != nil && !currentTime.Before(*campaign.EndTime)) {
+		message := fmt.Sprintf(constants.InfoMessageCampaignNotScheduled, vwoInstance.API, campaign.Key, campaign.StartTime, campaign.EndTime, currentTime)
+		utils.LogMessage(vwoInstance.Logger, constants.Info, schedule, message)
+		return false
+	}
+	return true
+}
+
+// GetEffectivePercentTraffic function returns the traffic allotted to the campaign at the current time,
+// the percentTraffic of the latest ramp step reached else the percentTraffic of the campaign
+func GetEffectivePercentTraffic(vwoInstance schema.VwoInstance, campaign schema.Campaign) int {
+	/*
+		Args:
+			campaign: campaign whose traffic is calculated
+
+		Returns:
+			int: traffic percentage, 0 if the campaign is out of its schedule
+	*/
+
+	if !IsCampaignScheduled(vwoInstance, campaign) {
+		return 0
+	}
+
+	currentTime := now(vwoInstance)
+	percentTraffic := campaign.PercentTraffic
+	var reached *schema.RampStep
+	for i, step := range campaign.Ramp {
+		if !step.Time.After(currentTime) && (reached == nil || step.Time.After(reached.Time)) {
+			reached = &campaign.Ramp[i]
+		}
+	}
+	if reached != nil {
+		percentTraffic = reached.PercentTraffic
+		message := fmt.Sprintf(constants.DebugMessageRampStepReached, vwoInstance.API, campaign.Key, reached.Time, percentTraffic)
+		utils.LogMessage(vwoInstance.Logger, constants.Debug, schedule, message)
+	}
+	return percentTraffic
+}
+
+// now function returns the current time from the clock of the instance if set, else the system time
+func now(vwoInstance schema.VwoInstance) time.Time {
+	if vwoInstance.Clock != nil {
+		return vwoInstance.Clock()
+	}
+	return time.Now()
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+func getScheduledCampaign(t *testing.T) schema.Campaign {
+	var campaign schema.Campaign
+	err := json.Unmarshal([]byte(`{
+		"key": "RAMP",
+		"percentTraffic": 100,
+		"startTime": "2022-01-01T00:00:00Z",
+		"endTime": "2022-02-01T00:00:00Z",
+		"ramp": [
+			{"time": "2022-01-03T00:00:00Z", "percentTraffic": 20},
+			{"time": "2022-01-01T00:00:00Z", "percentTraffic": 5},
+			{"time": "2022-01-07T00:00:00Z", "percentTraffic": 50}
+		]
+	}`), &campaign)
+	assert.Nil(t, err)
+	return campaign
+}
+
+func TestIsCampaignScheduled(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := getScheduledCampaign(t)
+
+	vwoInstance.Clock = func() time.Time { return time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC) }
+	assertOutput.False(IsCampaignScheduled(vwoInstance, campaign), "Campaign has not started")
+
+	vwoInstance.Clock = func() time.Time { return time.Date(2022, 1, 15, 0, 0, 0, 0, time.UTC) }
+	assertOutput.True(IsCampaignScheduled(vwoInstance, campaign))
+
+	vwoInstance.Clock = func() time.Time { return time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC) }
+	assertOutput.False(IsCampaignScheduled(vwoInstance, campaign), "Campaign has ended")
+	assertOutput.Equal(0, GetEffectivePercentTraffic(vwoInstance, campaign))
+
+	assertOutput.True(IsCampaignScheduled(vwoInstance, vwoInstance.SettingsFile.Campaigns[0]), "Campaign without schedule")
+}
+
+func TestGetEffectivePercentTraffic(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := getScheduledCampaign(t)
+	campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, vwoInstance.SettingsFile.Campaigns[0].Variations)
+
+	steps := map[time.Time]int{
+		time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC): 5,
+		time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC):  20,
+		time.Date(2022, 1, 20, 0, 0, 0, 0, time.UTC): 50,
+	}
+	for currentTime, expected := range steps {
+		vwoInstance.Clock = func() time.Time { return currentTime }
+		assertOutput.Equal(expected, GetEffectivePercentTraffic(vwoInstance, campaign), currentTime.String())
+	}
+
+	campaign.StartTime = nil
+	vwoInstance.Clock = func() time.Time { return time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC) }
+	assertOutput.Equal(100, GetEffectivePercentTraffic(vwoInstance, campaign), "Campaign traffic applies before the first ramp step")
+}
+
+func TestRampNeverEjectsUsers(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := getScheduledCampaign(t)
+	campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, vwoInstance.SettingsFile.Campaigns[0].Variations)
+
+	clocks := []time.Time{
+		time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2022, 1, 4, 0, 0, 0, 0, time.UTC),
+		time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC),
+	}
+	included := make(map[string]bool)
+	for i, currentTime := range clocks {
+		vwoInstance.Clock = func() time.Time { return currentTime }
+		count := 0
+		for j := 0; j < 1000; j++ {
+			userID := "user-" + strconv.Itoa(j)
+			isUserPart := IsUserPartOfCampaign(vwoInstance, userID, campaign)
+			if included[userID] {
+				assertOutput.True(isUserPart, "User should stay in the campaign at ramp step "+strconv.Itoa(i))
+			}
+			if isUserPart {
+				included[userID] = true
+				count++
+			}
+		}
+		assertOutput.InDelta(GetEffectivePercentTraffic(vwoInstance, campaign)*10, count, 60)
+	}
+}
@@ -0,0 +1,516 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+/*
+Segment expressions are a human readable form of the segments DSL evaluated by SegmentEvaluator.
+
+	plan == lower("pro") and (country in ["DE", "FR"] or not beta)
+
+Grammar:
+	expression := term ( "or" term )*
+	term       := factor ( "and" factor )*
+	factor     := "not" factor | "(" expression ")" | predicate
+	predicate  := "user" "(" string ( "," string )* ")"
+	            | key "==" value | key "!=" value | key "in" "[" value ( "," value )* "]" | key
+	key        := identifier | string
+	value      := string | number | "true" | "false" | ( "lower" | "wildcard" | "regex" ) "(" string ")"
+
+A bare key is a shorthand for key == true, != is a negated equality and in is an or of equalities.
+*/
+
+var (
+	segmentIdentifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	segmentNumberRegex     = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	segmentOperandRegex    = regexp.MustCompile(`^(lower|wildcard|regex)\((.*)\)$`)
+	segmentKeywords        = map[string]bool{"and": true, "or": true, "not": true, "in": true, "true": true, "false": true}
+)
+
+type segmentTokenType int
+
+const (
+	segmentTokenEOF segmentTokenType = iota
+	segmentTokenIdentifier
+	segmentTokenString
+	segmentTokenNumber
+	segmentTokenSymbol
+)
+
+type segmentToken struct {
+	tokenType segmentTokenType
+	value     string
+	position  int
+}
+
+// ParseSegmentExpression parses a segment expression into the segments structure consumed by SegmentEvaluator
+func ParseSegmentExpression(expression string) (map[string]interface{}, error) {
+	/*
+		Args:
+			expression: human readable segment expression
+
+		Returns:
+			map[string]interface{}: segments in the same shape as campaign.Segments, empty if expression is blank
+			error: if the expression could not be parsed
+	*/
+
+	tokens, err := tokenizeSegmentExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+	parser := &segmentParser{tokens: tokens}
+	if parser.peek().tokenType == segmentTokenEOF {
+		return map[string]interface{}{}, nil
+	}
+	segments, err := parser.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.peek(); token.tokenType != segmentTokenEOF {
+		return nil, fmt.Errorf(constants.ErrorMessageSegmentExpressionInvalid, token.position, "unexpected "+strconv.Quote(token.value))
+	}
+	return segments, nil
+}
+
+// FormatSegment converts segments back into a segment expression, mainly for logs and debugging
+func FormatSegment(segments map[string]interface{}) (string, error) {
+	/*
+		Args:
+			segments: segments from campaign or variation
+
+		Returns:
+			string: segment expression equivalent to the segments, empty if there are no segments
+			error: if segments contain an unsupported operator or value
+	*/
+
+	if len(segments) == 0 {
+		return "", nil
+	}
+	return formatSegmentNode(segments)
+}
+
+// tokenizeSegmentExpression splits the expression into identifiers, strings, numbers and symbols
+func tokenizeSegmentExpression(expression string) ([]segmentToken, error) {
+	var tokens []segmentToken
+	i := 0
+	for i < len(expression) {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(expression) && expression[end] != '"' {
+				if expression[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expression) {
+				return nil, fmt.Errorf(constants.ErrorMessageSegmentExpressionInvalid, i, "unterminated string")
+			}
+			value, err := strconv.Unquote(expression[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf(constants.ErrorMessageSegmentExpressionInvalid, i, err.Error())
+			}
+			tokens = append(tokens, segmentToken{segmentTokenString, value, i})
+			i = end + 1
+		case c == '=' || c == '!':
+			if i+1 >= len(expression) || expression[i+1] != '=' {
+				return nil, fmt.Errorf(constants.ErrorMessageSegmentExpressionInvalid, i, "expected "+string(c)+"=")
+			}
+			tokens = append(tokens, segmentToken{segmentTokenSymbol, expression[i : i+2], i})
+			i += 2
+		case strings.IndexByte("()[],", c) >= 0:
+			tokens = append(tokens, segmentToken{segmentTokenSymbol, string(c), i})
+			i++
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(expression) && (expression[end] == '.' || (expression[end] >= '0' && expression[end] <= '9')) {
+				end++
+			}
+			if !segmentNumberRegex.MatchString(expression[i:end]) {
+				return nil, fmt.Errorf(constants.ErrorMessageSegmentExpressionInvalid, i, "invalid number "+strconv.Quote(expression[i:end]))
+			}
+			tokens = append(tokens, segmentToken{segmentTokenNumber, expression[i:end], i})
+			i = end
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			end := i + 1
+			for end < len(expression) && isSegmentIdentifierChar(expression[end]) {
+				end++
+			}
+			tokens = append(tokens, segmentToken{segmentTokenIdentifier, expression[i:end], i})
+			i = end
+		default:
+			return nil, fmt.Errorf(constants.ErrorMessageSegmentExpressionInvalid, i, "unexpected character "+strconv.Quote(string(c)))
+		}
+	}
+	return append(tokens, segmentToken{segmentTokenEOF, "end of expression", len(expression)}), nil
+}
+
+func isSegmentIdentifierChar(c byte) bool {
+	return c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// segmentParser is a recursive descent parser over the tokens of a segment expression
+type segmentParser struct {
+	tokens   []segmentToken
+	position int
+}
+
+func (parser *segmentParser) peek() segmentToken {
+	return parser.tokens[parser.position]
+}
+
+func (parser *segmentParser) next() segmentToken {
+	token := parser.tokens[parser.position]
+	if token.tokenType != segmentTokenEOF {
+		parser.position++
+	}
+	return token
+}
+
+// isKeyword reports whether the upcoming token is the given (case insensitive) keyword
+func (parser *segmentParser) isKeyword(keyword string) bool {
+	token := parser.peek()
+	return token.tokenType == segmentTokenIdentifier && strings.ToLower(token.value) == keyword
+}
+
+func (parser *segmentParser) isSymbol(symbol string) bool {
+	token := parser.peek()
+	return token.tokenType == segmentTokenSymbol && token.value == symbol
+}
+
+func (parser *segmentParser) expectSymbol(symbol string) error {
+	token := parser.next()
+	if token.tokenType != segmentTokenSymbol || token.value != symbol {
+		return fmt.Errorf(constants.ErrorMessageSegmentExpressionInvalid, token.position, "expected "+strconv.Quote(symbol)+" but got "+strconv.Quote(token.value))
+	}
+	return nil
+}
+
+func (parser *segmentParser) parseExpression() (map[string]interface{}, error) {
+	return parser.parseList(constants.OperatorTypeOr, parser.parseTerm)
+}
+
+func (parser *segmentParser) parseTerm() (map[string]interface{}, error) {
+	return parser.parseList(constants.OperatorTypeAnd, parser.parseFactor)
+}
+
+// parseList parses operands joined by the operator and flattens them into a single and/or node
+func (parser *segmentParser) parseList(operator string, parseOperand func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	operand, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if !parser.isKeyword(operator) {
+		return operand, nil
+	}
+	operands := []interface{}{operand}
+	for parser.isKeyword(operator) {
+		parser.next()
+		operand, err = parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	return map[string]interface{}{operator: operands}, nil
+}
+
+func (parser *segmentParser) parseFactor() (map[string]interface{}, error) {
+	if parser.isKeyword(constants.OperatorTypeNot) {
+		parser.next()
+		operand, err := parser.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{constants.OperatorTypeNot: operand}, nil
+	}
+	if parser.isSymbol("(") {
+		parser.next()
+		segments, err := parser.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		return segments, parser.expectSymbol(")")
+	}
+	return parser.parsePredicate()
+}
+
+func (parser *segmentParser) parsePredicate() (map[string]interface{}, error) {
+	token := parser.next()
+	if token.tokenType != segmentTokenIdentifier && token.tokenType != segmentTokenString {
+		return nil, fmt.Errorf(constants.ErrorMessageSegmentExpressionInvalid, token.position, "expected a key but got "+strconv.Quote(token.value))
+	}
+	if token.tokenType == segmentTokenIdentifier && segmentKeywords[strings.ToLower(token.value)] {
+		return nil, fmt.Errorf(constants.ErrorMessageSegmentExpressionInvalid, token.position, "unexpected keyword "+strconv.Quote(token.value))
+	}
+	key := token.value
+
+	if token.tokenType == segmentTokenIdentifier && key == constants.OperandTypesUser && parser.isSymbol("(") {
+		users, err := parser.parseUsers()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{constants.OperandTypesUser: strings.Join(users, ",")}, nil
+	}
+
+	switch {
+	case parser.isSymbol("=="):
+		parser.next()
+		value, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return customVariableSegment(key, value), nil
+	case parser.isSymbol("!="):
+		parser.next()
+		value, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{constants.OperatorTypeNot: customVariableSegment(key, value)}, nil
+	case parser.isKeyword("in"):
+		parser.next()
+		if err := parser.expectSymbol("["); err != nil {
+			return nil, err
+		}
+		var operands []interface{}
+		for {
+			value, err := parser.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			operands = append(operands, customVariableSegment(key, value))
+			if !parser.isSymbol(",") {
+				break
+			}
+			parser.next()
+		}
+		if err := parser.expectSymbol("]"); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{constants.OperatorTypeOr: operands}, nil
+	}
+	return customVariableSegment(key, "true"), nil
+}
+
+// parseUsers parses the argument list of user("a", "b")
+func (parser *segmentParser) parseUsers() ([]string, error) {
+	parser.next()
+	var users []string
+	for {
+		token := parser.next()
+		if token.tokenType != segmentTokenString {
+			return nil, fmt.Errorf(constants.ErrorMessageSegmentExpressionInvalid, token.position, "expected a user ID string but got "+strconv.Quote(token.value))
+		}
+		users = append(users, token.value)
+		if !parser.isSymbol(",") {
+			break
+		}
+		parser.next()
+	}
+	return users, parser.expectSymbol(")")
+}
+
+// parseValue parses an operand value into the string form stored in custom_variable segments
+func (parser *segmentParser) parseValue() (string, error) {
+	token := parser.next()
+	switch token.tokenType {
+	case segmentTokenString, segmentTokenNumber:
+		return token.value, nil
+	case segmentTokenIdentifier:
+		name := strings.ToLower(token.value)
+		if name == "true" || name == "false" {
+			return name, nil
+		}
+		if (name == "lower" || name == "wildcard" || name == "regex") && parser.isSymbol("(") {
+			parser.next()
+			argument := parser.next()
+			if argument.tokenType != segmentTokenString {
+				return "", fmt.Errorf(constants.ErrorMessageSegmentExpressionInvalid, argument.position, "expected a string but got "+strconv.Quote(argument.value))
+			}
+			return name + "(" + argument.value + ")", parser.expectSymbol(")")
+		}
+	}
+	return "", fmt.Errorf(constants.ErrorMessageSegmentExpressionInvalid, token.position, "expected a value but got "+strconv.Quote(token.value))
+}
+
+func customVariableSegment(key, value string) map[string]interface{} {
+	return map[string]interface{}{
+		constants.OperandTypesCustomVariable: map[string]interface{}{key: value},
+	}
+}
+
+// formatSegmentNode formats a single node of the segments tree
+func formatSegmentNode(segments map[string]interface{}) (string, error) {
+	if len(segments) != 1 {
+		return "", fmt.Errorf(constants.ErrorMessageSegmentFormatFailed, segments)
+	}
+	operator, operand := utils.GetKeyValue(segments)
+	switch operator {
+	case constants.OperatorTypeAnd, constants.OperatorTypeOr:
+		operands, ok := operand.([]interface{})
+		if !ok || len(operands) == 0 {
+			return "", fmt.Errorf(constants.ErrorMessageSegmentFormatFailed, segments)
+		}
+		if operator == constants.OperatorTypeOr {
+			if key, values, ok := sameKeyCustomVariables(operands); ok && len(operands) > 1 {
+				return formatSegmentKey(key) + " in [" + strings.Join(values, ", ") + "]", nil
+			}
+		}
+		var parts []string
+		for _, child := range operands {
+			childSegments, ok := child.(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf(constants.ErrorMessageSegmentFormatFailed, segments)
+			}
+			part, err := formatSegmentOperand(childSegments)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		}
+		return strings.Join(parts, " "+operator+" "), nil
+	case constants.OperatorTypeNot:
+		child, ok := operand.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf(constants.ErrorMessageSegmentFormatFailed, segments)
+		}
+		if key, value, ok := customVariableOperand(child); ok {
+			return formatSegmentKey(key) + " != " + value, nil
+		}
+		part, err := formatSegmentOperand(child)
+		if err != nil {
+			return "", err
+		}
+		return "not " + part, nil
+	case constants.OperandTypesCustomVariable:
+		if key, value, ok := customVariableOperand(segments); ok {
+			return formatSegmentKey(key) + " == " + value, nil
+		}
+	case constants.OperandTypesUser:
+		if users, ok := operand.(string); ok {
+			var quoted []string
+			for _, user := range strings.Split(users, ",") {
+				quoted = append(quoted, strconv.Quote(strings.TrimSpace(user)))
+			}
+			return constants.OperandTypesUser + "(" + strings.Join(quoted, ", ") + ")", nil
+		}
+	}
+	return "", fmt.Errorf(constants.ErrorMessageSegmentFormatFailed, segments)
+}
+
+// formatSegmentOperand formats a node nested in and/or/not, wrapping and/or nodes in parentheses
+func formatSegmentOperand(segments map[string]interface{}) (string, error) {
+	text, err := formatSegmentNode(segments)
+	if err != nil {
+		return "", err
+	}
+	operator, operand := utils.GetKeyValue(segments)
+	if operator == constants.OperatorTypeAnd || (operator == constants.OperatorTypeOr && !isInExpression(operand)) {
+		return "(" + text + ")", nil
+	}
+	return text, nil
+}
+
+// isInExpression reports whether the or operands are printed as a single "key in [...]" predicate
+func isInExpression(operand interface{}) bool {
+	operands, ok := operand.([]interface{})
+	if !ok || len(operands) < 2 {
+		return false
+	}
+	_, _, ok = sameKeyCustomVariables(operands)
+	return ok
+}
+
+// sameKeyCustomVariables checks if all operands are custom_variable segments on the same key and formats their values
+func sameKeyCustomVariables(operands []interface{}) (string, []string, bool) {
+	var (
+		key    string
+		values []string
+	)
+	for i, child := range operands {
+		childSegments, ok := child.(map[string]interface{})
+		if !ok {
+			return "", nil, false
+		}
+		childKey, value, ok := customVariableOperand(childSegments)
+		if !ok || (i > 0 && childKey != key) {
+			return "", nil, false
+		}
+		key = childKey
+		values = append(values, value)
+	}
+	return key, values, true
+}
+
+// customVariableOperand returns the key and formatted value of a custom_variable segment
+func customVariableOperand(segments map[string]interface{}) (string, string, bool) {
+	if len(segments) != 1 {
+		return "", "", false
+	}
+	operator, operand := utils.GetKeyValue(segments)
+	if operator != constants.OperandTypesCustomVariable {
+		return "", "", false
+	}
+	custom, ok := operand.(map[string]interface{})
+	if !ok || len(custom) != 1 {
+		return "", "", false
+	}
+	key, value := utils.GetKeyValue(custom)
+	stringValue, ok := value.(string)
+	if !ok {
+		return "", "", false
+	}
+	return key, formatSegmentValue(stringValue), true
+}
+
+func formatSegmentKey(key string) string {
+	if segmentIdentifierRegex.MatchString(key) && !segmentKeywords[strings.ToLower(key)] {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+func formatSegmentValue(value string) string {
+	if submatch := segmentOperandRegex.FindStringSubmatch(value); submatch != nil {
+		return submatch[1] + "(" + strconv.Quote(submatch[2]) + ")"
+	}
+	if segmentNumberRegex.MatchString(value) {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// segmentsForLog returns the expression form of segments for log messages, falling back to the raw segments
+func segmentsForLog(segments map[string]interface{}) interface{} {
+	if expression, err := FormatSegment(segments); err == nil {
+		return expression
+	}
+	return segments
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/logger"
+)
+
+func TestParseSegmentExpression(t *testing.T) {
+	assertOutput := assert.New(t)
+
+	actual, err := ParseSegmentExpression(`plan == lower("pro") and (country in ["DE","FR"] or not beta)`)
+	assertOutput.Nil(err)
+	expected := map[string]interface{}{
+		"and": []interface{}{
+			map[string]interface{}{"custom_variable": map[string]interface{}{"plan": "lower(pro)"}},
+			map[string]interface{}{"or": []interface{}{
+				map[string]interface{}{"or": []interface{}{
+					map[string]interface{}{"custom_variable": map[string]interface{}{"country": "DE"}},
+					map[string]interface{}{"custom_variable": map[string]interface{}{"country": "FR"}},
+				}},
+				map[string]interface{}{"not": map[string]interface{}{"custom_variable": map[string]interface{}{"beta": "true"}}},
+			}},
+		},
+	}
+	assertOutput.Equal(expected, actual)
+
+	actual, err = ParseSegmentExpression(`age != 25.5 and "first name" == wildcard("*ash*") or user("u1", "u2")`)
+	assertOutput.Nil(err)
+	expected = map[string]interface{}{
+		"or": []interface{}{
+			map[string]interface{}{"and": []interface{}{
+				map[string]interface{}{"not": map[string]interface{}{"custom_variable": map[string]interface{}{"age": "25.5"}}},
+				map[string]interface{}{"custom_variable": map[string]interface{}{"first name": "wildcard(*ash*)"}},
+			}},
+			map[string]interface{}{"user": "u1,u2"},
+		},
+	}
+	assertOutput.Equal(expected, actual)
+
+	actual, err = ParseSegmentExpression("  ")
+	assertOutput.Nil(err)
+	assertOutput.Empty(actual)
+
+	for _, expression := range []string{`plan ==`, `plan = "pro"`, `(plan == "pro"`, `plan == "pro" and`, `country in []`, `user(1)`, `plan == "pro`, `and == "x"`} {
+		_, err = ParseSegmentExpression(expression)
+		assertOutput.NotNil(err, expression)
+	}
+}
+
+func TestFormatSegment(t *testing.T) {
+	assertOutput := assert.New(t)
+
+	segments, _ := ParseSegmentExpression(`plan == lower("pro") and (country in ["DE", "FR"] or not (beta and user("u1")))`)
+	actual, err := FormatSegment(segments)
+	assertOutput.Nil(err)
+	assertOutput.Equal(`plan == lower("pro") and (country in ["DE", "FR"] or not (beta == "true" and user("u1")))`, actual)
+
+	actual, err = FormatSegment(nil)
+	assertOutput.Nil(err)
+	assertOutput.Empty(actual)
+
+	_, err = FormatSegment(map[string]interface{}{"unknown": "value"})
+	assertOutput.NotNil(err)
+}
+
+func TestSegmentExpressionRoundTrip(t *testing.T) {
+	var TestData map[string]map[string]SegmentorTestCase
+	data, err := ioutil.ReadFile("../testdata/test_segment.json")
+	if err != nil {
+		logger.Info("Error: " + err.Error())
+	}
+
+	if err = json.Unmarshal(data, &TestData); err != nil {
+		logger.Info("Error: " + err.Error())
+	}
+
+	for parent, v := range TestData {
+		for child, value := range v {
+			expression, err := FormatSegment(value.DSL)
+			assert.Nil(t, err, parent+" "+child)
+
+			segments, err := ParseSegmentExpression(expression)
+			assert.Nil(t, err, parent+" "+child+" : "+expression)
+
+			variables := value.CustomVariable
+			if variables == nil {
+				variables = value.VariationTargetingVariables
+			}
+			assert.Equal(t, value.Expected, SegmentEvaluator(segments, variables), parent+" "+child+" : "+expression)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+const srmMonitor = "srm_monitor.go"
+
+// SRMAlert struct reports a sample ratio mismatch detected in the live assignments of a campaign
+type SRMAlert struct {
+	CampaignKey string
+	// Type is constants.SRMTypeTraffic for users part of the campaign against its percentTraffic,
+	// or constants.SRMTypeVariations for the split between variations against their weights
+	Type      string
+	Observed  map[string]int
+	Expected  map[string]float64
+	ChiSquare float64
+	PValue    float64
+}
+
+// SRMMonitor counts the live bucketing decisions per campaign and variation and tests them for sample ratio mismatch.
+// A bucketing key is counted once until its campaign is tested, the counts of a campaign are reset once tested
+// so that every check only tests the decisions made since the previous one
+type SRMMonitor struct {
+	PValueThreshold float64
+	MinSampleSize   int
+	CallBack        func(SRMAlert)
+	Logger          interface{}
+
+	mu        sync.Mutex
+	campaigns map[string]*campaignAssignments
+	done      chan struct{}
+	stopOnce  sync.Once
+}
+
+// campaignAssignments struct holds the observed and expected counts of a campaign, and the bucketing keys counted
+type campaignAssignments struct {
+	evaluated          int
+	included           int
+	expectedIncluded   float64
+	evaluatedKeys      map[string]struct{}
+	observedVariations map[string]int
+	expectedVariations map[string]float64
+	assignedKeys       map[string]struct{}
+}
+
+// NewSRMMonitor returns a monitor firing the callBack, or logging a warning if callBack is nil, for every mismatch found
+func NewSRMMonitor(pValueThreshold float64, minSampleSize int, callBack func(SRMAlert), logger interface{}) *SRMMonitor {
+	return &SRMMonitor{
+		PValueThreshold: pValueThreshold,
+		MinSampleSize:   minSampleSize,
+		CallBack:        callBack,
+		Logger:          logger,
+		campaigns:       make(map[string]*campaignAssignments),
+		done:            make(chan struct{}),
+	}
+}
+
+// RecordEvaluation counts a traffic evaluation, the user is expected to be part of the campaign with percentTraffic probability
+func (monitor *SRMMonitor) RecordEvaluation(campaign schema.Campaign, bucketingKey string, percentTraffic int, isUserPart bool) {
+	monitor.mu.Lock()
+	defer monitor.mu.Unlock()
+	assignments := monitor.getCampaignAssignments(campaign.Key)
+	if _, ok := assignments.evaluatedKeys[bucketingKey]; ok {
+		return
+	}
+	assignments.evaluatedKeys[bucketingKey] = struct{}{}
+	assignments.evaluated++
+	assignments.expectedIncluded += float64(percentTraffic) / constants.MaxTrafficPercent
+	if isUserPart {
+		assignments.included++
+	}
+}
+
+// RecordAssignment counts a variation assignment, the user is expected in each variation as per its weight
+func (monitor *SRMMonitor) RecordAssignment(campaign schema.Campaign, bucketingKey string, variation schema.Variation) {
+	totalWeight := 0.0
+	for _, campaignVariation := range campaign.Variations {
+		totalWeight += campaignVariation.Weight
+	}
+	if totalWeight <= 0 {
+		return
+	}
+
+	monitor.mu.Lock()
+	defer monitor.mu.Unlock()
+	assignments := monitor.getCampaignAssignments(campaign.Key)
+	if _, ok := assignments.assignedKeys[bucketingKey]; ok {
+		return
+	}
+	assignments.assignedKeys[bucketingKey] = struct{}{}
+	assignments.observedVariations[variation.Name]++
+	for _, campaignVariation := range campaign.Variations {
+		assignments.expectedVariations[campaignVariation.Name] += campaignVariation.Weight / totalWeight
+	}
+}
+
+// Check tests the counts of every campaign having at least MinSampleSize samples and reports the mismatches found,
+// the counts tested are then reset
+func (monitor *SRMMonitor) Check() []SRMAlert {
+	monitor.mu.Lock()
+	keys := make([]string, 0, len(monitor.campaigns))
+	for key := range monitor.campaigns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var alerts []SRMAlert
+	for _, key := range keys {
+		assignments := monitor.campaigns[key]
+		if assignments.evaluated >= monitor.MinSampleSize {
+			alert := SRMAlert{
+				CampaignKey: key,
+				Type:        constants.SRMTypeTraffic,
+				Observed:    map[string]int{"included": assignments.included, "excluded": assignments.evaluated - assignments.included},
+				Expected:    map[string]float64{"included": assignments.expectedIncluded, "excluded": float64(assignments.evaluated) - assignments.expectedIncluded},
+			}
+			if monitor.test(&alert) {
+				alerts = append(alerts, alert)
+			}
+			assignments.evaluated, assignments.included, assignments.expectedIncluded = 0, 0, 0
+			assignments.evaluatedKeys = make(map[string]struct{})
+		}
+
+		assigned := 0
+		for _, count := range assignments.observedVariations {
+			assigned += count
+		}
+		if assigned >= monitor.MinSampleSize {
+			alert := SRMAlert{
+				CampaignKey: key,
+				Type:        constants.SRMTypeVariations,
+				Observed:    make(map[string]int),
+				Expected:    make(map[string]float64),
+			}
+			for name, expected := range assignments.expectedVariations {
+				alert.Observed[name] = assignments.observedVariations[name]
+				alert.Expected[name] = expected
+			}
+			for name, observed := range assignments.observedVariations {
+				alert.Observed[name] = observed
+			}
+			if monitor.test(&alert) {
+				alerts = append(alerts, alert)
+			}
+			assignments.observedVariations = make(map[string]int)
+			assignments.expectedVariations = make(map[string]float64)
+			assignments.assignedKeys = make(map[string]struct{})
+		}
+	}
+	monitor.mu.Unlock()
+
+	for _, alert := range alerts {
+		if monitor.CallBack != nil {
+			monitor.CallBack(alert)
+		} else {
+			message := fmt.Sprintf(constants.WarningMessageSampleRatioMismatch, alert.CampaignKey, alert.Type, alert.PValue, alert.Observed, alert.Expected)
+			utils.LogMessage(monitor.Logger, constants.Warning, srmMonitor, message)
+		}
+	}
+	return alerts
+}
+
+// Start checks the counts at the given interval until the monitor is stopped
+func (monitor *SRMMonitor) Start(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-monitor.done:
+			return
+		case <-ticker.C:
+			monitor.Check()
+		}
+	}
+}
+
+// Stop stops the periodic checks of the monitor
+func (monitor *SRMMonitor) Stop() {
+	monitor.stopOnce.Do(func() {
+		close(monitor.done)
+	})
+}
+
+// test function computes the chi-square statistic and p-value of the alert and returns true if it is a mismatch
+func (monitor *SRMMonitor) test(alert *SRMAlert) bool {
+	var (
+		observed   []int
+		expected   []float64
+		categories int
+	)
+	for name, expectedCount := range alert.Expected {
+		observed = append(observed, alert.Observed[name])
+		expected = append(expected, expectedCount)
+		if expectedCount > 0 {
+			categories++
+		}
+	}
+	for name, observedCount := range alert.Observed {
+		if _, ok := alert.Expected[name]; !ok && observedCount > 0 {
+			// a variation which is not part of the campaign anymore can not be expected
+			alert.PValue = 0
+			return true
+		}
+	}
+	alert.ChiSquare = utils.ChiSquareStatistic(observed, expected)
+	alert.PValue = utils.ChiSquarePValue(alert.ChiSquare, categories-1)
+	return alert.PValue < monitor.PValueThreshold
+}
+
+// getCampaignAssignments function returns the counts of the campaign, creating them if needed
+func (monitor *SRMMonitor) getCampaignAssignments(campaignKey string) *campaignAssignments {
+	assignments, ok := monitor.campaigns[campaignKey]
+	if !ok {
+		assignments = &campaignAssignments{
+			evaluatedKeys:      make(map[string]struct{}),
+			observedVariations: make(map[string]int),
+			expectedVariations: make(map[string]float64),
+			assignedKeys:       make(map[string]struct{}),
+		}
+		monitor.campaigns[campaignKey] = assignments
+	}
+	return assignments
+}
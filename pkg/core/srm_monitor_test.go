@@ -0,0 +1,237 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+)
+
+func TestSRMMonitorBalancedSplit(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("T_75_W_10_TIMES_10")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	var alerts []SRMAlert
+	monitor := NewSRMMonitor(constants.SRMPValueThreshold, 1000, func(alert SRMAlert) {
+		alerts = append(alerts, alert)
+	}, vwoInstance.Logger)
+	vwoInstance.AssignmentRecorder = monitor
+
+	for i := 0; i < 5000; i++ {
+		GetVariation(vwoInstance, "user-"+strconv.Itoa(i), campaign, "", schema.Options{})
+	}
+	assignments := monitor.campaigns[campaign.Key]
+	assertOutput.Equal(5000, assignments.evaluated)
+	assertOutput.InDelta(3750, assignments.expectedIncluded, 0.001)
+	assertOutput.InDelta(3750, assignments.included, 150)
+
+	assertOutput.Empty(monitor.Check())
+	assertOutput.Empty(alerts)
+}
+
+func TestSRMMonitorVariationsMismatch(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	var alerts []SRMAlert
+	monitor := NewSRMMonitor(constants.SRMPValueThreshold, 100, func(alert SRMAlert) {
+		alerts = append(alerts, alert)
+	}, vwoInstance.Logger)
+
+	for i := 0; i < 1000; i++ {
+		variation := campaign.Variations[0]
+		if i >= 600 {
+			variation = campaign.Variations[1]
+		}
+		monitor.RecordEvaluation(campaign, "user-"+strconv.Itoa(i), 100, true)
+		monitor.RecordAssignment(campaign, "user-"+strconv.Itoa(i), variation)
+	}
+
+	assertOutput.Len(monitor.Check(), 1)
+	assertOutput.Len(alerts, 1)
+	assertOutput.Equal(campaign.Key, alerts[0].CampaignKey)
+	assertOutput.Equal(constants.SRMTypeVariations, alerts[0].Type)
+	assertOutput.Equal(600, alerts[0].Observed[campaign.Variations[0].Name])
+	assertOutput.InDelta(500, alerts[0].Expected[campaign.Variations[0].Name], 0.001)
+	assertOutput.InDelta(40, alerts[0].ChiSquare, 0.001)
+	assertOutput.True(alerts[0].PValue < constants.SRMPValueThreshold)
+}
+
+func TestSRMMonitorTrafficMismatch(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	monitor := NewSRMMonitor(constants.SRMPValueThreshold, 100, nil, vwoInstance.Logger)
+	for i := 0; i < 1000; i++ {
+		monitor.RecordEvaluation(campaign, "user-"+strconv.Itoa(i), 50, i%10 != 0)
+	}
+
+	alerts := monitor.Check()
+	assertOutput.Len(alerts, 1)
+	assertOutput.Equal(constants.SRMTypeTraffic, alerts[0].Type)
+	assertOutput.Equal(900, alerts[0].Observed["included"])
+	assertOutput.InDelta(500, alerts[0].Expected["included"], 0.001)
+}
+
+func TestSRMMonitorRemovedVariation(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	monitor := NewSRMMonitor(constants.SRMPValueThreshold, 10, nil, vwoInstance.Logger)
+	for i := 0; i < 10; i++ {
+		monitor.RecordAssignment(campaign, "user-"+strconv.Itoa(i), schema.Variation{Name: "Removed"})
+	}
+
+	alerts := monitor.Check()
+	assertOutput.Len(alerts, 1)
+	assertOutput.Equal(10, alerts[0].Observed["Removed"])
+	assertOutput.Equal(0.0, alerts[0].PValue)
+}
+
+func TestSRMMonitorZeroWeightVariation(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	campaign.Variations = []schema.Variation{
+		{Name: "Control", Weight: 100},
+		{Name: "Variation-1", Weight: 0},
+	}
+
+	monitor := NewSRMMonitor(constants.SRMPValueThreshold, 10, nil, vwoInstance.Logger)
+	for i := 0; i < 10; i++ {
+		monitor.RecordAssignment(campaign, "user-"+strconv.Itoa(i), campaign.Variations[i%2])
+	}
+
+	alerts := monitor.Check()
+	assertOutput.Len(alerts, 1, "Variation with no weight should not get users")
+	assertOutput.Equal(5, alerts[0].Observed["Variation-1"])
+	assertOutput.Equal(0.0, alerts[0].PValue)
+}
+
+func TestSRMMonitorMinSampleSize(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	monitor := NewSRMMonitor(constants.SRMPValueThreshold, 1000, nil, vwoInstance.Logger)
+	for i := 0; i < 999; i++ {
+		monitor.RecordEvaluation(campaign, "user-"+strconv.Itoa(i), 100, false)
+		monitor.RecordAssignment(campaign, "user-"+strconv.Itoa(i), campaign.Variations[0])
+	}
+	assertOutput.Empty(monitor.Check(), "Not enough samples to test")
+	assertOutput.Equal(999, monitor.campaigns[campaign.Key].evaluated, "Counts not tested should be kept")
+}
+
+func TestSRMMonitorRepeatedUser(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	monitor := NewSRMMonitor(constants.SRMPValueThreshold, 10, nil, vwoInstance.Logger)
+	for i := 0; i < 100; i++ {
+		monitor.RecordEvaluation(campaign, testdata.ValidUser, 50, false)
+		monitor.RecordAssignment(campaign, testdata.ValidUser, campaign.Variations[0])
+	}
+	assertOutput.Empty(monitor.Check(), "A user should be counted once")
+	assertOutput.Equal(1, monitor.campaigns[campaign.Key].evaluated)
+	assertOutput.Equal(1, monitor.campaigns[campaign.Key].observedVariations[campaign.Variations[0].Name])
+}
+
+func TestSRMMonitorResetAfterCheck(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	monitor := NewSRMMonitor(constants.SRMPValueThreshold, 10, nil, vwoInstance.Logger)
+	for i := 0; i < 100; i++ {
+		monitor.RecordAssignment(campaign, "user-"+strconv.Itoa(i), campaign.Variations[0])
+	}
+	assertOutput.Len(monitor.Check(), 1)
+	assertOutput.Empty(monitor.Check(), "Counts tested should be reset")
+
+	for i := 0; i < 100; i++ {
+		monitor.RecordAssignment(campaign, "user-"+strconv.Itoa(i), campaign.Variations[i%2])
+	}
+	assertOutput.Empty(monitor.Check(), "Users should be counted again after a check")
+}
+
+func TestSRMMonitorStoredAndWhitelistedUsers(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithStorage("AB_T_100_W_33_33_33")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	monitor := NewSRMMonitor(constants.SRMPValueThreshold, 1, nil, vwoInstance.Logger)
+	vwoInstance.AssignmentRecorder = monitor
+	variation, _, _ := GetVariation(vwoInstance, testdata.TempUser, campaign, "", schema.Options{})
+	assertOutput.Equal("Control", variation.Name)
+	assertOutput.Empty(monitor.campaigns, "Stored variations should not be counted")
+
+	vwoInstance = testdata.GetInstanceWithCustomSettings("SettingsFile3")
+	campaign = vwoInstance.SettingsFile.Campaigns[0]
+	vwoInstance.AssignmentRecorder = monitor
+	options := schema.Options{
+		VariationTargetingVariables: map[string]interface{}{"a": "123"},
+	}
+	variation, _, _ = GetVariation(vwoInstance, testdata.ValidUser, campaign, "", options)
+	assertOutput.NotEmpty(variation.Name)
+	assertOutput.Empty(monitor.campaigns, "Whitelisted variations should not be counted")
+}
+
+func TestSRMMonitorStart(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	alerts := make(chan SRMAlert, 10)
+	monitor := NewSRMMonitor(constants.SRMPValueThreshold, 10, func(alert SRMAlert) {
+		alerts <- alert
+	}, vwoInstance.Logger)
+	for i := 0; i < 100; i++ {
+		monitor.RecordAssignment(campaign, "user-"+strconv.Itoa(i), campaign.Variations[0])
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		monitor.Start(10 * time.Millisecond)
+		close(stopped)
+	}()
+
+	select {
+	case alert := <-alerts:
+		assertOutput.Equal(constants.SRMTypeVariations, alert.Type)
+	case <-time.After(time.Second):
+		t.Fatal("The monitor should check the counts periodically")
+	}
+
+	monitor.Stop()
+	monitor.Stop()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("The monitor should stop")
+	}
+}
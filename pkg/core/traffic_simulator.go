@@ -0,0 +1,118 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+// VariationSplit struct reports the users bucketed into a variation against its configured weight
+type VariationSplit struct {
+	VariationName   string  `json:"variationName"`
+	Weight          float64 `json:"weight"`
+	Users           int     `json:"users"`
+	ExpectedUsers   float64 `json:"expectedUsers"`
+	ObservedPercent float64 `json:"observedPercent"`
+}
+
+// TrafficSimulation struct reports the distribution of simulated users in a campaign
+type TrafficSimulation struct {
+	CampaignKey      string
+	Users            int
+	Included         int
+	ExpectedIncluded float64
+	Unbucketed       int
+	Variations       []VariationSplit
+	ChiSquare        float64
+	PValue           float64
+}
+
+// IsSampleRatioMismatch returns true if the p-value of the variation split is below the given threshold
+func (simulation TrafficSimulation) IsSampleRatioMismatch(threshold float64) bool {
+	return simulation.PValue < threshold
+}
+
+// SimulateTraffic function pushes users through IsUserPartOfCampaign and BucketUserToVariation and compares
+// the observed split per variation with the configured weights using a chi-square sample ratio mismatch test
+func SimulateTraffic(vwoInstance schema.VwoInstance, campaign schema.Campaign, userIDs []string, sampleSize int) TrafficSimulation {
+	/*
+		Args:
+			campaign: campaign to simulate, its variation allocation ranges are computed from the weights
+			userIDs: users to bucket, a synthetic sample of sampleSize users is used if empty
+			sampleSize: number of synthetic users, constants.BucketingChangeSampleSize if less than 1
+
+		Returns:
+			TrafficSimulation: observed and expected users per variation with the chi-square statistic and p-value
+	*/
+
+	userIDs = getSampleUserIDs(userIDs, sampleSize)
+	campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, campaign.Variations)
+	percentTraffic := GetEffectivePercentTraffic(vwoInstance, campaign)
+
+	simulation := TrafficSimulation{
+		CampaignKey:      campaign.Key,
+		Users:            len(userIDs),
+		ExpectedIncluded: float64(len(userIDs)) * float64(percentTraffic) / constants.MaxTrafficPercent,
+		Variations:       make([]VariationSplit, len(campaign.Variations)),
+	}
+	indexes := make(map[string]int)
+	for i, variation := range campaign.Variations {
+		simulation.Variations[i] = VariationSplit{VariationName: variation.Name, Weight: variation.Weight}
+		indexes[variation.Name] = i
+	}
+
+	for _, userID := range userIDs {
+		if !IsUserPartOfCampaignWithTraffic(vwoInstance, userID, campaign, percentTraffic) {
+			continue
+		}
+		simulation.Included++
+		variation, err := BucketUserToVariationWithTraffic(vwoInstance, userID, campaign, percentTraffic)
+		if err != nil {
+			simulation.Unbucketed++
+			continue
+		}
+		simulation.Variations[indexes[variation.Name]].Users++
+	}
+
+	totalWeight := 0.0
+	for _, variation := range simulation.Variations {
+		totalWeight += variation.Weight
+	}
+	bucketed := simulation.Included - simulation.Unbucketed
+	observed := make([]int, len(simulation.Variations))
+	expected := make([]float64, len(simulation.Variations))
+	categories := 0
+	for i := range simulation.Variations {
+		split := &simulation.Variations[i]
+		if totalWeight > 0 {
+			split.ExpectedUsers = float64(bucketed) * split.Weight / totalWeight
+		}
+		if bucketed > 0 {
+			split.ObservedPercent = float64(split.Users) * 100 / float64(bucketed)
+		}
+		observed[i] = split.Users
+		expected[i] = split.ExpectedUsers
+		if split.ExpectedUsers > 0 {
+			categories++
+		}
+	}
+	simulation.ChiSquare = utils.ChiSquareStatistic(observed, expected)
+	simulation.PValue = utils.ChiSquarePValue(simulation.ChiSquare, categories-1)
+	return simulation
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+)
+
+func TestSimulateTraffic(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("T_75_W_10_TIMES_10")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	simulation := SimulateTraffic(vwoInstance, campaign, nil, 20000)
+	assertOutput.Equal(campaign.Key, simulation.CampaignKey)
+	assertOutput.Equal(20000, simulation.Users)
+	assertOutput.Equal(15000.0, simulation.ExpectedIncluded)
+	assertOutput.InDelta(15000, simulation.Included, 300)
+	assertOutput.True(simulation.Unbucketed <= 2, "Only users at the edge of the traffic can exceed the last variation range")
+	assertOutput.Len(simulation.Variations, 10)
+	for _, split := range simulation.Variations {
+		assertOutput.InDelta(10, split.ObservedPercent, 1.5, split.VariationName)
+		assertOutput.InDelta(float64(simulation.Included-simulation.Unbucketed)/10, split.ExpectedUsers, 0.001)
+	}
+	assertOutput.False(simulation.IsSampleRatioMismatch(constants.SRMPValueThreshold), "Bucketing should follow the weights")
+}
+
+func TestSimulateTrafficSampleRatioMismatch(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	// duplicated user IDs always land in the same variation
+	userIDs := make([]string, 1000)
+	for i := range userIDs {
+		userIDs[i] = testdata.ValidUser
+	}
+	simulation := SimulateTraffic(vwoInstance, campaign, userIDs, 0)
+	assertOutput.Equal(1000, simulation.Included)
+	assertOutput.True(simulation.IsSampleRatioMismatch(constants.SRMPValueThreshold))
+	assertOutput.InDelta(1000, simulation.ChiSquare, 0.001)
+
+	campaign.Variations = nil
+	simulation = SimulateTraffic(vwoInstance, campaign, nil, 100)
+	assertOutput.Equal(0, simulation.Included)
+	assertOutput.Equal(1.0, simulation.PValue)
+}
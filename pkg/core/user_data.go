@@ -0,0 +1,72 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+)
+
+// NewUserData function returns the data to store for a variation assigned to the user now
+func NewUserData(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign, variation schema.Variation, goalIdentifier string) schema.UserData {
+	/*
+		Args:
+			userID: the unique ID assigned to User
+			campaign: campaign in which user is participating
+			variation: variation assigned to the user
+			goalIdentifier: goals already tracked for the user
+
+		Returns:
+			schema.UserData: data to store
+	*/
+	return schema.UserData{
+		UserID:         userID,
+		CampaignKey:    campaign.Key,
+		VariationName:  variation.Name,
+		GoalIdentifier: goalIdentifier,
+		CampaignID:     campaign.ID,
+		VariationID:    variation.ID,
+		AssignedAt:     now(vwoInstance),
+		SDKVersion:     constants.SDKVersion,
+	}
+}
+
+// MigrateUserData function fills the IDs of the campaign and variation of data stored without them, and the current
+// name of a renamed variation, it returns false if the stored data is up to date. The assignment time of data stored
+// without it stays unknown
+func MigrateUserData(campaign schema.Campaign, variation schema.Variation, userData schema.UserData) (schema.UserData, bool) {
+	/*
+		Args:
+			campaign: campaign in which user is participating
+			variation: variation of the stored data
+			userData: stored data
+
+		Returns:
+			schema.UserData: data to store
+			bool: true if the data has to be stored again
+	*/
+	if userData.CampaignID == campaign.ID && userData.VariationID == variation.ID && userData.VariationName == variation.Name && userData.SDKVersion != "" {
+		return userData, false
+	}
+	userData.CampaignID = campaign.ID
+	userData.VariationID = variation.ID
+	userData.VariationName = variation.Name
+	if userData.SDKVersion == "" {
+		userData.SDKVersion = constants.SDKVersion
+	}
+	return userData, true
+}
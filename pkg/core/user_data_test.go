@@ -0,0 +1,138 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+func TestNewUserData(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	currentTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	vwoInstance.Clock = func() time.Time { return currentTime }
+
+	userData := NewUserData(vwoInstance, testdata.ValidUser, campaign, campaign.Variations[1], "goal")
+	assertOutput.Equal(schema.UserData{
+		UserID:         testdata.ValidUser,
+		CampaignKey:    campaign.Key,
+		VariationName:  campaign.Variations[1].Name,
+		GoalIdentifier: "goal",
+		CampaignID:     campaign.ID,
+		VariationID:    campaign.Variations[1].ID,
+		AssignedAt:     currentTime,
+		SDKVersion:     constants.SDKVersion,
+	}, userData)
+}
+
+func TestMigrateUserData(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	variation := campaign.Variations[1]
+
+	legacy := schema.UserData{UserID: testdata.ValidUser, CampaignKey: campaign.Key, VariationName: variation.Name, GoalIdentifier: "goal"}
+	migrated, ok := MigrateUserData(campaign, variation, legacy)
+	assertOutput.True(ok)
+	assertOutput.Equal(campaign.ID, migrated.CampaignID)
+	assertOutput.Equal(variation.ID, migrated.VariationID)
+	assertOutput.Equal("goal", migrated.GoalIdentifier)
+	assertOutput.Equal(constants.SDKVersion, migrated.SDKVersion)
+	assertOutput.True(migrated.AssignedAt.IsZero(), "Unknown assignment time should stay unknown")
+
+	_, ok = MigrateUserData(campaign, variation, migrated)
+	assertOutput.False(ok, "Data is up to date")
+
+	migrated.SDKVersion = "1.0.0"
+	_, ok = MigrateUserData(campaign, variation, migrated)
+	assertOutput.False(ok, "SDK version of the assignment should be kept")
+
+	migrated.VariationName = "Old name"
+	migrated, ok = MigrateUserData(campaign, variation, migrated)
+	assertOutput.True(ok)
+	assertOutput.Equal(variation.Name, migrated.VariationName)
+}
+
+func TestGetVariationMigratesUserData(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, campaign.Variations)
+	variation := campaign.Variations[1]
+	storage := &batchUserStorage{records: map[string]schema.UserData{
+		campaign.Key + "user": {UserID: "user", CampaignKey: campaign.Key, VariationName: variation.Name},
+	}}
+	vwoInstance.UserStorage = storage
+
+	actual, storedGoalIdentifier, err := GetVariation(vwoInstance, "user", campaign, "", schema.Options{})
+	assertOutput.Nil(err)
+	assertOutput.Equal(variation.Name, actual.Name)
+	assertOutput.Empty(storedGoalIdentifier)
+	assertOutput.Equal(1, storage.sets, "Data stored without IDs should be migrated")
+	assertOutput.Equal(variation.ID, storage.records[campaign.Key+"user"].VariationID)
+
+	_, _, err = GetVariation(vwoInstance, "user", campaign, "", schema.Options{})
+	assertOutput.Nil(err)
+	assertOutput.Equal(1, storage.sets, "Migrated data should not be saved again")
+
+	// a renamed variation is found by its ID
+	campaign.Variations[1].Name = "Renamed"
+	actual, _, err = GetVariation(vwoInstance, "user", campaign, "", schema.Options{})
+	assertOutput.Nil(err)
+	assertOutput.Equal("Renamed", actual.Name)
+	assertOutput.Equal("Renamed", storage.records[campaign.Key+"user"].VariationName)
+
+	// new assignments are stored with their IDs
+	actual, userData, _, err := GetVariationAndUserData(vwoInstance, testdata.ValidUser, campaign, "goal", schema.Options{})
+	assertOutput.Nil(err)
+	assertOutput.Empty(userData.GoalIdentifier, "Goal is not tracked yet")
+	assertOutput.Equal(actual.ID, userData.VariationID)
+	assertOutput.Equal(campaign.ID, storage.records[campaign.Key+testdata.ValidUser].CampaignID)
+	assertOutput.Equal("goal", storage.records[campaign.Key+testdata.ValidUser].GoalIdentifier)
+	assertOutput.False(storage.records[campaign.Key+testdata.ValidUser].AssignedAt.IsZero())
+
+	// the deprecated storage can not keep the IDs, its data is never migrated
+	legacyStorage := &legacyUserStorage{userData: schema.UserData{UserID: "user", CampaignKey: campaign.Key, VariationName: "Renamed"}}
+	vwoInstance.UserStorage = schema.NewLegacyUserStorage(legacyStorage)
+	for i := 0; i < 2; i++ {
+		actual, _, err = GetVariation(vwoInstance, "user", campaign, "", schema.Options{})
+		assertOutput.Nil(err)
+		assertOutput.Equal("Renamed", actual.Name)
+	}
+	assertOutput.Equal(0, legacyStorage.sets)
+}
+
+type legacyUserStorage struct {
+	userData schema.UserData
+	sets     int
+}
+
+func (storage *legacyUserStorage) Get(userID, campaignKey string) schema.UserData {
+	return storage.userData
+}
+
+func (storage *legacyUserStorage) Set(userID, campaignKey, variationName, goalIdentifier string) {
+	storage.sets++
+}
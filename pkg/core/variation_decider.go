@@ -17,6 +17,7 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
@@ -33,40 +34,57 @@ type VariationDecider struct {
 	SegmentEvaluator string
 }
 
-// GetVariation function
+// GetVariation function returns the variation assigned to the user for the campaign and the goals already tracked for the user,
+// see GetVariationAndUserData
+func GetVariation(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign, goalIdentifier string, options schema.Options) (schema.Variation, string, error) {
+	variation, userData, _, err := GetVariationAndUserData(vwoInstance, userID, campaign, goalIdentifier, options)
+	return variation, userData.GoalIdentifier, err
+}
+
+// GetVariationAndUserData function
 /*	Returns variation for the user for given campaign
     This method achieves the variation assignment in the following way:
-    1. First get variation from UserStorage, if variation is found in user_storage_data,
-    return from there
+    1. If a variation is forced for the campaign in the overrides file or for the user at runtime, return it
     2. Evaluates white listing users for each variation, and find a targeted variation.
-    3. If no targeted variation is found, evaluate pre-segmentation result
-    4. Evaluate percent traffic
-    5. If user becomes part of campaign assign a variation.
-	6. Store the variation found in the user_storage
+    2.1. If the bucketing key is in the global holdout group, return control (no variation for rollouts)
+    3. If the campaign is out of its startTime and endTime, return no variation
+    4. Get variation from UserStorage, if variation is found in user_storage_data,
+    return from there, once the segments are evaluated again if alwaysCheckSegment is set
+    5. Evaluate percent traffic, as per the ramp step reached at the current time if any,
+    it is computed once and used for the bucketing too
+    6. If no targeted variation is found, evaluate pre-segmentation result
+    7. If user becomes part of campaign assign a variation.
+	8. Store the variation found in the user_storage
 */
-func GetVariation(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign, goalIdentifier string, options schema.Options) (schema.Variation, string, error) {
+func GetVariationAndUserData(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign, goalIdentifier string, options schema.Options) (schema.Variation, schema.UserData, bool, error) {
 	/*
 		Args:
 			userId: the unique ID assigned to User
 			campaign: campaign in which user is participating
 			customVariables(In option): variables for pre-segmentation
 			variationTargetingVariables(In option): variables for variation targeting
+			bucketingKey(In option): identity hashed instead of the user ID to bucket the user
+			alwaysCheckSegment(In option): re-evaluate the segments for users with a stored variation
 			revenueValue(In option): Value of revenue for the goal if the goal is revenue tracking
 
 		Returns:
 			schema.Variation: Struct object containing the information regarding variation assigned else empty object
+			schema.UserData: data stored for the user before this call, or stored for the variation assigned
+			bool: true if the variation is forced for the user or the campaign, it must not be stored
 			error: Error message
 	*/
 	vwoInstance.UserID = userID
 	vwoInstance.Campaign = campaign
 	integrationsMap := getIntegrationsMap(vwoInstance, campaign, userID, goalIdentifier, options)
+	options = addUserIDTargetingVariable(options, userID)
 
-	_, ok := options.VariationTargetingVariables["_vwo_user_id"]
-	if !ok {
-		if options.VariationTargetingVariables == nil {
-			options.VariationTargetingVariables = make(map[string]interface{})
-		}
-		options.VariationTargetingVariables["_vwo_user_id"] = userID
+	forcedVariation, err := GetForcedVariation(vwoInstance, userID, campaign)
+	if err != nil {
+		utils.LogMessage(vwoInstance.Logger, constants.Error, variationDecider, err.Error())
+	} else if forcedVariation.Name != "" {
+		integrationsMap["isForcedVariation"] = true
+		vwoInstance.Integrations.ExecuteCallBack(integrationsMap, false, campaign, forcedVariation, false)
+		return forcedVariation, schema.UserData{}, true, nil
 	}
 
 	targettedVariation, err := FindTargetedVariation(vwoInstance, userID, campaign, options)
@@ -76,50 +94,88 @@ func GetVariation(vwoInstance schema.VwoInstance, userID string, campaign schema
 		message := fmt.Sprintf(constants.InfoMessageGotVariationForUser, vwoInstance.API, userID, campaign.Key, campaign.Type, targettedVariation.Name)
 		utils.LogMessage(vwoInstance.Logger, constants.Info, variationDecider, message)
 		vwoInstance.Integrations.ExecuteCallBack(integrationsMap, false, campaign, targettedVariation, true)
-		return targettedVariation, "", nil
+		return targettedVariation, schema.UserData{}, false, nil
+	}
+
+	bucketingKey := GetBucketingKey(vwoInstance, userID, campaign, options)
+	if IsUserInHoldout(vwoInstance, bucketingKey) {
+		message := fmt.Sprintf(constants.InfoMessageUserInHoldout, vwoInstance.API, userID, campaign.Key)
+		utils.LogMessage(vwoInstance.Logger, constants.Info, variationDecider, message)
+		if utils.CheckCampaignType(campaign, constants.CampaignTypeFeatureRollout) {
+			return schema.Variation{}, schema.UserData{}, false, fmt.Errorf(constants.DebugMessageUserNotPartOfCampaign, vwoInstance.API, userID, campaign.Key, campaign.Type, "IsUserInHoldout")
+		}
+		integrationsMap["isHoldout"] = true
+		variation := utils.GetControlVariation(campaign)
+		vwoInstance.Integrations.ExecuteCallBack(integrationsMap, false, campaign, variation, false)
+		return variation, schema.UserData{}, false, nil
 	}
 
-	variationName, storedGoalIdentifier := GetVariationFromUserStorage(vwoInstance, userID, campaign)
-	if variationName != "" {
-		message := fmt.Sprintf(constants.InfoMessageGotStoredVariation, vwoInstance.API, variationName, campaign.Key, userID)
+	if !IsCampaignScheduled(vwoInstance, campaign) {
+		return schema.Variation{}, schema.UserData{}, false, fmt.Errorf(constants.DebugMessageUserNotPartOfCampaign, vwoInstance.API, userID, campaign.Key, campaign.Type, "IsCampaignScheduled")
+	}
+
+	userData := GetUserDataFromUserStorage(vwoInstance, userID, campaign, options)
+	if userData.VariationName != "" || userData.VariationID != 0 {
+		message := fmt.Sprintf(constants.InfoMessageGotStoredVariation, vwoInstance.API, userData.VariationName, campaign.Key, userID)
 		utils.LogMessage(vwoInstance.Logger, constants.Info, variationDecider, message)
-		variation, err := utils.GetCampaignVariation(vwoInstance.API, campaign, variationName)
+		if (campaign.IsAlwaysCheckSegment || options.AlwaysCheckSegment) && !EvaluateSegment(vwoInstance, campaign.Segments, options) {
+			// the stored data is kept so the user gets the same variation back once matching the segments again
+			message := fmt.Sprintf(constants.InfoMessageStoredUserFailedSegmentation, vwoInstance.API, userID, campaign.Key)
+			utils.LogMessage(vwoInstance.Logger, constants.Info, variationDecider, message)
+			return schema.Variation{}, schema.UserData{}, false, fmt.Errorf(constants.DebugMessageUserNotPartOfCampaign, vwoInstance.API, userID, campaign.Key, campaign.Type, "EvaluateSegment")
+		}
+		variation, err := utils.GetStoredVariation(vwoInstance.API, campaign, userData)
 		vwoInstance.Integrations.ExecuteCallBack(integrationsMap, true, campaign, variation, false)
-		return variation, storedGoalIdentifier, err
+		if err != nil {
+			return variation, userData, false, err
+		}
+		if !schema.IsLegacyUserStorage(vwoInstance.UserStorage) {
+			if migratedUserData, ok := MigrateUserData(campaign, variation, userData); ok {
+				message := fmt.Sprintf(constants.InfoMessageUserDataMigrated, vwoInstance.API, userID, campaign.Key, variation.Name, variation.ID)
+				utils.LogMessage(vwoInstance.Logger, constants.Info, variationDecider, message)
+				SetUserStorageData(vwoInstance, migratedUserData, options)
+				userData = migratedUserData
+			}
+		}
+		return variation, userData, false, nil
 	}
 
-	if !IsUserPartOfCampaign(vwoInstance, userID, campaign) {
-		return schema.Variation{}, "", fmt.Errorf(constants.DebugMessageUserNotPartOfCampaign, vwoInstance.API, userID, campaign.Key, campaign.Type, "IsUserPartOfCampaign")
+	percentTraffic := GetEffectivePercentTraffic(vwoInstance, campaign)
+	isUserPart := IsUserPartOfCampaignWithTraffic(vwoInstance, bucketingKey, campaign, percentTraffic)
+	if vwoInstance.AssignmentRecorder != nil {
+		vwoInstance.AssignmentRecorder.RecordEvaluation(campaign, bucketingKey, percentTraffic, isUserPart)
+	}
+	if !isUserPart {
+		return schema.Variation{}, schema.UserData{}, false, fmt.Errorf(constants.DebugMessageUserNotPartOfCampaign, vwoInstance.API, userID, campaign.Key, campaign.Type, "IsUserPartOfCampaign")
 	}
 
 	if EvaluateSegment(vwoInstance, campaign.Segments, options) {
-		variation, err := BucketUserToVariation(vwoInstance, userID, campaign)
+		variation, err := BucketUserToVariationWithTraffic(vwoInstance, bucketingKey, campaign, percentTraffic)
 		vwoInstance.Integrations.ExecuteCallBack(integrationsMap, false, campaign, variation, false)
 		if err != nil {
-			return schema.Variation{}, "", fmt.Errorf(constants.InfoMessageUserGotNoVariation, vwoInstance.API, userID, campaign.Key, err.Error())
+			return schema.Variation{}, schema.UserData{}, false, fmt.Errorf(constants.InfoMessageUserGotNoVariation, vwoInstance.API, userID, campaign.Key, err.Error())
 		}
 
-		if vwoInstance.UserStorage == nil {
-			message := fmt.Sprintf(constants.DebugMessageNoUserStorageServiceSet, vwoInstance.API)
-			utils.LogMessage(vwoInstance.Logger, constants.Warning, variationDecider, message)
-		} else {
-			if storage, ok := vwoInstance.UserStorage.(interface{ Set(a, b, c, d string) }); ok {
-				storage.Set(userID, campaign.Key, variation.Name, goalIdentifier)
-				message := fmt.Sprintf(constants.InfoMessageSettingDataUserStorageService, vwoInstance.API, userID)
-				utils.LogMessage(vwoInstance.Logger, constants.Info, variationDecider, message)
-			} else {
-				message := fmt.Sprintf(constants.ErrorMessageSetUserStorageServiceFailed, vwoInstance.API, userID)
-				utils.LogMessage(vwoInstance.Logger, constants.Debug, variationDecider, message)
-			}
+		storedGoalIdentifier := goalIdentifier
+		if vwoInstance.ConversionStore != nil || options.NoTrackingConsent {
+			// the conversions are kept in the conversion store, and no conversion is kept without tracking consent
+			storedGoalIdentifier = ""
 		}
+		userData := NewUserData(vwoInstance, userID, campaign, variation, storedGoalIdentifier)
+		SetUserStorageData(vwoInstance, userData, options)
+		// the goal is not tracked yet
+		userData.GoalIdentifier = ""
 
 		message := fmt.Sprintf(constants.InfoMessageVariationAllocated, vwoInstance.API, userID, campaign.Key, variation.Name)
 		utils.LogMessage(vwoInstance.Logger, constants.Info, variationDecider, message)
+		if vwoInstance.AssignmentRecorder != nil {
+			vwoInstance.AssignmentRecorder.RecordAssignment(campaign, bucketingKey, variation)
+		}
 
-		return variation, "", nil
+		return variation, userData, false, nil
 	}
 
-	return schema.Variation{}, "", fmt.Errorf(constants.ErrorMessageNoVariationAlloted, vwoInstance.API, userID, campaign.Key, campaign.Type)
+	return schema.Variation{}, schema.UserData{}, false, fmt.Errorf(constants.ErrorMessageNoVariationAlloted, vwoInstance.API, userID, campaign.Key, campaign.Type)
 }
 
 // FindTargetedVariation function Identifies and retrives if there exists any targeted
@@ -131,6 +187,7 @@ func FindTargetedVariation(vwoInstance schema.VwoInstance, userID string, campai
 			campaign: campaign in which user is participating
 			customVariables(In option): variables for pre-segmentation
 			variationTargetingVariables(In option): variables for variation targeting
+			bucketingKey(In option): identity hashed instead of the user ID to bucket the user
 			revenueValue(In option): Value of revenue for the goal if the goal is revenue tracking
 
 		Returns:
@@ -156,7 +213,7 @@ func FindTargetedVariation(vwoInstance schema.VwoInstance, userID string, campai
 	} else {
 		whiteListedVariationsList = utils.ScaleVariations(whiteListedVariationsList)
 		whiteListedVariationsList = utils.GetVariationAllocationRanges(vwoInstance, whiteListedVariationsList)
-		_, bucketValue := GetBucketValueForUser(vwoInstance, userID, constants.MaxTrafficValue, 1, campaign)
+		_, bucketValue := GetBucketValueForUser(vwoInstance, GetBucketingKey(vwoInstance, userID, campaign, options), constants.MaxTrafficValue, 1, campaign)
 		var err error
 		targettedVariation, err = GetBucketerVariation(vwoInstance, whiteListedVariationsList, bucketValue, userID, campaign.Key)
 		if err != nil {
@@ -172,40 +229,185 @@ func FindTargetedVariation(vwoInstance schema.VwoInstance, userID string, campai
 	return targettedVariation, nil
 }
 
+// GetForcedVariation function returns the variation forced at runtime for the userID in the given campaign,
+// a variation forced for the whole campaign in the overrides file takes precedence
+func GetForcedVariation(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign) (schema.Variation, error) {
+	/*
+		Args:
+			userId: the unique ID assigned to User
+			campaign: campaign in which user is participating
+
+		Returns:
+			schema.Variation: forced variation, empty if no variation is forced for the user
+			error: if the forced variation is no longer part of the campaign
+	*/
+
+	variationName := vwoInstance.Overrides.GetForcedVariation(campaign.Key)
+	if variationName == "" {
+		forcedVariationName, ok := vwoInstance.ForcedVariations.Get(userID, campaign.Key)
+		if !ok {
+			return schema.Variation{}, nil
+		}
+		variationName = forcedVariationName
+	}
+	variation, err := utils.GetCampaignVariation(vwoInstance.API, campaign, variationName)
+	if err != nil {
+		return schema.Variation{}, err
+	}
+
+	message := fmt.Sprintf(constants.InfoMessageForcedvariationAllocated, vwoInstance.API, userID, campaign.Key, campaign.Type, variation.Name)
+	utils.LogMessage(vwoInstance.Logger, constants.Info, variationDecider, message)
+	return variation, nil
+}
+
+// IsUserInCampaignHoldout function returns true if the user is kept out of the campaign by the global holdout group,
+// users getting a forced or whitelisted variation of the campaign are not
+func IsUserInCampaignHoldout(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign, options schema.Options) bool {
+	/*
+		Args:
+			userId: the unique ID assigned to User
+			campaign: campaign in which user is participating
+			variationTargetingVariables(In option): variables for variation targeting
+			bucketingKey(In option): identity hashed instead of the user ID to bucket the user
+
+		Returns:
+			bool: true if the user gets the holdout decision for the campaign
+	*/
+
+	if forcedVariation, err := GetForcedVariation(vwoInstance, userID, campaign); err == nil && forcedVariation.Name != "" {
+		return false
+	}
+	if _, err := FindTargetedVariation(vwoInstance, userID, campaign, addUserIDTargetingVariable(options, userID)); err == nil {
+		return false
+	}
+	return IsUserInHoldout(vwoInstance, GetBucketingKey(vwoInstance, userID, campaign, options))
+}
+
+// addUserIDTargetingVariable function adds the userID to the variation targeting variables unless already passed
+func addUserIDTargetingVariable(options schema.Options, userID string) schema.Options {
+	if _, ok := options.VariationTargetingVariables["_vwo_user_id"]; !ok {
+		if options.VariationTargetingVariables == nil {
+			options.VariationTargetingVariables = make(map[string]interface{})
+		}
+		options.VariationTargetingVariables["_vwo_user_id"] = userID
+	}
+	return options
+}
+
+// GetBucketingKey function returns the identity hashed to bucket the user, the bucketingKey option if passed else the userID
+func GetBucketingKey(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign, options schema.Options) string {
+	/*
+		Args:
+			userId: the unique ID assigned to User
+			campaign: campaign in which user is participating
+			bucketingKey(In option): identity shared by users who must get the same variation, like an organization ID
+
+		Returns:
+			string: the identity to hash for the user
+	*/
+
+	if options.BucketingKey == "" {
+		return userID
+	}
+	message := fmt.Sprintf(constants.DebugMessageBucketingKeyUsed, vwoInstance.API, userID, campaign.Key, options.BucketingKey)
+	utils.LogMessage(vwoInstance.Logger, constants.Debug, variationDecider, message)
+	return options.BucketingKey
+}
+
 // GetVariationFromUserStorage function tries retrieving variation from user_storage
-func GetVariationFromUserStorage(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign) (string, string) {
+func GetVariationFromUserStorage(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign, options schema.Options) (string, string) {
 	/*
 		Args:
 			userId: the unique ID assigned to User
 			campaign: campaign in which user is participating
+			options: the context(In option) is passed to the user storage
 
 		Returns:
 			variationName: Name of the found varaition in the user storage
-			string: Log level
-			error: Error message
+			goalIdentifier: Goals already tracked for the user
+	*/
+
+	userData := GetUserDataFromUserStorage(vwoInstance, userID, campaign, options)
+	return userData.VariationName, userData.GoalIdentifier
+}
+
+// GetUserDataFromUserStorage function tries retrieving the data of the user for the campaign from user_storage
+func GetUserDataFromUserStorage(vwoInstance schema.VwoInstance, userID string, campaign schema.Campaign, options schema.Options) schema.UserData {
+	/*
+		Args:
+			userId: the unique ID assigned to User
+			campaign: campaign in which user is participating
+			options: the context(In option) is passed to the user storage
+
+		Returns:
+			schema.UserData: stored data, empty if none is found
 	*/
 
 	if vwoInstance.UserStorage == nil {
 		message := fmt.Sprintf(constants.InfoMessageNoUserStorageServiceGet, vwoInstance.API)
 		utils.LogMessage(vwoInstance.Logger, constants.Debug, variationDecider, message)
-		return "", ""
+		return schema.UserData{}
 	}
-	if storage, ok := vwoInstance.UserStorage.(interface {
-		Get(a, b string) schema.UserData
-	}); ok {
-		userStorageFetch := storage.Get(userID, campaign.Key)
-		message := fmt.Sprintf(constants.DebugMessageGettingStoredVariation, vwoInstance.API, userID, campaign.Key)
+	storage, ok := schema.ToUserStorage(vwoInstance.UserStorage)
+	if !ok {
+		message := fmt.Sprintf(constants.ErrorMessageGetUserStorageServiceFailed, vwoInstance.API, userID)
 		utils.LogMessage(vwoInstance.Logger, constants.Debug, variationDecider, message)
-		if userStorageFetch.VariationName == "" {
-			message := fmt.Sprintf(constants.DebugMessageNoStoredVariation, vwoInstance.API, userID, campaign.Key)
-			utils.LogMessage(vwoInstance.Logger, constants.Debug, variationDecider, message)
-		}
-		return userStorageFetch.VariationName, userStorageFetch.GoalIdentifier
+		return schema.UserData{}
 	}
 
-	message := fmt.Sprintf(constants.ErrorMessageGetUserStorageServiceFailed, vwoInstance.API, userID)
+	message := fmt.Sprintf(constants.DebugMessageGettingStoredVariation, vwoInstance.API, userID, campaign.Key)
 	utils.LogMessage(vwoInstance.Logger, constants.Debug, variationDecider, message)
-	return "", ""
+	userStorageFetch, err := storage.Get(getContext(options), userID, campaign.Key)
+	if err != nil {
+		message := fmt.Sprintf(constants.ErrorMessageGetUserStorageServiceError, vwoInstance.API, userID, campaign.Key, err.Error())
+		utils.LogMessage(vwoInstance.Logger, constants.Error, variationDecider, message)
+		return schema.UserData{}
+	}
+	if userStorageFetch.VariationName == "" && userStorageFetch.VariationID == 0 {
+		message := fmt.Sprintf(constants.DebugMessageNoStoredVariation, vwoInstance.API, userID, campaign.Key)
+		utils.LogMessage(vwoInstance.Logger, constants.Debug, variationDecider, message)
+	}
+	return userStorageFetch
+}
+
+// SetUserStorageData function saves the data of the user into user_storage, it returns false if it could not be saved
+func SetUserStorageData(vwoInstance schema.VwoInstance, userData schema.UserData, options schema.Options) bool {
+	/*
+		Args:
+			userData: variation and goals of the user to save
+			options: the context(In option) is passed to the user storage
+
+		Returns:
+			bool: true if the data was saved
+	*/
+
+	if vwoInstance.UserStorage == nil {
+		message := fmt.Sprintf(constants.DebugMessageNoUserStorageServiceSet, vwoInstance.API)
+		utils.LogMessage(vwoInstance.Logger, constants.Warning, variationDecider, message)
+		return false
+	}
+	storage, ok := schema.ToUserStorage(vwoInstance.UserStorage)
+	if !ok {
+		message := fmt.Sprintf(constants.ErrorMessageSetUserStorageServiceFailed, vwoInstance.API, userData.UserID)
+		utils.LogMessage(vwoInstance.Logger, constants.Debug, variationDecider, message)
+		return false
+	}
+	if err := storage.Set(getContext(options), userData); err != nil {
+		message := fmt.Sprintf(constants.ErrorMessageSetUserStorageServiceError, vwoInstance.API, userData.UserID, userData.CampaignKey, err.Error())
+		utils.LogMessage(vwoInstance.Logger, constants.Error, variationDecider, message)
+		return false
+	}
+	message := fmt.Sprintf(constants.InfoMessageSettingDataUserStorageService, vwoInstance.API, userData.UserID)
+	utils.LogMessage(vwoInstance.Logger, constants.Info, variationDecider, message)
+	return true
+}
+
+// getContext function returns the context passed in the options, or the background context
+func getContext(options schema.Options) context.Context {
+	if options.Context != nil {
+		return options.Context
+	}
+	return context.Background()
 }
 
 //GetWhiteListedVariationsList function identifies all forced variations which are targeted by variation_targeting_variables
@@ -239,7 +441,7 @@ func GetWhiteListedVariationsList(vwoInstance schema.VwoInstance, userID string,
 			whiteListedVariationsList = append(whiteListedVariationsList, variation)
 		}
 
-		message := fmt.Sprintf(constants.DebugMessageSegmentationStatusForVariation, vwoInstance.API, userID, campaign.Key, options.VariationTargetingVariables, variation.Segments, strconv.FormatBool(status), "WhiteListing", variation.Name)
+		message := fmt.Sprintf(constants.DebugMessageSegmentationStatusForVariation, vwoInstance.API, userID, campaign.Key, options.VariationTargetingVariables, segmentsForLog(variation.Segments), strconv.FormatBool(status), "WhiteListing", variation.Name)
 		utils.LogMessage(vwoInstance.Logger, constants.Debug, variationDecider, message)
 	}
 	return whiteListedVariationsList
@@ -265,7 +467,7 @@ func EvaluateSegment(vwoInstance schema.VwoInstance, segments map[string]interfa
 
 	status := SegmentEvaluator(segments, options.CustomVariables)
 
-	message := fmt.Sprintf(constants.InfoMessageSegmentationStatus, vwoInstance.API, vwoInstance.UserID, vwoInstance.Campaign.Key, segments, options.CustomVariables, strconv.FormatBool(status), "PreSegmentation")
+	message := fmt.Sprintf(constants.InfoMessageSegmentationStatus, vwoInstance.API, vwoInstance.UserID, vwoInstance.Campaign.Key, segmentsForLog(segments), options.CustomVariables, strconv.FormatBool(status), "PreSegmentation")
 	utils.LogMessage(vwoInstance.Logger, constants.Info, variationDecider, message)
 
 	return status
@@ -300,6 +502,8 @@ func getIntegrationsMap(vwoInstance schema.VwoInstance, campaign schema.Campaign
 	integrationsMap["event"] = constants.CampaignDecisionType
 	integrationsMap["goalIdentifier"] = goalIdentifier
 	integrationsMap["isForcedVariationEnabled"] = campaign.IsForcedVariation
+	integrationsMap["isForcedVariation"] = false
+	integrationsMap["isHoldout"] = false
 	integrationsMap["sdkVersion"] = constants.SDKVersion
 	integrationsMap["source"] = vwoInstance.API
 	integrationsMap["userId"] = userID
@@ -17,9 +17,12 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"log"
+	"strconv"
 	"testing"
 
 	"github.com/wingify/vwo-go-sdk/pkg/constants"
@@ -215,7 +218,7 @@ func TestGetVariationFromUserStorage(t *testing.T) {
 
 	campaign := vwoInstance.SettingsFile.Campaigns[0]
 	userID := testdata.ValidUser
-	actual, storedGoalIdentifier := GetVariationFromUserStorage(vwoInstance, userID, campaign)
+	actual, storedGoalIdentifier := GetVariationFromUserStorage(vwoInstance, userID, campaign, schema.Options{})
 	assertOutput.Empty(actual, "Actual and Expected Variation Name mismatch")
 
 	vwoInstance = testdata.GetInstanceWithStorage("AB_T_50_W_50_50")
@@ -223,15 +226,192 @@ func TestGetVariationFromUserStorage(t *testing.T) {
 	campaign = vwoInstance.SettingsFile.Campaigns[0]
 	userID = testdata.ValidUser
 	expected := testdata.DummyVariation
-	actual, storedGoalIdentifier = GetVariationFromUserStorage(vwoInstance, userID, campaign)
+	actual, storedGoalIdentifier = GetVariationFromUserStorage(vwoInstance, userID, campaign, schema.Options{})
 	assertOutput.Equal(testdata.DummyGoal, storedGoalIdentifier, "Actual and Expected goalIdentifier did not match")
 	assertOutput.Equal(expected, actual, "Actual and Expected Variation Name mismatch")
 
 	campaign = vwoInstance.SettingsFile.Campaigns[0]
 	userID = testdata.InvalidUser
 	expected = ""
-	actual, storedGoalIdentifier = GetVariationFromUserStorage(vwoInstance, userID, campaign)
+	actual, storedGoalIdentifier = GetVariationFromUserStorage(vwoInstance, userID, campaign, schema.Options{})
 	assertOutput.Equal(storedGoalIdentifier, "", "Actual and Expected goalIdentifier did not match")
 	assertOutput.Equal(expected, actual, "Actual and Expected Variation Name mismatch")
 
 }
+
+type contextKey string
+
+type failingUserStorage struct {
+	contexts []context.Context
+	saved    []schema.UserData
+}
+
+func (storage *failingUserStorage) Get(ctx context.Context, userID, campaignKey string) (schema.UserData, error) {
+	storage.contexts = append(storage.contexts, ctx)
+	return schema.UserData{}, errors.New("storage unavailable")
+}
+
+func (storage *failingUserStorage) Set(ctx context.Context, userData schema.UserData) error {
+	storage.contexts = append(storage.contexts, ctx)
+	storage.saved = append(storage.saved, userData)
+	return errors.New("storage unavailable")
+}
+
+func TestUserStorageErrors(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, campaign.Variations)
+	storage := &failingUserStorage{}
+	vwoInstance.UserStorage = storage
+
+	ctx := context.WithValue(context.Background(), contextKey("request"), "id")
+	options := schema.Options{Context: ctx}
+	variationName, goalIdentifier := GetVariationFromUserStorage(vwoInstance, testdata.ValidUser, campaign, options)
+	assertOutput.Empty(variationName)
+	assertOutput.Empty(goalIdentifier)
+
+	variation, _, err := GetVariation(vwoInstance, testdata.ValidUser, campaign, "", options)
+	assertOutput.Nil(err, "User should be bucketed when the storage fails")
+	assertOutput.NotEmpty(variation.Name)
+	assertOutput.Len(storage.saved, 1)
+	assertOutput.Equal(testdata.ValidUser, storage.saved[0].UserID)
+	assertOutput.Equal(campaign.Key, storage.saved[0].CampaignKey)
+	assertOutput.Equal(variation.Name, storage.saved[0].VariationName)
+	for _, storageContext := range storage.contexts {
+		assertOutput.Equal(ctx, storageContext)
+	}
+
+	assertOutput.False(SetUserStorageData(vwoInstance, schema.UserData{UserID: testdata.ValidUser}, schema.Options{}))
+	assertOutput.Equal(context.Background(), storage.contexts[len(storage.contexts)-1])
+}
+
+func TestGetVariationAlwaysCheckSegment(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, campaign.Variations)
+	campaign.Segments = map[string]interface{}{
+		"or": []interface{}{map[string]interface{}{"custom_variable": map[string]interface{}{"plan": "premium"}}},
+	}
+	variation := campaign.Variations[1]
+	storage := &batchUserStorage{records: map[string]schema.UserData{
+		campaign.Key + "user": NewUserData(vwoInstance, "user", campaign, variation, ""),
+	}}
+	vwoInstance.UserStorage = storage
+	premium := map[string]interface{}{"plan": "premium"}
+	free := map[string]interface{}{"plan": "free"}
+
+	actual, _, err := GetVariation(vwoInstance, "user", campaign, "", schema.Options{CustomVariables: free})
+	assertOutput.Nil(err)
+	assertOutput.Equal(variation.Name, actual.Name, "Segments are not checked for stored users by default")
+
+	_, _, err = GetVariation(vwoInstance, "user", campaign, "", schema.Options{CustomVariables: free, AlwaysCheckSegment: true})
+	assertOutput.NotNil(err, "User no longer matching the segments should be excluded")
+
+	actual, _, err = GetVariation(vwoInstance, "user", campaign, "", schema.Options{CustomVariables: premium, AlwaysCheckSegment: true})
+	assertOutput.Nil(err)
+	assertOutput.Equal(variation.Name, actual.Name, "User matching the segments should keep the stored variation")
+
+	campaign.IsAlwaysCheckSegment = true
+	_, _, err = GetVariation(vwoInstance, "user", campaign, "", schema.Options{CustomVariables: free})
+	assertOutput.NotNil(err, "Campaign setting should check the segments")
+	assertOutput.Equal(variation.Name, storage.records[campaign.Key+"user"].VariationName, "Stored data should be kept")
+	assertOutput.Equal(0, storage.sets)
+
+	actual, _, err = GetVariation(vwoInstance, "user", campaign, "", schema.Options{CustomVariables: premium})
+	assertOutput.Nil(err)
+	assertOutput.Equal(variation.Name, actual.Name)
+}
+
+func TestGetForcedVariation(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	actual, err := GetForcedVariation(vwoInstance, testdata.InvalidUser, campaign)
+	assertOutput.Nil(err)
+	assertOutput.Empty(actual, "No variation is forced")
+
+	vwoInstance.ForcedVariations = &schema.ForcedVariations{}
+	vwoInstance.ForcedVariations.Set(testdata.InvalidUser, campaign.Key, "Variation-1")
+	actual, err = GetForcedVariation(vwoInstance, testdata.InvalidUser, campaign)
+	assertOutput.Nil(err)
+	assertOutput.Equal("Variation-1", actual.Name)
+
+	variation, _, err := GetVariation(vwoInstance, testdata.InvalidUser, campaign, "", schema.Options{})
+	assertOutput.Nil(err)
+	assertOutput.Equal("Variation-1", variation.Name, "Forced variation should take precedence")
+
+	vwoInstance.ForcedVariations.Set(testdata.InvalidUser, campaign.Key, testdata.InvalidVariationName)
+	_, err = GetForcedVariation(vwoInstance, testdata.InvalidUser, campaign)
+	assertOutput.NotNil(err, "Forced variation is not part of the campaign")
+}
+
+func TestGetVariationHoldout(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_0_100")
+	vwoInstance.SettingsFile.Campaigns[0].Variations = utils.GetVariationAllocationRanges(vwoInstance, vwoInstance.SettingsFile.Campaigns[0].Variations)
+	vwoInstance.Holdout = &schema.Holdout{PercentTraffic: 100}
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	variation, _, err := GetVariation(vwoInstance, testdata.GetRandomUser(), campaign, "", schema.Options{})
+	assertOutput.Nil(err)
+	assertOutput.Equal("Control", variation.Name, "Held out users should get control")
+	assertOutput.True(IsUserInCampaignHoldout(vwoInstance, testdata.ValidUser, campaign, schema.Options{}))
+
+	vwoInstance.ForcedVariations = &schema.ForcedVariations{}
+	vwoInstance.ForcedVariations.Set(testdata.ValidUser, campaign.Key, "Variation-1")
+	variation, _, err = GetVariation(vwoInstance, testdata.ValidUser, campaign, "", schema.Options{})
+	assertOutput.Nil(err)
+	assertOutput.Equal("Variation-1", variation.Name, "Forced variation should take precedence over the holdout")
+	assertOutput.False(IsUserInCampaignHoldout(vwoInstance, testdata.ValidUser, campaign, schema.Options{}))
+
+	vwoInstance = testdata.GetInstanceWithSettings("FR_T_100_W_100")
+	vwoInstance.SettingsFile.Campaigns[0].Variations = utils.GetVariationAllocationRanges(vwoInstance, vwoInstance.SettingsFile.Campaigns[0].Variations)
+	vwoInstance.Holdout = &schema.Holdout{PercentTraffic: 100}
+	campaign = vwoInstance.SettingsFile.Campaigns[0]
+
+	variation, _, err = GetVariation(vwoInstance, testdata.GetRandomUser(), campaign, "", schema.Options{})
+	assertOutput.NotNil(err, "Held out users should not be part of rollouts")
+	assertOutput.Empty(variation)
+}
+
+func TestGetVariationHoldoutBucketingKey(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_0_100")
+	vwoInstance.SettingsFile.Campaigns[0].Variations = utils.GetVariationAllocationRanges(vwoInstance, vwoInstance.SettingsFile.Campaigns[0].Variations)
+	vwoInstance.Holdout = &schema.Holdout{PercentTraffic: 50}
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	for i := 0; i < 20; i++ {
+		options := schema.Options{BucketingKey: "organization-" + strconv.Itoa(i)}
+		expected := IsUserInHoldout(vwoInstance, options.BucketingKey)
+		for j := 0; j < 5; j++ {
+			userID := testdata.GetRandomUser()
+			assertOutput.Equal(expected, IsUserInCampaignHoldout(vwoInstance, userID, campaign, options), "Users sharing a bucketing key should share the holdout")
+			variation, _, err := GetVariation(vwoInstance, userID, campaign, "", options)
+			assertOutput.Nil(err)
+			assertOutput.Equal(expected, variation.Name == "Control", "Only held out users get control")
+		}
+	}
+}
+
+func TestGetVariationBucketingKey(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("T_75_W_10_TIMES_10")
+	vwoInstance.SettingsFile.Campaigns[0].Variations = utils.GetVariationAllocationRanges(vwoInstance, vwoInstance.SettingsFile.Campaigns[0].Variations)
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+
+	for i := 0; i < 20; i++ {
+		options := schema.Options{BucketingKey: "organization-" + strconv.Itoa(i)}
+		expected, _, expectedErr := GetVariation(vwoInstance, testdata.GetRandomUser(), campaign, "", options)
+		for j := 0; j < 5; j++ {
+			actual, _, err := GetVariation(vwoInstance, testdata.GetRandomUser(), campaign, "", options)
+			assertOutput.Equal(expected.Name, actual.Name, "Users of the same organization should get the same variation")
+			assertOutput.Equal(expectedErr == nil, err == nil)
+		}
+	}
+
+	assertOutput.Equal(testdata.ValidUser, GetBucketingKey(vwoInstance, testdata.ValidUser, campaign, schema.Options{}))
+}
@@ -17,6 +17,7 @@
 package event
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
@@ -35,6 +36,28 @@ func Dispatch(vwoInstance schema.VwoInstance, impression schema.Impression) {
 			impression: impression to be dispatched
 	*/
 
+	if err := dispatch(context.Background(), vwoInstance, impression); err != nil {
+		message := fmt.Sprintf(constants.ErrorMessageImpressionFailed, vwoInstance.API, err)
+		utils.LogMessage(vwoInstance.Logger, constants.Error, eventDispatcher, message)
+	}
+}
+
+// DispatchTrackingGoal function dispatches the event with goal tracking represented by
+// the impression object to our servers
+func DispatchTrackingGoal(vwoInstance schema.VwoInstance, goalType string, impression schema.Impression) {
+	/*
+		Args:
+			impression: impression to be dispatched
+	*/
+
+	if err := dispatchTrackingGoal(context.Background(), vwoInstance, goalType, impression); err != nil {
+		message := fmt.Sprintf(constants.ErrorMessageImpressionFailed, vwoInstance.API, err)
+		utils.LogMessage(vwoInstance.Logger, constants.Error, eventDispatcher, message)
+	}
+}
+
+// dispatch function sends the track user or push impression, nothing is sent in development mode
+func dispatch(ctx context.Context, vwoInstance schema.VwoInstance, impression schema.Impression) error {
 	if !vwoInstance.IsDevelopmentMode {
 		URL := impression.URL + "?" +
 			"random=" + strconv.FormatFloat(float64(impression.Random), 'f', -1, 64) +
@@ -55,32 +78,25 @@ func Dispatch(vwoInstance schema.VwoInstance, impression schema.Impression) {
 				"&experiment_id=" + strconv.Itoa(impression.ExperimentID) +
 				"&combination=" + strconv.Itoa(impression.Combination)
 		}
-		_, err := utils.GetRequest(URL)
+		err := getRequest(ctx, URL)
 		logURL := regexp.MustCompile(`(&env=.{32})`).ReplaceAllString(URL, "")
 
 		if err != nil {
-			message := fmt.Sprintf(constants.ErrorMessageImpressionFailed, vwoInstance.API, err)
-			utils.LogMessage(vwoInstance.Logger, constants.Error, eventDispatcher, message)
+			return err
+		}
+		if vwoInstance.API == "Push" {
+			message := fmt.Sprintf(constants.InfoMessageImpressionSuccess, vwoInstance.API, "Push", logURL)
+			utils.LogMessage(vwoInstance.Logger, constants.Info, eventDispatcher, message)
 		} else {
-			if vwoInstance.API == "Push" {
-				message := fmt.Sprintf(constants.InfoMessageImpressionSuccess, vwoInstance.API, "Push", logURL)
-				utils.LogMessage(vwoInstance.Logger, constants.Info, eventDispatcher, message)
-			} else {
-				message := fmt.Sprintf(constants.InfoMessageImpressionSuccess, vwoInstance.API, "Tracking User", logURL)
-				utils.LogMessage(vwoInstance.Logger, constants.Info, eventDispatcher, message)
-			}
+			message := fmt.Sprintf(constants.InfoMessageImpressionSuccess, vwoInstance.API, "Tracking User", logURL)
+			utils.LogMessage(vwoInstance.Logger, constants.Info, eventDispatcher, message)
 		}
 	}
+	return nil
 }
 
-// DispatchTrackingGoal function dispatches the event with goal tracking represented by
-// the impression object to our servers
-func DispatchTrackingGoal(vwoInstance schema.VwoInstance, goalType string, impression schema.Impression) {
-	/*
-		Args:
-			impression: impression to be dispatched
-	*/
-
+// dispatchTrackingGoal function sends the track goal impression, nothing is sent in development mode
+func dispatchTrackingGoal(ctx context.Context, vwoInstance schema.VwoInstance, goalType string, impression schema.Impression) error {
 	if !vwoInstance.IsDevelopmentMode {
 		URL := impression.URL + "?" +
 			"random=" + strconv.FormatFloat(float64(impression.Random), 'f', -1, 64) +
@@ -99,16 +115,14 @@ func DispatchTrackingGoal(vwoInstance schema.VwoInstance, goalType string, impre
 			URL = URL + "&r=" + impression.R
 		}
 
-		_, err := utils.GetRequest(URL)
-
+		err := getRequest(ctx, URL)
 		logURL := regexp.MustCompile(`(&env=.{32})`).ReplaceAllString(URL, "")
 
 		if err != nil {
-			message := fmt.Sprintf(constants.ErrorMessageImpressionFailed, vwoInstance.API, err)
-			utils.LogMessage(vwoInstance.Logger, constants.Error, eventDispatcher, message)
-		} else {
-			message := fmt.Sprintf(constants.InfoMessageImpressionSuccess, vwoInstance.API, "Tracking Goal", logURL)
-			utils.LogMessage(vwoInstance.Logger, constants.Info, eventDispatcher, message)
+			return err
 		}
+		message := fmt.Sprintf(constants.InfoMessageImpressionSuccess, vwoInstance.API, "Tracking Goal", logURL)
+		utils.LogMessage(vwoInstance.Logger, constants.Info, eventDispatcher, message)
 	}
+	return nil
 }
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package event
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/request"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+const httpDispatcher = "http_dispatcher.go"
+
+// HTTPDispatcher sends the events to the VWO endpoints, it is the default EventDispatcher.
+// Nothing is sent in development mode
+type HTTPDispatcher struct {
+	SettingsFile      schema.SettingsFile
+	Logger            interface{}
+	IsDevelopmentMode bool
+	UsageStats        map[string]string
+}
+
+// NewHTTPDispatcher returns a dispatcher sending the events of the account of the instance
+func NewHTTPDispatcher(vwoInstance schema.VwoInstance) *HTTPDispatcher {
+	return &HTTPDispatcher{
+		SettingsFile:      vwoInstance.SettingsFile,
+		Logger:            vwoInstance.Logger,
+		IsDevelopmentMode: vwoInstance.IsDevelopmentMode,
+		UsageStats:        schema.GetUsageStatsObject(vwoInstance),
+	}
+}
+
+// Dispatch function sends the event to the track user, track goal or push endpoint, the request is canceled with the context
+func (dispatcher *HTTPDispatcher) Dispatch(ctx context.Context, event schema.Event) error {
+	vwoInstance := schema.VwoInstance{
+		SettingsFile:      dispatcher.SettingsFile,
+		Logger:            dispatcher.Logger,
+		IsDevelopmentMode: dispatcher.IsDevelopmentMode,
+		API:               event.API,
+	}
+	if event.Type == constants.EventsTrackGoal {
+		return dispatchTrackingGoal(ctx, vwoInstance, event.GoalType, event.Impression)
+	}
+	return dispatch(ctx, vwoInstance, event.Impression)
+}
+
+// DispatchBatch function sends the events to the batch events endpoint in a single request, the request is canceled
+// with the context
+func (dispatcher *HTTPDispatcher) DispatchBatch(ctx context.Context, events []schema.Event) error {
+	if dispatcher.IsDevelopmentMode || len(events) == 0 {
+		return nil
+	}
+
+	impressions := make([]schema.Impression, len(events))
+	for i, event := range events {
+		impressions[i] = event.Impression
+	}
+	sdkKey := dispatcher.SettingsFile.SDKKey
+	accountID := strconv.Itoa(dispatcher.SettingsFile.AccountID)
+	headers := map[string]string{"Authorization": sdkKey}
+	baseURL := constants.BaseURL
+	if dispatcher.SettingsFile.CollectionPrefix != "" {
+		baseURL = baseURL + "/" + dispatcher.SettingsFile.CollectionPrefix
+	}
+	url := constants.HTTPSProtocol + baseURL + constants.BatchEndPoint
+	body := map[string]interface{}{"ev": schema.GetBatchMinifiedPayload(impressions, sdkKey)}
+	queryParams := map[string]string{
+		"a":   accountID,
+		"sd":  constants.SDKName,
+		"sv":  constants.SDKVersion,
+		"env": sdkKey,
+	}
+	for key, element := range dispatcher.UsageStats {
+		queryParams[key] = element
+	}
+
+	response, err := request.SendPostRequest(ctx, url, body, headers, queryParams)
+	if err == nil && response.StatusCode == http.StatusOK {
+		utils.LogMessage(dispatcher.Logger, constants.Info, httpDispatcher, fmt.Sprintf(constants.InfoBatchImpressionSuccess, constants.BatchEndPoint))
+		return nil
+	}
+
+	if err == nil {
+		var message string
+		if response.StatusCode == http.StatusRequestEntityTooLarge {
+			message = fmt.Sprintf(constants.DebugMessagePayloadTooLarge, constants.BatchEndPoint, len(events))
+		} else {
+			message = fmt.Sprintf(constants.ErrorMessageBatchImpressionFailed, constants.BatchEndPoint, strconv.Itoa(response.StatusCode))
+		}
+		err = newHTTPError(response, message)
+	}
+	utils.LogMessage(dispatcher.Logger, constants.Debug, httpDispatcher, err.Error())
+	return err
+}
+
+// HTTPError is returned by the HTTPDispatcher when VWO does not accept the events, RetryAfter is the delay asked
+// by the Retry-After header of the response, or 0
+type HTTPError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Message    string
+}
+
+// Error function returns the message of the error
+func (err *HTTPError) Error() string {
+	return err.Message
+}
+
+// Temporary function returns true if the request may succeed if sent again, i.e. for a 5xx or 429 status
+func (err *HTTPError) Temporary() bool {
+	return err.StatusCode >= http.StatusInternalServerError || err.StatusCode == http.StatusTooManyRequests
+}
+
+// newHTTPError function returns the error of a response with a non 2xx status
+func newHTTPError(response request.Response, message string) *HTTPError {
+	return &HTTPError{
+		StatusCode: response.StatusCode,
+		RetryAfter: parseRetryAfter(response.Header.Get("Retry-After"), time.Now()),
+		Message:    message,
+	}
+}
+
+// parseRetryAfter function returns the delay of a Retry-After header, given in seconds or as a date, 0 if it is invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// getRequest function sends a GET request to the url, an HTTPError is returned for a non 2xx status
+func getRequest(ctx context.Context, url string) error {
+	response, err := request.GetRequest(ctx, url)
+	if err != nil {
+		return fmt.Errorf(constants.ErrorMessageURLNotFound, "", err.Error())
+	}
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		endpoint := strings.SplitN(url, "?", 2)[0]
+		return newHTTPError(response, fmt.Sprintf(constants.ErrorMessageBatchImpressionFailed, endpoint, strconv.Itoa(response.StatusCode)))
+	}
+	return nil
+}
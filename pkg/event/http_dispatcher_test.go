@@ -0,0 +1,101 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/mocks"
+	"github.com/wingify/vwo-go-sdk/pkg/request"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+)
+
+func TestHTTPDispatcherDispatchBatch(t *testing.T) {
+	assertOutput := assert.New(t)
+	var requests []*http.Request
+	var bodies []map[string][]map[string]interface{}
+	status := http.StatusOK
+	client := request.Client
+	defer func() { request.Client = client }()
+	request.Client = mocks.MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			var body map[string][]map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			requests = append(requests, req)
+			bodies = append(bodies, body)
+			return &http.Response{StatusCode: status, Body: ioutil.NopCloser(bytes.NewBufferString(""))}, nil
+		},
+	}
+
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	vwoInstance.SettingsFile.AccountID = 12345
+	vwoInstance.SettingsFile.SDKKey = "sdkKey"
+	vwoInstance.SettingsFile.CollectionPrefix = "eu01"
+	vwoInstance.IsDevelopmentMode = false
+	dispatcher := NewHTTPDispatcher(vwoInstance)
+	events := []schema.Event{
+		{Type: constants.EventsTrackUser, Impression: schema.Impression{U: "U1", SID: "1", EventType: constants.EventsTrackUser, ExperimentID: 1, Combination: 2}},
+		{Type: constants.EventsPush, Impression: schema.Impression{U: "U2", SID: "1", EventType: constants.EventsPush, Tags: "tags"}},
+	}
+
+	assertOutput.Nil(dispatcher.DispatchBatch(context.Background(), events))
+	assertOutput.Len(requests, 1, "Events should be sent in a single request")
+	assertOutput.Equal("/eu01"+constants.BatchEndPoint, requests[0].URL.Path)
+	assertOutput.Equal("12345", requests[0].URL.Query().Get("a"))
+	assertOutput.Equal("sdkKey", requests[0].Header.Get("Authorization"))
+	assertOutput.Len(bodies[0]["ev"], 2)
+	assertOutput.Equal("U2", bodies[0]["ev"][1]["u"])
+	assertOutput.Equal("tags", bodies[0]["ev"][1]["t"])
+
+	status = http.StatusBadRequest
+	assertOutput.NotNil(dispatcher.DispatchBatch(context.Background(), events))
+
+	dispatcher.IsDevelopmentMode = true
+	assertOutput.Nil(dispatcher.DispatchBatch(context.Background(), events))
+	assertOutput.Nil(dispatcher.Dispatch(context.Background(), events[0]))
+	assertOutput.Len(requests, 2, "Nothing should be sent in development mode")
+}
+
+func TestHTTPDispatcherContext(t *testing.T) {
+	assertOutput := assert.New(t)
+	client := request.Client
+	defer func() { request.Client = client }()
+	request.Client = mocks.MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		},
+	}
+
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	vwoInstance.IsDevelopmentMode = false
+	dispatcher := NewHTTPDispatcher(vwoInstance)
+	event := schema.Event{Type: constants.EventsTrackUser, Impression: schema.Impression{U: "U1", EventType: constants.EventsTrackUser}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assertOutput.NotNil(dispatcher.Dispatch(ctx, event), "A canceled request should fail")
+	assertOutput.NotNil(dispatcher.DispatchBatch(ctx, []schema.Event{event}), "A canceled request should fail")
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package event
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+const retryingDispatcher = "retrying_dispatcher.go"
+
+// RetryingDispatcher sends the events with its Dispatcher and retries them following the Policy. Every error is retried
+// except an HTTPError which is not Temporary, the events not sent in the end are given to the DeadLetterCallBack
+type RetryingDispatcher struct {
+	Dispatcher schema.EventDispatcher
+	Policy     schema.RetryPolicy
+	Logger     interface{}
+
+	sleep func(ctx context.Context, delay time.Duration) error
+}
+
+// NewRetryingDispatcher returns a dispatcher retrying the events of the dispatcher, the Logger may be nil
+func NewRetryingDispatcher(dispatcher schema.EventDispatcher, policy schema.RetryPolicy, logger interface{}) *RetryingDispatcher {
+	policy.SetDefaults()
+	return &RetryingDispatcher{
+		Dispatcher: dispatcher,
+		Policy:     policy,
+		Logger:     logger,
+		sleep:      sleep,
+	}
+}
+
+// Dispatch function sends the event, retrying it until it is sent, the retries are exhausted or the context is done
+func (dispatcher *RetryingDispatcher) Dispatch(ctx context.Context, event schema.Event) error {
+	return dispatcher.retry(ctx, []schema.Event{event}, func() error {
+		return dispatcher.Dispatcher.Dispatch(ctx, event)
+	})
+}
+
+// DispatchBatch function sends and retries the events at once if the Dispatcher implements schema.BatchEventDispatcher,
+// one by one otherwise
+func (dispatcher *RetryingDispatcher) DispatchBatch(ctx context.Context, events []schema.Event) error {
+	if batchDispatcher, ok := dispatcher.Dispatcher.(schema.BatchEventDispatcher); ok {
+		return dispatcher.retry(ctx, events, func() error {
+			return batchDispatcher.DispatchBatch(ctx, events)
+		})
+	}
+	var err error
+	for _, event := range events {
+		if dispatchErr := dispatcher.Dispatch(ctx, event); err == nil {
+			err = dispatchErr
+		}
+	}
+	return err
+}
+
+// retry function calls send until it succeeds or fails for good, the events are then dead-lettered
+func (dispatcher *RetryingDispatcher) retry(ctx context.Context, events []schema.Event, send func() error) error {
+	err := send()
+	retries := 0
+	for ; err != nil && retries < dispatcher.Policy.MaxRetries && isTemporary(err); retries++ {
+		delay := dispatcher.getDelay(retries+1, err)
+		dispatcher.log(constants.Debug, fmt.Sprintf(constants.DebugMessageEventRetry, len(events), delay, retries+1, dispatcher.Policy.MaxRetries, err.Error()))
+		if dispatcher.sleep(ctx, delay) != nil {
+			break
+		}
+		err = send()
+	}
+	if err != nil {
+		dispatcher.log(constants.Error, fmt.Sprintf(constants.ErrorMessageEventsDeadLettered, len(events), retries, err.Error()))
+		if dispatcher.Policy.DeadLetterCallBack != nil {
+			dispatcher.Policy.DeadLetterCallBack(events, err)
+		}
+	}
+	return err
+}
+
+// getDelay function returns the delay before the retry, asked by the error or a jittered exponential backoff,
+// both capped to MaxBackoff
+func (dispatcher *RetryingDispatcher) getDelay(retry int, err error) time.Duration {
+	maxBackoff := dispatcher.Policy.MaxBackoff
+	if httpErr, ok := err.(*HTTPError); ok && httpErr.RetryAfter > 0 {
+		if httpErr.RetryAfter > maxBackoff {
+			return maxBackoff
+		}
+		return httpErr.RetryAfter
+	}
+	// the backoff is doubled step by step so that it never overflows
+	backoff := dispatcher.Policy.InitialBackoff
+	for i := 1; i < retry && backoff < maxBackoff; i++ {
+		if backoff > maxBackoff/2 {
+			backoff = maxBackoff
+		} else {
+			backoff *= 2
+		}
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// log function logs the message if the dispatcher has a logger
+func (dispatcher *RetryingDispatcher) log(level, message string) {
+	if dispatcher.Logger != nil {
+		utils.LogMessage(dispatcher.Logger, level, retryingDispatcher, message)
+	}
+}
+
+// isTemporary function returns false for the errors which would happen again if the events were sent again
+func isTemporary(err error) bool {
+	if httpErr, ok := err.(*HTTPError); ok {
+		return httpErr.Temporary()
+	}
+	return true
+}
+
+// sleep function waits for the delay, an error is returned if the context is done first
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
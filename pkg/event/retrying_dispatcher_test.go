@@ -0,0 +1,235 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package event
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/mocks"
+	"github.com/wingify/vwo-go-sdk/pkg/request"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+)
+
+type failingDispatcher struct {
+	errs   []error
+	events [][]schema.Event
+}
+
+func (dispatcher *failingDispatcher) Dispatch(ctx context.Context, event schema.Event) error {
+	dispatcher.events = append(dispatcher.events, []schema.Event{event})
+	return dispatcher.next()
+}
+
+func (dispatcher *failingDispatcher) next() error {
+	if len(dispatcher.errs) == 0 {
+		return nil
+	}
+	err := dispatcher.errs[0]
+	dispatcher.errs = dispatcher.errs[1:]
+	return err
+}
+
+type failingBatchDispatcher struct {
+	failingDispatcher
+}
+
+func (dispatcher *failingBatchDispatcher) DispatchBatch(ctx context.Context, events []schema.Event) error {
+	dispatcher.events = append(dispatcher.events, events)
+	return dispatcher.next()
+}
+
+func getRetryingDispatcher(dispatcher schema.EventDispatcher, policy schema.RetryPolicy) (*RetryingDispatcher, *[]time.Duration) {
+	var delays []time.Duration
+	retryingDispatcher := NewRetryingDispatcher(dispatcher, policy, testdata.GetInstanceWithSettings("AB_T_50_W_50_50").Logger)
+	retryingDispatcher.sleep = func(ctx context.Context, delay time.Duration) error {
+		delays = append(delays, delay)
+		return ctx.Err()
+	}
+	return retryingDispatcher, &delays
+}
+
+func TestRetryingDispatcherDispatch(t *testing.T) {
+	assertOutput := assert.New(t)
+	event := schema.Event{Type: constants.EventsTrackUser, UserID: "user"}
+	var deadLetters []schema.Event
+	var deadLetterErr error
+	policy := schema.RetryPolicy{
+		MaxRetries:     3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+		DeadLetterCallBack: func(events []schema.Event, err error) {
+			deadLetters = append(deadLetters, events...)
+			deadLetterErr = err
+		},
+	}
+
+	dispatcher := &failingDispatcher{errs: []error{errors.New("connection refused"), &HTTPError{StatusCode: http.StatusServiceUnavailable}}}
+	retryingDispatcher, delays := getRetryingDispatcher(dispatcher, policy)
+	assertOutput.Nil(retryingDispatcher.Dispatch(context.Background(), event))
+	assertOutput.Len(dispatcher.events, 3)
+	assertOutput.Len(*delays, 2)
+	assertOutput.True((*delays)[0] >= 50*time.Millisecond && (*delays)[0] <= 100*time.Millisecond)
+	assertOutput.True((*delays)[1] >= 100*time.Millisecond && (*delays)[1] <= 200*time.Millisecond)
+	assertOutput.Empty(deadLetters)
+
+	// the Retry-After delay is used, both are capped to MaxBackoff
+	tooManyRequests := &HTTPError{StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Hour}
+	serverError := &HTTPError{StatusCode: http.StatusInternalServerError}
+	dispatcher = &failingDispatcher{errs: []error{tooManyRequests, serverError, serverError, serverError}}
+	retryingDispatcher, delays = getRetryingDispatcher(dispatcher, policy)
+	assertOutput.Equal(serverError, retryingDispatcher.Dispatch(context.Background(), event))
+	assertOutput.Len(dispatcher.events, 4)
+	assertOutput.Equal(300*time.Millisecond, (*delays)[0], "Retry-After should be capped to MaxBackoff")
+	assertOutput.True((*delays)[2] >= 150*time.Millisecond && (*delays)[2] <= 300*time.Millisecond)
+	assertOutput.Equal([]schema.Event{event}, deadLetters)
+	assertOutput.Equal(serverError, deadLetterErr)
+
+	// a rejected event is not retried
+	deadLetters = nil
+	badRequest := &HTTPError{StatusCode: http.StatusBadRequest}
+	dispatcher = &failingDispatcher{errs: []error{badRequest}}
+	retryingDispatcher, delays = getRetryingDispatcher(dispatcher, policy)
+	assertOutput.Equal(badRequest, retryingDispatcher.Dispatch(context.Background(), event))
+	assertOutput.Len(dispatcher.events, 1)
+	assertOutput.Empty(*delays)
+	assertOutput.Equal([]schema.Event{event}, deadLetters)
+
+	// the retries stop when the context is done
+	deadLetters = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dispatcher = &failingDispatcher{errs: []error{serverError}}
+	retryingDispatcher, _ = getRetryingDispatcher(dispatcher, policy)
+	assertOutput.Equal(serverError, retryingDispatcher.Dispatch(ctx, event))
+	assertOutput.Len(dispatcher.events, 1)
+	assertOutput.Equal([]schema.Event{event}, deadLetters)
+}
+
+func TestRetryingDispatcherDispatchBatch(t *testing.T) {
+	assertOutput := assert.New(t)
+	events := []schema.Event{{UserID: "first"}, {UserID: "second"}}
+	var deadLetters []schema.Event
+	policy := schema.RetryPolicy{
+		MaxRetries:     1,
+		InitialBackoff: time.Millisecond,
+		DeadLetterCallBack: func(events []schema.Event, err error) {
+			deadLetters = append(deadLetters, events...)
+		},
+	}
+	serverError := &HTTPError{StatusCode: http.StatusBadGateway}
+
+	batchDispatcher := &failingBatchDispatcher{failingDispatcher{errs: []error{serverError}}}
+	retryingDispatcher, _ := getRetryingDispatcher(batchDispatcher, policy)
+	assertOutput.Nil(retryingDispatcher.DispatchBatch(context.Background(), events))
+	assertOutput.Equal([][]schema.Event{events, events}, batchDispatcher.events, "The batch should be sent again at once")
+
+	batchDispatcher = &failingBatchDispatcher{failingDispatcher{errs: []error{serverError, serverError}}}
+	retryingDispatcher, _ = getRetryingDispatcher(batchDispatcher, policy)
+	assertOutput.Equal(serverError, retryingDispatcher.DispatchBatch(context.Background(), events))
+	assertOutput.Equal(events, deadLetters)
+
+	// without a batch dispatcher only the failing events are retried and dead-lettered
+	deadLetters = nil
+	dispatcher := &failingDispatcher{errs: []error{serverError, serverError}}
+	retryingDispatcher, _ = getRetryingDispatcher(dispatcher, policy)
+	assertOutput.Equal(serverError, retryingDispatcher.DispatchBatch(context.Background(), events))
+	assertOutput.Equal([][]schema.Event{events[:1], events[:1], events[1:]}, dispatcher.events)
+	assertOutput.Equal(events[:1], deadLetters)
+}
+
+func TestRetryingDispatcherGetDelay(t *testing.T) {
+	assertOutput := assert.New(t)
+	retryingDispatcher := NewRetryingDispatcher(nil, schema.RetryPolicy{InitialBackoff: time.Hour, MaxBackoff: 2 * time.Hour}, nil)
+	serverError := &HTTPError{StatusCode: http.StatusInternalServerError}
+	for retry := 1; retry < 100; retry++ {
+		delay := retryingDispatcher.getDelay(retry, serverError)
+		assertOutput.True(delay >= 30*time.Minute && delay <= 2*time.Hour, "Backoff should not overflow")
+	}
+	retryingDispatcher.Policy.MaxBackoff = time.Duration(math.MaxInt64)
+	for retry := 1; retry < 100; retry++ {
+		assertOutput.True(retryingDispatcher.getDelay(retry, serverError) > 0, "Backoff should not overflow")
+	}
+	assertOutput.Equal(2*time.Second, retryingDispatcher.getDelay(1, &HTTPError{StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Second}))
+}
+
+func TestRetryPolicySetDefaults(t *testing.T) {
+	assertOutput := assert.New(t)
+	policy := schema.RetryPolicy{}
+	policy.SetDefaults()
+	assertOutput.Equal(constants.EventDefaultMaxRetries, policy.MaxRetries)
+	assertOutput.Equal(constants.EventDefaultInitialBackoff*time.Second, policy.InitialBackoff)
+	assertOutput.Equal(constants.EventDefaultMaxBackoff*time.Second, policy.MaxBackoff)
+
+	policy = schema.RetryPolicy{MaxRetries: 5, InitialBackoff: time.Minute}
+	policy.SetDefaults()
+	assertOutput.Equal(5, policy.MaxRetries)
+	assertOutput.Equal(time.Minute, policy.MaxBackoff)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assertOutput := assert.New(t)
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	assertOutput.Equal(120*time.Second, parseRetryAfter("120", now))
+	assertOutput.Equal(time.Minute, parseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now))
+	assertOutput.Equal(time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+	assertOutput.Equal(time.Duration(0), parseRetryAfter("-1", now))
+	assertOutput.Equal(time.Duration(0), parseRetryAfter("soon", now))
+	assertOutput.Equal(time.Duration(0), parseRetryAfter("", now))
+}
+
+func TestHTTPDispatcherErrors(t *testing.T) {
+	assertOutput := assert.New(t)
+	status := http.StatusTooManyRequests
+	client := request.Client
+	defer func() { request.Client = client }()
+	request.Client = mocks.MockClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			header := http.Header{}
+			header.Set("Retry-After", "30")
+			return &http.Response{StatusCode: status, Header: header, Body: ioutil.NopCloser(bytes.NewBufferString(""))}, nil
+		},
+	}
+
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	vwoInstance.SettingsFile.SDKKey = "sdkKey"
+	vwoInstance.IsDevelopmentMode = false
+	dispatcher := NewHTTPDispatcher(vwoInstance)
+	event := schema.Event{Type: constants.EventsTrackUser, Impression: schema.Impression{URL: "https://dev.visualwebsiteoptimizer.com/server-side/track-user", EventType: constants.EventsTrackUser}}
+
+	for _, err := range []error{dispatcher.Dispatch(context.Background(), event), dispatcher.DispatchBatch(context.Background(), []schema.Event{event})} {
+		httpErr, ok := err.(*HTTPError)
+		assertOutput.True(ok)
+		assertOutput.Equal(http.StatusTooManyRequests, httpErr.StatusCode)
+		assertOutput.Equal(30*time.Second, httpErr.RetryAfter)
+		assertOutput.True(httpErr.Temporary())
+		assertOutput.NotContains(httpErr.Error(), "sdkKey")
+	}
+
+	status = http.StatusBadRequest
+	httpErr, _ := dispatcher.Dispatch(context.Background(), event).(*HTTPError)
+	assertOutput.False(httpErr.Temporary())
+}
@@ -0,0 +1,310 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mocks
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockRedisServer is an in-process stand-in of Redis supporting the commands used by the SDK on hashes and MULTI/EXEC
+type MockRedisServer struct {
+	password string
+	listener net.Listener
+	mu       sync.Mutex
+	hashes   map[string]map[string]string
+	expiries map[string]time.Time
+	commands []string
+	wg       sync.WaitGroup
+}
+
+// NewMockRedisServer starts a server listening on a random local port
+func NewMockRedisServer() (*MockRedisServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	server := &MockRedisServer{
+		listener: listener,
+		hashes:   make(map[string]map[string]string),
+		expiries: make(map[string]time.Time),
+	}
+	server.wg.Add(1)
+	go server.serve()
+	return server, nil
+}
+
+// Address returns the address the server listens on
+func (server *MockRedisServer) Address() string {
+	return server.listener.Addr().String()
+}
+
+// Close stops the server
+func (server *MockRedisServer) Close() {
+	server.listener.Close()
+	server.wg.Wait()
+}
+
+// SetPassword requires the password from the connections opened afterwards
+func (server *MockRedisServer) SetPassword(password string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.password = password
+}
+
+// Keys returns the sorted keys stored
+func (server *MockRedisServer) Keys() []string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	keys := make([]string, 0, len(server.hashes))
+	for key := range server.hashes {
+		if server.isAlive(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TTL returns the time left before the key expires, 0 if it has no expiry
+func (server *MockRedisServer) TTL(key string) time.Duration {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	expiresAt, ok := server.expiries[key]
+	if !ok {
+		return 0
+	}
+	return time.Until(expiresAt)
+}
+
+// Commands returns the names of the commands received
+func (server *MockRedisServer) Commands() []string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]string(nil), server.commands...)
+}
+
+// serve accepts the connections until the server is closed
+func (server *MockRedisServer) serve() {
+	defer server.wg.Done()
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.handle(conn)
+	}
+}
+
+// handle replies to the commands of a connection
+func (server *MockRedisServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	server.mu.Lock()
+	password := server.password
+	server.mu.Unlock()
+	authenticated := password == ""
+	// the commands queued by MULTI, nil outside of a transaction
+	var queued [][]string
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(args[0])
+		var reply string
+		if command == "AUTH" {
+			if len(args) == 2 && args[1] == password {
+				authenticated = true
+				reply = "+OK\r\n"
+			} else {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		} else if !authenticated {
+			reply = "-NOAUTH Authentication required.\r\n"
+		} else if command == "MULTI" {
+			queued = [][]string{}
+			reply = "+OK\r\n"
+		} else if command == "EXEC" {
+			if queued == nil {
+				reply = "-ERR EXEC without MULTI\r\n"
+			} else {
+				reply = server.executeAll(queued)
+				queued = nil
+			}
+		} else if queued != nil {
+			queued = append(queued, args)
+			reply = "+QUEUED\r\n"
+		} else {
+			reply = server.execute(command, args[1:])
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// execute runs the command and returns its encoded reply
+func (server *MockRedisServer) execute(command string, args []string) string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.run(command, args)
+}
+
+// executeAll runs the commands of a transaction at once and returns the encoded array of their replies
+func (server *MockRedisServer) executeAll(commands [][]string) string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.commands = append(server.commands, "MULTI")
+	reply := "*" + strconv.Itoa(len(commands)) + "\r\n"
+	for _, args := range commands {
+		reply += server.run(strings.ToUpper(args[0]), args[1:])
+	}
+	server.commands = append(server.commands, "EXEC")
+	return reply
+}
+
+// run runs the command and returns its encoded reply, the server must be locked
+func (server *MockRedisServer) run(command string, args []string) string {
+	server.commands = append(server.commands, command)
+
+	switch command {
+	case "PING":
+		return "+PONG\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "HSET":
+		if len(args) < 3 || len(args)%2 != 1 {
+			return "-ERR wrong number of arguments for 'hset' command\r\n"
+		}
+		if !server.isAlive(args[0]) || server.hashes[args[0]] == nil {
+			server.hashes[args[0]] = make(map[string]string)
+		}
+		added := 0
+		for i := 1; i < len(args); i += 2 {
+			if _, ok := server.hashes[args[0]][args[i]]; !ok {
+				added++
+			}
+			server.hashes[args[0]][args[i]] = args[i+1]
+		}
+		return ":" + strconv.Itoa(added) + "\r\n"
+	case "HGETALL":
+		if len(args) != 1 {
+			return "-ERR wrong number of arguments for 'hgetall' command\r\n"
+		}
+		if !server.isAlive(args[0]) {
+			return "*0\r\n"
+		}
+		hash := server.hashes[args[0]]
+		reply := "*" + strconv.Itoa(2*len(hash)) + "\r\n"
+		for field, value := range hash {
+			reply += encodeBulkString(field) + encodeBulkString(value)
+		}
+		return reply
+	case "HMGET":
+		if len(args) < 2 {
+			return "-ERR wrong number of arguments for 'hmget' command\r\n"
+		}
+		reply := "*" + strconv.Itoa(len(args)-1) + "\r\n"
+		for _, field := range args[1:] {
+			value, ok := server.hashes[args[0]][field]
+			if !ok || !server.isAlive(args[0]) {
+				reply += "$-1\r\n"
+				continue
+			}
+			reply += encodeBulkString(value)
+		}
+		return reply
+	case "PEXPIRE":
+		if len(args) != 2 {
+			return "-ERR wrong number of arguments for 'pexpire' command\r\n"
+		}
+		milliseconds, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		if !server.isAlive(args[0]) {
+			return ":0\r\n"
+		}
+		server.expiries[args[0]] = time.Now().Add(time.Duration(milliseconds) * time.Millisecond)
+		return ":1\r\n"
+	case "DEL":
+		deleted := 0
+		for _, key := range args {
+			if server.isAlive(key) {
+				deleted++
+			}
+			delete(server.hashes, key)
+			delete(server.expiries, key)
+		}
+		return ":" + strconv.Itoa(deleted) + "\r\n"
+	}
+	return "-ERR unknown command '" + command + "'\r\n"
+}
+
+// isAlive returns true if the key exists and has not expired, expired keys are deleted
+func (server *MockRedisServer) isAlive(key string) bool {
+	if _, ok := server.hashes[key]; !ok {
+		return false
+	}
+	if expiresAt, ok := server.expiries[key]; ok && !time.Now().Before(expiresAt) {
+		delete(server.hashes, key)
+		delete(server.expiries, key)
+		return false
+	}
+	return true
+}
+
+// readCommand reads a command sent as an array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || count < 1 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	args := make([]string, count)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		value := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		args[i] = string(value[:length])
+	}
+	return args, nil
+}
+
+// encodeBulkString encodes the value as a bulk string reply
+func encodeBulkString(value string) string {
+	return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+}
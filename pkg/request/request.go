@@ -18,11 +18,13 @@ package request
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"github.com/wingify/vwo-go-sdk/pkg/constants"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 type HTTPClient interface {
@@ -34,28 +36,64 @@ var (
 )
 
 func init() {
-	Client = &http.Client{}
+	Client = &http.Client{Timeout: constants.HttpDefaultTimeout * time.Second}
+}
+
+// Response struct holds the status, headers and body of a response
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// GetRequest sends a GET request to the uri with the Client, the request is canceled with the context
+func GetRequest(ctx context.Context, uri string) (Response, error) {
+	req, err := http.NewRequest(constants.HttpGetMethod, uri, nil)
+	if err != nil {
+		return Response{}, err
+	}
+	return send(req.WithContext(ctx))
 }
 
 func PostRequest(uri string, body interface{}, headers map[string]string, queryParams map[string]string) ([]byte, int, error) {
-	u, _ := url.Parse(uri)
+	response, err := SendPostRequest(context.Background(), uri, body, headers, queryParams)
+	return response.Body, response.StatusCode, err
+}
+
+// SendPostRequest sends the body as JSON in a POST request to the uri with the Client, and returns the whole response,
+// the request is canceled with the context
+func SendPostRequest(ctx context.Context, uri string, body interface{}, headers map[string]string, queryParams map[string]string) (Response, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return Response{}, err
+	}
 	q := u.Query()
 	for k, v := range queryParams {
 		q.Set(k, v)
 	}
 	u.RawQuery = q.Encode()
 
-	jsonBody, _ := json.Marshal(body)
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return Response{}, err
+	}
 	req, err := http.NewRequest(constants.HttpPostMethod, u.String(), bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return Response{}, err
+	}
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
+	return send(req.WithContext(ctx))
+}
 
+// send sends the request with the Client and reads the response
+func send(req *http.Request) (Response, error) {
 	response, err := Client.Do(req)
-	defer response.Body.Close()
-	if err == nil {
-		responseBody, err := ioutil.ReadAll(response.Body)
-		return responseBody, response.StatusCode, err
+	if err != nil {
+		return Response{}, err
 	}
-	return nil, response.StatusCode, err
+	defer response.Body.Close()
+	responseBody, err := ioutil.ReadAll(response.Body)
+	return Response{StatusCode: response.StatusCode, Header: response.Header, Body: responseBody}, err
 }
@@ -0,0 +1,27 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+// AssignmentRecorder is notified of the bucketing decisions made while deciding variations, the users getting a stored,
+// whitelisted or forced variation are not bucketed. The bucketing key identifies the users sharing the same decision,
+// the same key may be bucketed again when no user storage keeps its variation
+type AssignmentRecorder interface {
+	// RecordEvaluation is called when the traffic of the campaign is evaluated for a user without stored variation
+	RecordEvaluation(campaign Campaign, bucketingKey string, percentTraffic int, isUserPart bool)
+	// RecordAssignment is called when a user part of the campaign is bucketed into a variation
+	RecordAssignment(campaign Campaign, bucketingKey string, variation Variation)
+}
@@ -16,73 +16,436 @@
 package schema
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"net/url"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/wingify/vwo-go-sdk/pkg/constants"
 	"github.com/wingify/vwo-go-sdk/pkg/logger"
-	"github.com/wingify/vwo-go-sdk/pkg/request"
 )
 
+// BatchEventQueue queues the impressions and sends them in batches of at most EventsPerRequest events, a batch is sent
+// as soon as it is full or RequestTimeInterval seconds after its first impression was queued. A single goroutine owns
+// the queued impressions and another one sends the batches in order, every method asks them and is concurrency safe.
+// While constants.BatchMaxPendingBatches batches wait to be sent the impressions stay queued, up to
+// constants.BatchMaxQueuedEvents impressions, the next ones are dropped so that queueing an impression never waits
+// for a late dispatcher.
+// The exported fields must be set before the queue starts, the Dispatcher is then replaced with SetDispatcher
 type BatchEventQueue struct {
+	// the counters come first to be 64-bit aligned for the atomic operations on 32-bit platforms
+	undelivered int64
+	dropped     int64
+
 	AccountID           int
-	impressions         []Impression
 	Logger              interface{}
-	ch                  chan Impression
-	cancel              chan bool
 	RequestTimeInterval int
 	EventsPerRequest    int
 	SDKKey              string
 	IsDevelopmentMode   bool
 	FlushCallBack       func(error, []map[string]interface{})
+	Dispatcher          EventDispatcher
+	EventLog            EventLog
+
+	startOnce    sync.Once
+	startErr     error
+	closeOnce    sync.Once
+	closing      chan struct{}
+	dispatcherMu sync.RWMutex
+	stopped      bool
+	requests     chan func()
+	jobs         chan *batchJob
+	pendingMu    sync.Mutex
+	pending      []*batchJob
+	handovers    sync.WaitGroup
+	ctx          context.Context
+	cancel       context.CancelFunc
+	impressions  []Impression
+	timer        *time.Timer
+}
+
+// batchJob struct is a batch handed to the sending goroutine, done receives the error of its dispatch if it is set.
+// The EventLog checkpoint of the impressions is truncated once they are all sent. The impressions of a job are
+// guarded by pendingMu until the sending goroutine takes it
+type batchJob struct {
+	impressions []Impression
+	checkpoint  int64
+	sealed      bool
+	done        chan error
+}
+
+// Start function starts the queue once, the impressions of the EventLog not sent before the last shutdown are then
+// queued and sent at once. The dispatcher is used if the Dispatcher is not set, the error of the first start is returned.
+// A queue whose EventLog can not be replayed is not started and drops the impressions as a closed queue
+func (batch *BatchEventQueue) Start(dispatcher EventDispatcher) error {
+	batch.startOnce.Do(func() {
+		if batch.Dispatcher == nil {
+			batch.Dispatcher = dispatcher
+		}
+		if batch.EventsPerRequest < constants.BatchMinEventsPerRequest || batch.EventsPerRequest > constants.BatchMaxEventsPerRequest {
+			batch.EventsPerRequest = constants.BatchDefaultEventsPerRequest
+		}
+		if batch.RequestTimeInterval < constants.BatchMinRequestInterval {
+			batch.RequestTimeInterval = constants.BatchDefaultRequestInterval
+		}
+		batch.requests = make(chan func())
+		batch.closing = make(chan struct{})
+		batch.jobs = make(chan *batchJob, constants.BatchMaxPendingBatches)
+		batch.ctx, batch.cancel = context.WithCancel(context.Background())
+
+		if batch.EventLog != nil {
+			impressions, err := batch.EventLog.Replay()
+			if err != nil {
+				batch.startErr = err
+				batch.closeOnce.Do(func() { close(batch.closing) })
+				batch.cancel()
+				return
+			}
+			if len(impressions) > 0 {
+				batch.log(constants.Info, fmt.Sprintf(constants.InfoMessageEventsReplayed, len(impressions)))
+				atomic.AddInt64(&batch.undelivered, int64(len(impressions)))
+				batch.impressions = impressions
+				batch.cut(nil)
+			}
+		}
+		go batch.run()
+		go batch.send()
+	})
+	return batch.startErr
+}
+
+// SetDispatcher function replaces the dispatcher of the batches not sent yet
+func (batch *BatchEventQueue) SetDispatcher(dispatcher EventDispatcher) {
+	batch.dispatcherMu.Lock()
+	defer batch.dispatcherMu.Unlock()
+	batch.Dispatcher = dispatcher
 }
 
-func (batch *BatchEventQueue) AddToBatch(message Impression, vwoInstance VwoInstance) {
-	if batch.cancel == nil {
-		batch.cancel = make(chan bool)
+// AddToBatch function queues the impression once it is persisted to the EventLog, the queue is started if needed.
+// The impression is dropped if the queue is closed or full
+func (batch *BatchEventQueue) AddToBatch(impression Impression) {
+	ok := batch.do(func() {
+		if len(batch.impressions) >= constants.BatchMaxQueuedEvents {
+			atomic.AddInt64(&batch.dropped, 1)
+			batch.log(constants.Error, fmt.Sprintf(constants.ErrorMessageBatchDropped, len(batch.impressions)))
+			return
+		}
+		if batch.EventLog != nil {
+			if err := batch.EventLog.Append(impression); err != nil {
+				batch.log(constants.Error, fmt.Sprintf(constants.ErrorMessageEventLogFailed, "append", err.Error()))
+			}
+		}
+		batch.impressions = append(batch.impressions, impression)
+		atomic.AddInt64(&batch.undelivered, 1)
+		if len(batch.impressions) >= batch.EventsPerRequest {
+			batch.cut(nil)
+		} else if batch.timer == nil {
+			batch.timer = time.NewTimer(time.Duration(batch.RequestTimeInterval) * time.Second)
+		}
+	})
+	if !ok {
+		batch.log(constants.Error, fmt.Sprintf(constants.ErrorMessageBatchFlushError, constants.ErrorMessageBatchQueueClosed))
 	}
-	if batch.ch == nil {
-		batch.ch = make(chan Impression)
-		interval := time.Duration(batch.RequestTimeInterval) * time.Second
+}
+
+// Flush function sends the queued impressions and waits for their dispatch, an *UndeliveredEventsError
+// reports the events which could not be sent
+func (batch *BatchEventQueue) Flush() error {
+	done := make(chan error, 1)
+	if !batch.do(func() { batch.cut(done) }) {
+		return nil
+	}
+	return <-done
+}
 
+// Dropped function returns the number of impressions dropped because the queue was full
+func (batch *BatchEventQueue) Dropped() int {
+	return int(atomic.LoadInt64(&batch.dropped))
+}
+
+// Close function stops the queue once it has sent the queued impressions, waiting for their dispatch until the context
+// is done, the dispatch of the batches not sent is then canceled. An *UndeliveredEventsError reports the events which
+// could not be sent, or the number of events still queued or being sent when the context was done
+func (batch *BatchEventQueue) Close(ctx context.Context) error {
+	batch.Start(nil)
+	first := false
+	batch.closeOnce.Do(func() {
+		first = true
+		close(batch.closing)
+	})
+	if !first {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	// the goroutines stop once the last batch is handed over, no request is sent anymore once closing is closed
+	stop := func() {
+		batch.cut(done)
+		batch.stopped = true
 		go func() {
-			timer := time.NewTimer(interval)
-			open := true
-			for open {
-				select {
-				case <-timer.C:
-					batch.FlushBatch(vwoInstance)
-					timer.Reset(interval)
-				case data := <-batch.ch:
-					batch.impressions = append(batch.impressions, data)
-					timer.Reset(interval)
-					if len(batch.impressions) >= batch.EventsPerRequest {
-						batch.FlushBatch(vwoInstance)
-					}
-				case <-batch.cancel:
-					open = false
-					batch.FlushBatch(vwoInstance)
-				}
-			}
-			timer.Stop()
+			batch.handovers.Wait()
+			close(batch.jobs)
 		}()
 	}
-	batch.ch <- message
+	select {
+	case batch.requests <- stop:
+	case <-ctx.Done():
+		batch.cancel()
+		go func() { batch.requests <- stop }()
+		return &UndeliveredEventsError{InFlight: int(atomic.LoadInt64(&batch.undelivered)), Err: ctx.Err()}
+	}
+
+	select {
+	case err := <-done:
+		batch.cancel()
+		return err
+	case <-ctx.Done():
+		batch.cancel()
+		return &UndeliveredEventsError{InFlight: int(atomic.LoadInt64(&batch.undelivered)), Err: ctx.Err()}
+	}
+}
+
+// RemoveUserImpressions function removes the impressions of the user which are queued or waiting to be sent, from the
+// EventLog too if it implements DeletableStorage, so that its checkpoints are rewritten without them. The number of
+// impressions removed is returned, the impressions already being sent are not removed
+func (batch *BatchEventQueue) RemoveUserImpressions(userID string) int {
+	removed := 0
+	batch.do(func() {
+		if eventLog, ok := batch.EventLog.(DeletableStorage); ok {
+			if err := eventLog.Delete(context.Background(), userID); err != nil {
+				batch.log(constants.Error, fmt.Sprintf(constants.ErrorMessageEventLogFailed, "delete", err.Error()))
+			}
+		}
+		escapedUserID := url.PathEscape(userID)
+		batch.impressions, removed = removeImpressions(batch.impressions, escapedUserID)
+		if len(batch.impressions) == 0 {
+			batch.stopTimer()
+		}
+
+		batch.pendingMu.Lock()
+		for _, job := range batch.pending {
+			var jobRemoved int
+			job.impressions, jobRemoved = removeImpressions(job.impressions, escapedUserID)
+			removed += jobRemoved
+		}
+		batch.pendingMu.Unlock()
+		atomic.AddInt64(&batch.undelivered, -int64(removed))
+	})
+	return removed
 }
 
-func (batch *BatchEventQueue) Flush() {
-	batch.cancel <- true
-	var vwoInstance VwoInstance
-	batch.FlushBatch(vwoInstance)
-	if batch.ch != nil {
-		close(batch.ch)
-		batch.ch = nil
+// GetBatchImpressions function returns a copy of the queued impressions, nil if there is none
+func (batch *BatchEventQueue) GetBatchImpressions() []Impression {
+	var impressions []Impression
+	batch.do(func() {
+		if len(batch.impressions) > 0 {
+			impressions = append(impressions, batch.impressions...)
+		}
+	})
+	return impressions
+}
+
+// do function runs the request in the goroutine owning the queued impressions and waits for it,
+// false is returned without running it if the queue is closed. The requests never wait for the dispatch
+func (batch *BatchEventQueue) do(request func()) bool {
+	batch.Start(nil)
+	done := make(chan struct{})
+	select {
+	case batch.requests <- func() {
+		request()
+		close(done)
+	}:
+	case <-batch.closing:
+		return false
 	}
+	<-done
+	return true
 }
 
-func (batch *BatchEventQueue) getBatchMinifiedPayload(impressions []Impression) []map[string]interface{} {
+// run function runs the requests and cuts a batch when its interval is over, until the close request is run
+func (batch *BatchEventQueue) run() {
+	for {
+		var timeout <-chan time.Time
+		if batch.timer != nil {
+			timeout = batch.timer.C
+		}
+		select {
+		case request := <-batch.requests:
+			request()
+			if batch.stopped {
+				return
+			}
+		case <-timeout:
+			batch.timer = nil
+			batch.cut(nil)
+		}
+	}
+}
+
+// cut function hands the queued impressions over to the sending goroutine without waiting, done receives the error
+// of their dispatch. When too many batches are waiting the impressions stay queued until the next cut or interval,
+// unless done is set: the batch is then handed over by another goroutine as the caller waits for its dispatch anyway.
+// It must be called by the goroutine owning the impressions
+func (batch *BatchEventQueue) cut(done chan error) {
+	batch.stopTimer()
+	if len(batch.impressions) == 0 && done == nil {
+		return
+	}
+	if done == nil && len(batch.jobs) == cap(batch.jobs) {
+		batch.timer = time.NewTimer(time.Duration(batch.RequestTimeInterval) * time.Second)
+		return
+	}
+	job := &batchJob{impressions: batch.impressions, done: done}
+	batch.impressions = nil
+	if len(job.impressions) > 0 && batch.EventLog != nil {
+		checkpoint, err := batch.EventLog.Seal()
+		if err != nil {
+			batch.log(constants.Error, fmt.Sprintf(constants.ErrorMessageEventLogFailed, "seal", err.Error()))
+		}
+		job.checkpoint, job.sealed = checkpoint, err == nil
+	}
+	batch.pendingMu.Lock()
+	batch.pending = append(batch.pending, job)
+	batch.pendingMu.Unlock()
+
+	select {
+	case batch.jobs <- job:
+		return
+	default:
+	}
+	batch.handovers.Add(1)
+	go func() {
+		defer batch.handovers.Done()
+		select {
+		case batch.jobs <- job:
+		case <-batch.ctx.Done():
+			impressions := batch.takePending(job)
+			atomic.AddInt64(&batch.undelivered, -int64(len(impressions)))
+			job.done <- getUndeliveredEventsError(impressions, batch.ctx.Err())
+		}
+	}()
+}
+
+// stopTimer function stops the interval of the batch being queued, it must be called by the goroutine owning the impressions
+func (batch *BatchEventQueue) stopTimer() {
+	if batch.timer != nil {
+		batch.timer.Stop()
+		batch.timer = nil
+	}
+}
+
+// send function sends the batches handed over in order, in requests of at most EventsPerRequest events. The EventLog
+// checkpoint of a batch is rewritten with the impressions not sent yet as soon as a request succeeds, so that the
+// impressions sent are not replayed. Nothing is sent anymore once the context of the queue is canceled
+func (batch *BatchEventQueue) send() {
+	for job := range batch.jobs {
+		jobImpressions := batch.takePending(job)
+		var failed []Impression
+		var err error
+		for start := 0; start < len(jobImpressions); start += batch.EventsPerRequest {
+			end := start + batch.EventsPerRequest
+			if end > len(jobImpressions) {
+				end = len(jobImpressions)
+			}
+			impressions := jobImpressions[start:end]
+			dispatchErr := batch.ctx.Err()
+			if dispatchErr == nil {
+				dispatchErr = batch.flush(impressions)
+			}
+			atomic.AddInt64(&batch.undelivered, -int64(len(impressions)))
+			if dispatchErr != nil {
+				err = dispatchErr
+				failed = append(failed, impressions...)
+			} else if job.sealed {
+				batch.checkpoint(job.checkpoint, append(append([]Impression(nil), failed...), jobImpressions[end:]...))
+			}
+		}
+
+		if job.sealed && err != nil {
+			batch.log(constants.Info, fmt.Sprintf(constants.InfoMessageEventsKeptInEventLog, len(failed)))
+		}
+		if job.done != nil {
+			if err != nil {
+				job.done <- getUndeliveredEventsError(failed, err)
+			} else {
+				job.done <- nil
+			}
+		}
+	}
+}
+
+// checkpoint function keeps only the impressions not sent yet in the EventLog checkpoint of a batch
+func (batch *BatchEventQueue) checkpoint(checkpoint int64, unsent []Impression) {
+	if len(unsent) == 0 {
+		if err := batch.EventLog.Truncate(checkpoint); err != nil {
+			batch.log(constants.Error, fmt.Sprintf(constants.ErrorMessageEventLogFailed, "truncate", err.Error()))
+		}
+	} else if err := batch.EventLog.Rewrite(checkpoint, unsent); err != nil {
+		batch.log(constants.Error, fmt.Sprintf(constants.ErrorMessageEventLogFailed, "rewrite", err.Error()))
+	}
+}
+
+// takePending function removes the job from the pending ones, whose impressions can no longer be removed, and
+// returns its impressions
+func (batch *BatchEventQueue) takePending(job *batchJob) []Impression {
+	batch.pendingMu.Lock()
+	defer batch.pendingMu.Unlock()
+	for i, pendingJob := range batch.pending {
+		if pendingJob == job {
+			batch.pending = append(batch.pending[:i], batch.pending[i+1:]...)
+			break
+		}
+	}
+	return job.impressions
+}
+
+// flush function sends the impressions with the Dispatcher, at once if it implements BatchEventDispatcher,
+// and calls the FlushCallBack with the error of the dispatch
+func (batch *BatchEventQueue) flush(impressions []Impression) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			batch.log(constants.Error, fmt.Sprintf(constants.ErrorMessageBatchFlushError, recovered))
+			err = fmt.Errorf(constants.ErrorMessageBatchFlushError, recovered)
+		}
+	}()
+	batch.dispatcherMu.RLock()
+	dispatcher := batch.Dispatcher
+	batch.dispatcherMu.RUnlock()
+	if dispatcher == nil {
+		batch.log(constants.Error, fmt.Sprintf(constants.ErrorMessageBatchFlushError, constants.ErrorMessageNoEventDispatcher))
+		return errors.New(constants.ErrorMessageNoEventDispatcher)
+	}
+
+	batch.log(constants.Debug, fmt.Sprintf(constants.DebugBeforeBatchFlush, len(impressions), batch.AccountID))
+	events := make([]Event, len(impressions))
+	for i, impression := range impressions {
+		events[i] = getBatchEvent(impression)
+	}
+	if batchDispatcher, ok := dispatcher.(BatchEventDispatcher); ok {
+		err = batchDispatcher.DispatchBatch(batch.ctx, events)
+	} else {
+		for _, event := range events {
+			if dispatchErr := dispatcher.Dispatch(batch.ctx, event); err == nil {
+				err = dispatchErr
+			}
+		}
+	}
+	if err == nil {
+		batch.log(constants.Debug, fmt.Sprintf(constants.DebugAfterBatchFlush, len(impressions)))
+	}
+
+	if batch.FlushCallBack != nil {
+		batch.FlushCallBack(err, GetBatchMinifiedPayload(impressions, batch.SDKKey))
+	}
+	return err
+}
+
+// GetBatchMinifiedPayload function returns the events of the batch events endpoint for the impressions
+func GetBatchMinifiedPayload(impressions []Impression, sdkKey string) []map[string]interface{} {
 	eventTypeMapping := constants.EventTypeMapping
 	events := make([]map[string]interface{}, 0)
 	for _, impression := range impressions {
@@ -107,65 +470,65 @@ func (batch *BatchEventQueue) getBatchMinifiedPayload(impressions []Impression)
 		if eventName == constants.EventsPush {
 			event["t"] = impression.Tags
 		}
-		event["env"] = batch.SDKKey
+		event["env"] = sdkKey
 		events = append(events, event)
 	}
 	return events
 }
 
-func (batch *BatchEventQueue) FlushBatch(vwoInstance VwoInstance) {
-	defer batch.clear()
-	if batch.IsDevelopmentMode || batch.impressions == nil || len(batch.impressions) == 0 {
-		return
-	}
-	log := batch.Logger.(*logger.Logger)
-	defer func() {
-		if err := recover(); err != nil {
-			log.Error(fmt.Sprintf(constants.ErrorMessageBatchFlushError, err))
+// removeImpressions function returns the impressions which are not of the escaped userID and the number removed
+func removeImpressions(impressions []Impression, escapedUserID string) ([]Impression, int) {
+	var kept []Impression
+	for _, impression := range impressions {
+		if impression.UID != escapedUserID {
+			kept = append(kept, impression)
 		}
-	}()
+	}
+	return kept, len(impressions) - len(kept)
+}
 
-	headers := map[string]string{"Authorization": batch.SDKKey}
-	UpdatedBaseURL := constants.BaseURL
-	if vwoInstance.SettingsFile.CollectionPrefix != "" {
-		UpdatedBaseURL = UpdatedBaseURL + "/" + vwoInstance.SettingsFile.CollectionPrefix
-	}
-	url := constants.HTTPSProtocol + UpdatedBaseURL + constants.BatchEndPoint
-	body := map[string]interface{}{"ev": batch.getBatchMinifiedPayload(batch.impressions)}
-	queryParams := map[string]string{
-		"a":   strconv.Itoa(batch.AccountID),
-		"sd":  constants.SDKName,
-		"sv":  constants.SDKVersion,
-		"env": batch.SDKKey,
-	}
-	for key, element := range GetUsageStatsObject(vwoInstance) {
-		queryParams[key] = element
-	}
-	log.Debug(fmt.Sprintf(constants.DebugBeforeBatchFlush, strconv.Itoa(len(batch.impressions)), strconv.Itoa(batch.AccountID)))
-	_, status, err := request.PostRequest(url, body, headers, queryParams)
-	log.Debug(fmt.Sprintf(constants.DebugAfterBatchFlush, strconv.Itoa(len(batch.impressions))))
-	if status == http.StatusOK {
-		log.Info(fmt.Sprintf(constants.InfoBatchImpressionSuccess, constants.BatchEndPoint))
-	} else {
-		var errString string
-		if status == http.StatusRequestEntityTooLarge {
-			errString = fmt.Sprintf(constants.DebugMessagePayloadTooLarge, constants.BatchEndPoint, batch.EventsPerRequest)
-		} else if status == http.StatusBadRequest {
-			errString = fmt.Sprintf(constants.ErrorMessageBatchImpressionFailed, constants.BatchEndPoint, strconv.Itoa(status))
-		}
-		log.Debug(errString)
-		err = fmt.Errorf(errString)
+// getUndeliveredEventsError function returns the error reporting the impressions which could not be sent
+func getUndeliveredEventsError(impressions []Impression, err error) *UndeliveredEventsError {
+	events := make([]Event, len(impressions))
+	for i, impression := range impressions {
+		events[i] = getBatchEvent(impression)
 	}
+	return &UndeliveredEventsError{Events: events, Err: err}
+}
 
-	if batch.FlushCallBack != nil {
-		batch.FlushCallBack(err, batch.getBatchMinifiedPayload(batch.impressions))
+// getBatchEvent function returns the event of a queued impression, the goal type of a track goal event
+// is told by the revenue which is only sent for revenue goals
+func getBatchEvent(impression Impression) Event {
+	event := Event{Type: impression.EventType, API: "FlushEvents", Impression: impression}
+	event.UserID, _ = url.PathUnescape(impression.UID)
+	if impression.EventType == constants.EventsTrackGoal {
+		event.GoalType = constants.GoalTypeCustom
+		if impression.R != "" {
+			event.GoalType = constants.GoalTypeRevenue
+		}
 	}
+	return event
 }
 
-func (batch *BatchEventQueue) clear() {
-	batch.impressions = nil
+// log function logs the message if the queue has a logger
+func (batch *BatchEventQueue) log(level, message string) {
+	logMessage(batch.Logger, level, message)
 }
 
-func (batch *BatchEventQueue) GetBatchImpressions() []Impression {
-	return batch.impressions
+// logMessage function logs the message with the logger if it is set
+func logMessage(sdkLogger interface{}, level, message string) {
+	log, ok := sdkLogger.(*logger.Logger)
+	if !ok {
+		return
+	}
+	switch level {
+	case constants.Error:
+		log.Error(message)
+	case constants.Warning:
+		log.Warning(message)
+	case constants.Debug:
+		log.Debug(message)
+	default:
+		log.Info(message)
+	}
 }
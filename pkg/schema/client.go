@@ -16,6 +16,8 @@
 
 package schema
 
+import "time"
+
 type VwoInstance struct {
 	SettingsFile             SettingsFile
 	UserStorage              interface{}
@@ -26,7 +28,23 @@ type VwoInstance struct {
 	API                      string
 	GoalTypeToTrack          interface{}
 	ShouldTrackReturningUser interface{}
-	BatchEventQueue          BatchEventQueue
+	BatchEventQueue          *BatchEventQueue
 	IsBatchingEnabled        bool
 	Integrations             Integrations
+	ForcedVariations         *ForcedVariations
+	ForcedVariationsFile     string
+	Overrides                *Overrides
+	OverridesFile            string
+	OverridesPollInterval    int
+	Holdout                  *Holdout
+	Clock                    func() time.Time
+	IsStickyBucketingEnabled bool
+	AssignmentRecorder       AssignmentRecorder
+	SRMCheckInterval         int
+	ConversionStore          ConversionStore
+	EventDispatcher          EventDispatcher
+	ActiveEventDispatcher    *EventDispatcherHolder
+	EventRetryPolicy         *RetryPolicy
+	EventLog                 EventLog
+	EventWorkerPool          *WorkerPool
 }
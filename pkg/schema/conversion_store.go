@@ -0,0 +1,40 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"context"
+	"time"
+)
+
+// Conversion struct is the first conversion of a user on a goal of a campaign
+type Conversion struct {
+	UserID           string
+	CampaignKey      string
+	GoalIdentifier   string
+	FirstConvertedAt time.Time
+}
+
+// ConversionStore interface is implemented by the stores remembering which goals every user already converted on,
+// it decides whether a returning user is tracked again when shouldTrackReturningUser is false
+type ConversionStore interface {
+	// GetConversion returns the first conversion of the user on the goal, ok is false if the user did not convert on it
+	GetConversion(ctx context.Context, userID, campaignKey, goalIdentifier string) (conversion Conversion, ok bool, err error)
+	// AddConversion saves the conversion unless the user already converted on the goal, it returns the first conversion
+	// and isFirst true if it is the given one, implementations must make the check and the save atomic
+	AddConversion(ctx context.Context, conversion Conversion) (first Conversion, isFirst bool, err error)
+}
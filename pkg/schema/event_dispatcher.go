@@ -0,0 +1,105 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+)
+
+// Event struct is an event sent to VWO, Type is constants.EventsTrackUser, constants.EventsTrackGoal or constants.EventsPush,
+// GoalType is only set for the track goal events
+type Event struct {
+	Type       string
+	API        string
+	UserID     string
+	GoalType   string
+	Impression Impression
+}
+
+// EventDispatcherHolder holds the event dispatcher of an instance, it is replaced while the events are dispatched
+// when the settings file is updated
+type EventDispatcherHolder struct {
+	value atomic.Value
+}
+
+// Load function returns the dispatcher held, nil if there is none
+func (holder *EventDispatcherHolder) Load() EventDispatcher {
+	value, _ := holder.value.Load().(dispatcherValue)
+	return value.dispatcher
+}
+
+// Store function replaces the dispatcher held
+func (holder *EventDispatcherHolder) Store(dispatcher EventDispatcher) {
+	holder.value.Store(dispatcherValue{dispatcher})
+}
+
+// EventDispatcher interface is implemented by the dispatchers sending the events, event.HTTPDispatcher sending them
+// to the VWO endpoints is used by default
+type EventDispatcher interface {
+	Dispatch(ctx context.Context, event Event) error
+}
+
+// BatchEventDispatcher interface is optionally implemented by an EventDispatcher able to send several events at once,
+// the events of the batch event queue are otherwise dispatched one by one
+type BatchEventDispatcher interface {
+	DispatchBatch(ctx context.Context, events []Event) error
+}
+
+// RetryPolicy struct configures the retries of the events failing with a transient error, the delay before the nth retry
+// is a random duration between half and all of InitialBackoff*2^(n-1) capped to MaxBackoff, or the delay asked by
+// the Retry-After header of the response capped to MaxBackoff too. DeadLetterCallBack receives the events which could not be sent
+type RetryPolicy struct {
+	MaxRetries         int
+	InitialBackoff     time.Duration
+	MaxBackoff         time.Duration
+	DeadLetterCallBack func(events []Event, err error)
+}
+
+// SetDefaults sets the default value of every field which is not set
+func (policy *RetryPolicy) SetDefaults() {
+	if policy.MaxRetries < 1 {
+		policy.MaxRetries = constants.EventDefaultMaxRetries
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = constants.EventDefaultInitialBackoff * time.Second
+	}
+	if policy.MaxBackoff < policy.InitialBackoff {
+		policy.MaxBackoff = constants.EventDefaultMaxBackoff * time.Second
+		if policy.MaxBackoff < policy.InitialBackoff {
+			policy.MaxBackoff = policy.InitialBackoff
+		}
+	}
+}
+
+// UndeliveredEventsError is returned by Close when some events could not be delivered, Events are the events
+// which failed or were never sent, InFlight the number of events still queued or being sent when the context was done,
+// and Err the error of the context or of the last failed dispatch
+type UndeliveredEventsError struct {
+	Events   []Event
+	InFlight int
+	Err      error
+}
+
+// Error function returns the message of the error
+func (err *UndeliveredEventsError) Error() string {
+	return fmt.Sprintf(constants.ErrorMessageEventsUndelivered, len(err.Events), err.InFlight, err.Err)
+}
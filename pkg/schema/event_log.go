@@ -0,0 +1,32 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+// EventLog interface is implemented by the write-ahead logs persisting the impressions of the batch event queue
+// until they are sent, so that the impressions queued when the process stops are not lost
+type EventLog interface {
+	// Append persists the impression before it is queued
+	Append(impression Impression) error
+	// Replay returns the impressions persisted and not truncated yet, it is called once when the queue starts
+	Replay() ([]Impression, error)
+	// Seal returns the checkpoint of the impressions appended or replayed since the previous Seal
+	Seal() (int64, error)
+	// Truncate removes the impressions of the checkpoint once they are sent
+	Truncate(checkpoint int64) error
+	// Rewrite replaces the impressions of the checkpoint with the given ones, not sent yet, once a part of them is sent
+	Rewrite(checkpoint int64, impressions []Impression) error
+}
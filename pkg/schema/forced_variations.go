@@ -0,0 +1,62 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import "sync"
+
+// ForcedVariations holds variation names forced at runtime, keyed by campaign key and user ID
+type ForcedVariations struct {
+	mu         sync.RWMutex
+	variations map[string]map[string]string
+}
+
+// Get returns the variation name forced for the user in the campaign, if any
+func (forcedVariations *ForcedVariations) Get(userID, campaignKey string) (string, bool) {
+	if forcedVariations == nil {
+		return "", false
+	}
+	forcedVariations.mu.RLock()
+	defer forcedVariations.mu.RUnlock()
+	variationName, ok := forcedVariations.variations[campaignKey][userID]
+	return variationName, ok
+}
+
+// Set forces the variation name for the user in the campaign
+func (forcedVariations *ForcedVariations) Set(userID, campaignKey, variationName string) {
+	forcedVariations.mu.Lock()
+	defer forcedVariations.mu.Unlock()
+	if forcedVariations.variations == nil {
+		forcedVariations.variations = make(map[string]map[string]string)
+	}
+	if forcedVariations.variations[campaignKey] == nil {
+		forcedVariations.variations[campaignKey] = make(map[string]string)
+	}
+	forcedVariations.variations[campaignKey][userID] = variationName
+}
+
+// Clear removes the forced variation of the user in the campaign
+func (forcedVariations *ForcedVariations) Clear(userID, campaignKey string) {
+	if forcedVariations == nil {
+		return
+	}
+	forcedVariations.mu.Lock()
+	defer forcedVariations.mu.Unlock()
+	delete(forcedVariations.variations[campaignKey], userID)
+	if len(forcedVariations.variations[campaignKey]) == 0 {
+		delete(forcedVariations.variations, campaignKey)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import "sync"
+
+// CampaignOverride struct holds the local overrides applied on top of a campaign from the settings file
+type CampaignOverride struct {
+	ForcedVariation string                 `json:"forcedVariation" yaml:"forcedVariation"`
+	Status          string                 `json:"status" yaml:"status"`
+	PercentTraffic  *int                   `json:"percentTraffic" yaml:"percentTraffic"`
+	Variables       map[string]interface{} `json:"variables" yaml:"variables"`
+}
+
+// Overrides holds the campaign overrides loaded from a local overrides file, keyed by campaign key
+type Overrides struct {
+	mu        sync.RWMutex
+	campaigns map[string]CampaignOverride
+	done      chan struct{}
+	stopOnce  sync.Once
+}
+
+// Set replaces all the campaign overrides
+func (overrides *Overrides) Set(campaigns map[string]CampaignOverride) {
+	overrides.mu.Lock()
+	defer overrides.mu.Unlock()
+	overrides.campaigns = campaigns
+}
+
+// Get returns the override of the campaign, if any
+func (overrides *Overrides) Get(campaignKey string) (CampaignOverride, bool) {
+	if overrides == nil {
+		return CampaignOverride{}, false
+	}
+	overrides.mu.RLock()
+	defer overrides.mu.RUnlock()
+	override, ok := overrides.campaigns[campaignKey]
+	return override, ok
+}
+
+// GetForcedVariation returns the variation name forced for every user of the campaign, if any
+func (overrides *Overrides) GetForcedVariation(campaignKey string) string {
+	override, _ := overrides.Get(campaignKey)
+	return override.ForcedVariation
+}
+
+// ApplyToCampaign returns a copy of the campaign with its status, traffic and variable overrides applied
+func (overrides *Overrides) ApplyToCampaign(campaign Campaign) Campaign {
+	override, ok := overrides.Get(campaign.Key)
+	if !ok {
+		return campaign
+	}
+
+	if override.Status != "" {
+		campaign.Status = override.Status
+	}
+	if override.PercentTraffic != nil && *override.PercentTraffic < campaign.PercentTraffic {
+		campaign.PercentTraffic = *override.PercentTraffic
+		if campaign.PercentTraffic < 0 {
+			campaign.PercentTraffic = 0
+		}
+	}
+	if override.PercentTraffic != nil && len(campaign.Ramp) > 0 {
+		ramp := make([]RampStep, len(campaign.Ramp))
+		for i, step := range campaign.Ramp {
+			if step.PercentTraffic > *override.PercentTraffic {
+				step.PercentTraffic = *override.PercentTraffic
+			}
+			ramp[i] = step
+		}
+		campaign.Ramp = ramp
+	}
+	if len(override.Variables) > 0 {
+		campaign.Variables = overrideVariables(campaign.Variables, override.Variables)
+		variations := make([]Variation, len(campaign.Variations))
+		for i, variation := range campaign.Variations {
+			variation.Variables = overrideVariables(variation.Variables, override.Variables)
+			variations[i] = variation
+		}
+		campaign.Variations = variations
+	}
+	return campaign
+}
+
+// Done returns a channel which is closed once the overrides are stopped
+func (overrides *Overrides) Done() <-chan struct{} {
+	overrides.mu.Lock()
+	defer overrides.mu.Unlock()
+	if overrides.done == nil {
+		overrides.done = make(chan struct{})
+	}
+	return overrides.done
+}
+
+// Stop signals the watcher of the overrides file to stop reloading it
+func (overrides *Overrides) Stop() {
+	if overrides == nil {
+		return
+	}
+	overrides.Done()
+	overrides.stopOnce.Do(func() {
+		close(overrides.done)
+	})
+}
+
+// overrideVariables returns a copy of the variables with the overridden values replaced
+func overrideVariables(variables []Variable, values map[string]interface{}) []Variable {
+	if variables == nil {
+		return nil
+	}
+	overridden := make([]Variable, len(variables))
+	for i, variable := range variables {
+		if value, ok := values[variable.Key]; ok {
+			variable.Value = value
+		}
+		overridden[i] = variable
+	}
+	return overridden
+}
@@ -16,12 +16,23 @@
 
 package schema
 
+import (
+	"context"
+	"time"
+)
+
 // SettingsFile struct
 type SettingsFile struct {
 	SDKKey           string     `json:"sdkKey"`
 	Campaigns        []Campaign `json:"campaigns"`
 	AccountID        int        `json:"accountId"`
 	CollectionPrefix  string     `json:"collectionPrefix"`
+	Holdout          *Holdout   `json:"holdout"`
+}
+
+// Holdout struct
+type Holdout struct {
+	PercentTraffic int `json:"percentTraffic"`
 }
 
 // Campaign struct
@@ -38,6 +49,16 @@ type Campaign struct {
 	Type                   string                 `json:"type"`
 	IsBucketingSeedEnabled bool                   `json:"isBucketingSeedEnabled"`
   IsUserListEnabled      bool                   `json:"isUserListEnabled"`
+	StartTime              *time.Time             `json:"startTime"`
+	EndTime                *time.Time             `json:"endTime"`
+	Ramp                   []RampStep             `json:"ramp"`
+	IsAlwaysCheckSegment   bool                   `json:"isAlwaysCheckSegment"`
+}
+
+// RampStep struct
+type RampStep struct {
+	Time           time.Time `json:"time"`
+	PercentTraffic int       `json:"percentTraffic"`
 }
 
 // Goal struct
@@ -77,6 +98,10 @@ type Options struct {
 	RevenueValue                interface{}
 	GoalTypeToTrack             interface{}
 	ShouldTrackReturningUser    interface{}
+	BucketingKey                string
+	Context                     context.Context
+	AlwaysCheckSegment          bool
+	NoTrackingConsent           bool
 }
 
 // UserData  struct
@@ -85,6 +110,10 @@ type UserData struct {
 	CampaignKey    string
 	VariationName  string
 	GoalIdentifier string
+	CampaignID     int
+	VariationID    int
+	AssignedAt     time.Time
+	SDKVersion     string
 }
 
 // VariationAllocationRange struct
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"context"
+)
+
+// UserStorage interface is implemented by the storages keeping the variation assigned to every user,
+// an error is returned when the storage fails, a user without stored data gets an empty UserData and a nil error
+type UserStorage interface {
+	Get(ctx context.Context, userID, campaignKey string) (UserData, error)
+	Set(ctx context.Context, userData UserData) error
+}
+
+// BatchUserStorage interface is optionally implemented by a UserStorage able to get and set the data of a user
+// for several campaigns in a single round trip, GetMany omits the campaigns without stored data
+type BatchUserStorage interface {
+	GetMany(ctx context.Context, userID string, campaignKeys []string) (map[string]UserData, error)
+	SetMany(ctx context.Context, userDatas []UserData) error
+}
+
+// DeletableStorage interface is optionally implemented by a UserStorage or a ConversionStore able to delete
+// all the data stored for a user, it is called by ForgetUser to honor data deletion requests
+type DeletableStorage interface {
+	Delete(ctx context.Context, userID string) error
+}
+
+// LegacyUserStorage interface
+//
+// Deprecated: implement UserStorage instead, LegacyUserStorage can not report storage errors
+type LegacyUserStorage interface {
+	Get(userID, campaignKey string) UserData
+	Set(userID, campaignKey, variationName, goalIdentifier string)
+}
+
+// legacyUserStorage struct adapts a LegacyUserStorage to UserStorage
+type legacyUserStorage struct {
+	storage LegacyUserStorage
+}
+
+// NewLegacyUserStorage returns a UserStorage calling the methods of the deprecated storage
+func NewLegacyUserStorage(storage LegacyUserStorage) UserStorage {
+	return legacyUserStorage{storage: storage}
+}
+
+// Get function fetches the stored data, the context is ignored
+func (adapter legacyUserStorage) Get(ctx context.Context, userID, campaignKey string) (UserData, error) {
+	return adapter.storage.Get(userID, campaignKey), nil
+}
+
+// Set function saves the data, the context is ignored
+func (adapter legacyUserStorage) Set(ctx context.Context, userData UserData) error {
+	adapter.storage.Set(userData.UserID, userData.CampaignKey, userData.VariationName, userData.GoalIdentifier)
+	return nil
+}
+
+// ToUserStorage function returns the storage as a UserStorage, adapting the deprecated LegacyUserStorage,
+// ok is false if the storage implements neither of them
+func ToUserStorage(storage interface{}) (userStorage UserStorage, ok bool) {
+	switch typedStorage := storage.(type) {
+	case UserStorage:
+		return typedStorage, true
+	case LegacyUserStorage:
+		return NewLegacyUserStorage(typedStorage), true
+	}
+	return nil, false
+}
+
+// IsLegacyUserStorage function returns true if the storage only implements the deprecated LegacyUserStorage,
+// or is adapted from it, such a storage only keeps the UserID, CampaignKey, VariationName and GoalIdentifier
+func IsLegacyUserStorage(storage interface{}) bool {
+	if _, ok := storage.(legacyUserStorage); ok {
+		return true
+	}
+	if _, ok := storage.(UserStorage); ok {
+		return false
+	}
+	_, ok := storage.(LegacyUserStorage)
+	return ok
+}
@@ -0,0 +1,265 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+)
+
+// WorkerPool dispatches the events of the non batched calls with a fixed number of Workers taking them from a queue
+// of QueueSize events. When the queue is full the OverflowPolicy, constants.WorkerPoolOverflowDropNewest by default,
+// constants.WorkerPoolOverflowDropOldest or constants.WorkerPoolOverflowBlock, tells which event is dropped or
+// whether Submit waits for room in the queue
+type WorkerPool struct {
+	// the counters come first to be 64-bit aligned for the atomic operations on 32-bit platforms
+	dispatched int64
+	failed     int64
+	dropped    int64
+	inFlight   int64
+
+	Workers        int
+	QueueSize      int
+	OverflowPolicy string
+	Logger         interface{}
+
+	// dispatcher holds a dispatcherValue, it is read by the workers without any lock
+	dispatcher atomic.Value
+	queue      chan Event
+	done       chan struct{}
+	ctx        context.Context
+	cancel     context.CancelFunc
+	// mu guards the queue and closed, it is never held across a channel operation
+	mu        sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+	submits   sync.WaitGroup
+	wg        sync.WaitGroup
+
+	undeliveredMu  sync.Mutex
+	undelivered    []Event
+	undeliveredErr error
+}
+
+// dispatcherValue struct wraps the dispatcher of a WorkerPool, atomic.Value needs a single concrete type
+type dispatcherValue struct {
+	dispatcher EventDispatcher
+}
+
+// WorkerPoolStats struct counts the events of a WorkerPool, Queued is the number of events waiting for a worker
+type WorkerPoolStats struct {
+	Dispatched int64
+	Failed     int64
+	Dropped    int64
+	Queued     int
+}
+
+// SetDefaults sets the default value of every field which is not set
+func (pool *WorkerPool) SetDefaults() {
+	if pool.Workers < 1 {
+		pool.Workers = constants.WorkerPoolDefaultWorkers
+	}
+	if pool.QueueSize < 1 {
+		pool.QueueSize = constants.WorkerPoolDefaultQueueSize
+	}
+	if pool.OverflowPolicy == "" {
+		pool.OverflowPolicy = constants.WorkerPoolOverflowDropNewest
+	}
+}
+
+// Start function starts the workers dispatching the events with the dispatcher, an error is returned
+// for an unknown OverflowPolicy
+func (pool *WorkerPool) Start(dispatcher EventDispatcher) error {
+	pool.SetDefaults()
+	if pool.OverflowPolicy != constants.WorkerPoolOverflowBlock && pool.OverflowPolicy != constants.WorkerPoolOverflowDropOldest && pool.OverflowPolicy != constants.WorkerPoolOverflowDropNewest {
+		return fmt.Errorf(constants.ErrorMessageInvalidOverflowPolicy, pool.OverflowPolicy)
+	}
+
+	pool.SetDispatcher(dispatcher)
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if pool.queue != nil {
+		return nil
+	}
+	pool.queue = make(chan Event, pool.QueueSize)
+	pool.done = make(chan struct{})
+	pool.ctx, pool.cancel = context.WithCancel(context.Background())
+	for i := 0; i < pool.Workers; i++ {
+		pool.wg.Add(1)
+		go pool.work()
+	}
+	return nil
+}
+
+// SetDispatcher function replaces the dispatcher of the events not dispatched yet
+func (pool *WorkerPool) SetDispatcher(dispatcher EventDispatcher) {
+	pool.dispatcher.Store(dispatcherValue{dispatcher})
+}
+
+// Submit function queues the event, false is returned if it is dropped because the queue is full or the pool is closed
+func (pool *WorkerPool) Submit(event Event) bool {
+	pool.mu.RLock()
+	if pool.queue == nil || pool.closed {
+		pool.mu.RUnlock()
+		atomic.AddInt64(&pool.dropped, 1)
+		return false
+	}
+	// Close waits for the pending submits before closing the queue, the lock is not held while sending
+	pool.submits.Add(1)
+	pool.mu.RUnlock()
+	defer pool.submits.Done()
+
+	switch pool.OverflowPolicy {
+	case constants.WorkerPoolOverflowDropNewest:
+		select {
+		case pool.queue <- event:
+			return true
+		default:
+			atomic.AddInt64(&pool.dropped, 1)
+			return false
+		}
+	case constants.WorkerPoolOverflowDropOldest:
+		for {
+			select {
+			case pool.queue <- event:
+				return true
+			default:
+			}
+			select {
+			case <-pool.queue:
+				atomic.AddInt64(&pool.dropped, 1)
+			default:
+			}
+		}
+	default:
+		select {
+		case pool.queue <- event:
+			return true
+		case <-pool.done:
+			atomic.AddInt64(&pool.dropped, 1)
+			return false
+		}
+	}
+}
+
+// Stats function returns the counters of the events
+func (pool *WorkerPool) Stats() WorkerPoolStats {
+	return WorkerPoolStats{
+		Dispatched: atomic.LoadInt64(&pool.dispatched),
+		Failed:     atomic.LoadInt64(&pool.failed),
+		Dropped:    atomic.LoadInt64(&pool.dropped),
+		Queued:     len(pool.queue),
+	}
+}
+
+// Close function stops accepting events and waits for the workers to dispatch the queued ones until the context is done,
+// the dispatch of the events in flight is then canceled. An *UndeliveredEventsError reports the events which failed
+// while closing or were still queued, and the number of events still in flight
+func (pool *WorkerPool) Close(ctx context.Context) error {
+	pool.mu.RLock()
+	started := pool.queue != nil
+	pool.mu.RUnlock()
+	if !started {
+		return nil
+	}
+	pool.mu.Lock()
+	first := !pool.closed
+	pool.closed = true
+	pool.mu.Unlock()
+	// unblocks the calls waiting for room in the queue, the queue is closed once none is sending anymore
+	pool.closeOnce.Do(func() { close(pool.done) })
+	if first {
+		pool.submits.Wait()
+		close(pool.queue)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		pool.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		pool.cancel()
+		return pool.getUndeliveredError(nil)
+	case <-ctx.Done():
+		pool.cancel()
+		// the workers still running are racing for the events left
+		for event := range pool.queue {
+			pool.addUndelivered(event, nil)
+		}
+		return pool.getUndeliveredError(ctx.Err())
+	}
+}
+
+// addUndelivered function records an event which could not be delivered while closing, and the error of its dispatch
+func (pool *WorkerPool) addUndelivered(event Event, err error) {
+	pool.undeliveredMu.Lock()
+	defer pool.undeliveredMu.Unlock()
+	pool.undelivered = append(pool.undelivered, event)
+	if err != nil {
+		pool.undeliveredErr = err
+	}
+}
+
+// getUndeliveredError function returns the error reporting the events not delivered while closing, nil if there is none
+func (pool *WorkerPool) getUndeliveredError(err error) error {
+	pool.undeliveredMu.Lock()
+	defer pool.undeliveredMu.Unlock()
+	inFlight := int(atomic.LoadInt64(&pool.inFlight))
+	if err == nil && len(pool.undelivered) == 0 {
+		return nil
+	}
+	if err == nil {
+		err = pool.undeliveredErr
+	}
+	return &UndeliveredEventsError{
+		Events:   append([]Event(nil), pool.undelivered...),
+		InFlight: inFlight,
+		Err:      err,
+	}
+}
+
+// work function dispatches the queued events until the queue is closed and drained
+func (pool *WorkerPool) work() {
+	defer pool.wg.Done()
+	for event := range pool.queue {
+		dispatcher := pool.dispatcher.Load().(dispatcherValue).dispatcher
+		closed := false
+		select {
+		case <-pool.done:
+			closed = true
+		default:
+		}
+		atomic.AddInt64(&pool.inFlight, 1)
+		err := dispatcher.Dispatch(pool.ctx, event)
+		atomic.AddInt64(&pool.inFlight, -1)
+		if err != nil {
+			atomic.AddInt64(&pool.failed, 1)
+			logMessage(pool.Logger, constants.Error, fmt.Sprintf(constants.ErrorMessageImpressionFailed, event.API, err))
+			if closed {
+				pool.addUndelivered(event, err)
+			}
+			continue
+		}
+		atomic.AddInt64(&pool.dispatched, 1)
+	}
+}
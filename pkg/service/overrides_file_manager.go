@@ -0,0 +1,171 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+	"gopkg.in/yaml.v2"
+)
+
+const overridesFileManager = "overrides_file_manager.go"
+
+// OverridesFileManager loads campaign overrides from a local JSON or YAML file and reloads them whenever the file changes
+type OverridesFileManager struct {
+	Path      string
+	Overrides *schema.Overrides
+	Logger    interface{}
+
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// overridesFile struct is the content of the overrides file
+type overridesFile struct {
+	Campaigns map[string]schema.CampaignOverride `json:"campaigns"`
+}
+
+// Load function reads the overrides file and replaces the current overrides with its content
+func (ofm *OverridesFileManager) Load() error {
+	/*
+		Returns:
+			error: nil if the overrides are loaded, a missing file clears the overrides
+	*/
+
+	info, err := os.Stat(ofm.Path)
+	if os.IsNotExist(err) {
+		ofm.exists = false
+		ofm.Overrides.Set(nil)
+		message := fmt.Sprintf(constants.InfoMessageOverridesFileMissing, ofm.Path)
+		utils.LogMessage(ofm.Logger, constants.Info, overridesFileManager, message)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf(constants.ErrorMessageOverridesFileInvalid, ofm.Path, err.Error())
+	}
+
+	data, err := ioutil.ReadFile(ofm.Path)
+	if err != nil {
+		return fmt.Errorf(constants.ErrorMessageOverridesFileInvalid, ofm.Path, err.Error())
+	}
+	campaigns, err := parseOverrides(ofm.Path, data)
+	if err != nil {
+		return fmt.Errorf(constants.ErrorMessageOverridesFileInvalid, ofm.Path, err.Error())
+	}
+
+	ofm.exists = true
+	ofm.modTime = info.ModTime()
+	ofm.size = info.Size()
+	ofm.Overrides.Set(campaigns)
+
+	message := fmt.Sprintf(constants.InfoMessageOverridesFileLoaded, ofm.Path, len(campaigns))
+	utils.LogMessage(ofm.Logger, constants.Info, overridesFileManager, message)
+	return nil
+}
+
+// Reload function loads the overrides file again if it has changed since it was last loaded
+func (ofm *OverridesFileManager) Reload() (bool, error) {
+	/*
+		Returns:
+			bool: true if the file had changed
+			error: if the changed file could not be loaded, the previous overrides are kept
+	*/
+
+	info, err := os.Stat(ofm.Path)
+	if os.IsNotExist(err) {
+		if !ofm.exists {
+			return false, nil
+		}
+		return true, ofm.Load()
+	}
+	if err != nil {
+		return false, fmt.Errorf(constants.ErrorMessageOverridesFileInvalid, ofm.Path, err.Error())
+	}
+	if ofm.exists && info.ModTime().Equal(ofm.modTime) && info.Size() == ofm.size {
+		return false, nil
+	}
+	return true, ofm.Load()
+}
+
+// Watch function polls the overrides file at the given interval and reloads it on change until the overrides are stopped
+func (ofm *OverridesFileManager) Watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	done := ofm.Overrides.Done()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if _, err := ofm.Reload(); err != nil {
+				utils.LogMessage(ofm.Logger, constants.Error, overridesFileManager, err.Error())
+			}
+		}
+	}
+}
+
+// parseOverrides function parses the campaign overrides from JSON, or YAML if the file has a .yaml or .yml extension
+func parseOverrides(path string, data []byte) (map[string]schema.CampaignOverride, error) {
+	extension := strings.ToLower(filepath.Ext(path))
+	if extension == ".yaml" || extension == ".yml" {
+		var content interface{}
+		if err := yaml.Unmarshal(data, &content); err != nil {
+			return nil, err
+		}
+		// converting to JSON keeps value types the same as the ones in the settings file
+		converted, err := json.Marshal(yamlToJSON(content))
+		if err != nil {
+			return nil, err
+		}
+		data = converted
+	}
+
+	var file overridesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return file.Campaigns, nil
+}
+
+// yamlToJSON function converts the maps decoded from YAML to maps with string keys
+func yamlToJSON(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			converted[fmt.Sprint(key)] = yamlToJSON(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, item := range value {
+			converted[i] = yamlToJSON(item)
+		}
+		return converted
+	}
+	return value
+}
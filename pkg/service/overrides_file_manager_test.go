@@ -0,0 +1,105 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/logger"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+)
+
+func TestOverridesFileManager(t *testing.T) {
+	assertOutput := assert.New(t)
+	logs := logger.Init(constants.SDKName, true, false, ioutil.Discard)
+	defer logger.Close()
+
+	dir, _ := ioutil.TempDir("", "overrides")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "overrides.json")
+
+	overridesFileManager := OverridesFileManager{Path: path, Overrides: &schema.Overrides{}, Logger: logs}
+	assertOutput.Nil(overridesFileManager.Load(), "Missing file should not be an error")
+	_, ok := overridesFileManager.Overrides.Get("CAMPAIGN")
+	assertOutput.False(ok)
+
+	ioutil.WriteFile(path, []byte(`{"campaigns": {"CAMPAIGN": {"status": "PAUSED"}}}`), 0644)
+	changed, err := overridesFileManager.Reload()
+	assertOutput.True(changed)
+	assertOutput.Nil(err)
+	override, _ := overridesFileManager.Overrides.Get("CAMPAIGN")
+	assertOutput.Equal("PAUSED", override.Status)
+
+	changed, err = overridesFileManager.Reload()
+	assertOutput.False(changed, "Unchanged file should not be reloaded")
+	assertOutput.Nil(err)
+
+	ioutil.WriteFile(path, []byte(`{"campaigns": {"CAMPAIGN": {"forcedVariation": "Control"}}}`), 0644)
+	changed, err = overridesFileManager.Reload()
+	assertOutput.True(changed)
+	assertOutput.Nil(err)
+	assertOutput.Equal("Control", overridesFileManager.Overrides.GetForcedVariation("CAMPAIGN"))
+
+	ioutil.WriteFile(path, []byte(`{"campaigns": {"CAMPAIGN": `), 0644)
+	changed, err = overridesFileManager.Reload()
+	assertOutput.True(changed)
+	assertOutput.NotNil(err)
+	assertOutput.Equal("Control", overridesFileManager.Overrides.GetForcedVariation("CAMPAIGN"), "Previous overrides should be kept")
+
+	os.Remove(path)
+	changed, err = overridesFileManager.Reload()
+	assertOutput.True(changed)
+	assertOutput.Nil(err)
+	assertOutput.Empty(overridesFileManager.Overrides.GetForcedVariation("CAMPAIGN"), "Overrides should be cleared")
+}
+
+func TestOverridesFileManagerYAML(t *testing.T) {
+	assertOutput := assert.New(t)
+	logs := logger.Init(constants.SDKName, true, false, ioutil.Discard)
+	defer logger.Close()
+
+	dir, _ := ioutil.TempDir("", "overrides")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "overrides.yaml")
+	ioutil.WriteFile(path, []byte("campaigns:\n  CAMPAIGN:\n    percentTraffic: 10\n    variables:\n      INTEGER_VARIABLE: 5\n      JSON_VARIABLE:\n        enabled: true\n"), 0644)
+
+	overridesFileManager := OverridesFileManager{Path: path, Overrides: &schema.Overrides{}, Logger: logs}
+	assertOutput.Nil(overridesFileManager.Load())
+
+	campaign := schema.Campaign{
+		Key:            "CAMPAIGN",
+		PercentTraffic: 100,
+		Variables:      []schema.Variable{{Key: "INTEGER_VARIABLE", Value: float64(1)}, {Key: "STRING_VARIABLE", Value: "value"}},
+		Variations:     []schema.Variation{{Name: "Control", Variables: []schema.Variable{{Key: "JSON_VARIABLE"}}}},
+		Ramp:           []schema.RampStep{{PercentTraffic: 5}, {PercentTraffic: 50}},
+	}
+	overridden := overridesFileManager.Overrides.ApplyToCampaign(campaign)
+	assertOutput.Equal(10, overridden.PercentTraffic)
+	assertOutput.Equal([]schema.RampStep{{PercentTraffic: 5}, {PercentTraffic: 10}}, overridden.Ramp)
+	assertOutput.Equal(float64(5), overridden.Variables[0].Value)
+	assertOutput.Equal("value", overridden.Variables[1].Value)
+	assertOutput.Equal(map[string]interface{}{"enabled": true}, overridden.Variations[0].Variables[0].Value)
+	assertOutput.Equal(float64(1), campaign.Variables[0].Value, "Settings file campaign should not be modified")
+
+	ioutil.WriteFile(path, []byte("campaigns: [invalid"), 0644)
+	assertOutput.NotNil(overridesFileManager.Load())
+}
@@ -0,0 +1,410 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+)
+
+// FileEventLogConfig struct configures a FileEventLog
+type FileEventLogConfig struct {
+	// MaxBytes caps the size of all the segments, the impressions appended once it is reached are not persisted
+	MaxBytes int64
+	// MaxSegmentBytes is the size from which the impressions are appended to a new segment
+	MaxSegmentBytes int64
+	// NoSync skips the fsync of every append, the impressions appended last may then be lost by a crash of the host
+	NoSync bool
+}
+
+// SetDefaults sets the default value of every field which is not set
+func (config *FileEventLogConfig) SetDefaults() {
+	if config.MaxBytes < 1 {
+		config.MaxBytes = constants.EventLogDefaultMaxBytes
+	}
+	if config.MaxSegmentBytes < 1 {
+		config.MaxSegmentBytes = constants.EventLogDefaultMaxSegmentBytes
+	}
+	if config.MaxSegmentBytes > config.MaxBytes {
+		config.MaxSegmentBytes = config.MaxBytes
+	}
+}
+
+// FileEventLog is a concurrency safe schema.EventLog appending the impressions to segment files of a directory,
+// one JSON record per line. Every Seal starts a new segment, the segments of a checkpoint are removed by Truncate
+// or merged by Rewrite, and the segments left by a previous process are replayed
+type FileEventLog struct {
+	Dir    string
+	Config FileEventLogConfig
+
+	mu          sync.Mutex
+	file        *os.File
+	segment     int64
+	nextSegment int64
+	size        int64
+	sizes       map[int64]int64
+	pending     []int64
+	checkpoints map[int64][]int64
+	checkpoint  int64
+	closed      bool
+}
+
+// NewFileEventLog opens the event log of the directory, creating it if needed. The log must be closed with Close
+func NewFileEventLog(dir string, config FileEventLogConfig) (*FileEventLog, error) {
+	config.SetDefaults()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	eventLog := &FileEventLog{
+		Dir:         dir,
+		Config:      config,
+		sizes:       make(map[int64]int64),
+		checkpoints: make(map[int64][]int64),
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, constants.EventLogSegmentExtension) {
+			continue
+		}
+		segment, err := strconv.ParseInt(strings.TrimSuffix(name, constants.EventLogSegmentExtension), 10, 64)
+		if err != nil {
+			continue
+		}
+		eventLog.sizes[segment] = file.Size()
+		eventLog.size += file.Size()
+		eventLog.pending = append(eventLog.pending, segment)
+		if segment >= eventLog.nextSegment {
+			eventLog.nextSegment = segment + 1
+		}
+	}
+	sort.Slice(eventLog.pending, func(i, j int) bool { return eventLog.pending[i] < eventLog.pending[j] })
+	return eventLog, nil
+}
+
+// Append function writes the impression to the current segment, starting a new one if it is full,
+// an error is returned without writing anything if the log would exceed its MaxBytes
+func (eventLog *FileEventLog) Append(impression schema.Impression) error {
+	line, err := json.Marshal(impression)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	eventLog.mu.Lock()
+	defer eventLog.mu.Unlock()
+	if eventLog.closed {
+		return fmt.Errorf(constants.ErrorMessageEventLogClosed, eventLog.Dir)
+	}
+	if eventLog.size+int64(len(line)) > eventLog.Config.MaxBytes {
+		return fmt.Errorf(constants.ErrorMessageEventLogFull, eventLog.Dir, eventLog.Config.MaxBytes)
+	}
+	if eventLog.file != nil && eventLog.sizes[eventLog.segment]+int64(len(line)) > eventLog.Config.MaxSegmentBytes {
+		if err := eventLog.closeSegment(); err != nil {
+			return err
+		}
+	}
+	if eventLog.file == nil {
+		if err := eventLog.openSegment(); err != nil {
+			return err
+		}
+	}
+
+	written, err := eventLog.file.Write(line)
+	if err != nil {
+		if written > 0 {
+			// never leave a partial record the next ones would be appended to
+			eventLog.file.Truncate(eventLog.sizes[eventLog.segment])
+		}
+		return err
+	}
+	eventLog.sizes[eventLog.segment] += int64(written)
+	eventLog.size += int64(written)
+	if !eventLog.Config.NoSync {
+		return eventLog.file.Sync()
+	}
+	return nil
+}
+
+// Replay function returns the impressions of all the segments in the order they were appended,
+// the partial last record of a segment left by a crash is skipped
+func (eventLog *FileEventLog) Replay() ([]schema.Impression, error) {
+	eventLog.mu.Lock()
+	defer eventLog.mu.Unlock()
+	var impressions []schema.Impression
+	for _, segment := range eventLog.getSegments() {
+		segmentImpressions, err := readSegment(eventLog.getPath(segment))
+		if err != nil {
+			return nil, err
+		}
+		impressions = append(impressions, segmentImpressions...)
+	}
+	return impressions, nil
+}
+
+// Seal function returns the checkpoint of the segments appended or replayed since the previous Seal,
+// the impressions appended afterwards go to a new segment
+func (eventLog *FileEventLog) Seal() (int64, error) {
+	eventLog.mu.Lock()
+	defer eventLog.mu.Unlock()
+	if eventLog.closed {
+		return 0, fmt.Errorf(constants.ErrorMessageEventLogClosed, eventLog.Dir)
+	}
+	if err := eventLog.closeSegment(); err != nil {
+		return 0, err
+	}
+	eventLog.checkpoint++
+	eventLog.checkpoints[eventLog.checkpoint] = eventLog.pending
+	eventLog.pending = nil
+	return eventLog.checkpoint, nil
+}
+
+// Truncate function removes the segments of the checkpoint, the segments of the checkpoints not truncated
+// are kept on the disk and replayed when the log is opened again
+func (eventLog *FileEventLog) Truncate(checkpoint int64) error {
+	eventLog.mu.Lock()
+	defer eventLog.mu.Unlock()
+	var err error
+	for _, segment := range eventLog.checkpoints[checkpoint] {
+		if removeErr := os.Remove(eventLog.getPath(segment)); removeErr != nil && !os.IsNotExist(removeErr) {
+			if err == nil {
+				err = removeErr
+			}
+			continue
+		}
+		eventLog.size -= eventLog.sizes[segment]
+		delete(eventLog.sizes, segment)
+	}
+	delete(eventLog.checkpoints, checkpoint)
+	return err
+}
+
+// Rewrite function writes the impressions to the first segment of the checkpoint and removes the other ones,
+// the checkpoint is truncated if there is no impression left
+func (eventLog *FileEventLog) Rewrite(checkpoint int64, impressions []schema.Impression) error {
+	if len(impressions) == 0 {
+		return eventLog.Truncate(checkpoint)
+	}
+	eventLog.mu.Lock()
+	defer eventLog.mu.Unlock()
+	segments := eventLog.checkpoints[checkpoint]
+	if len(segments) == 0 {
+		return nil
+	}
+	size, err := writeSegment(eventLog.getPath(segments[0]), impressions)
+	if err != nil {
+		return err
+	}
+	eventLog.size += size - eventLog.sizes[segments[0]]
+	eventLog.sizes[segments[0]] = size
+	for _, segment := range segments[1:] {
+		if removeErr := os.Remove(eventLog.getPath(segment)); removeErr != nil && !os.IsNotExist(removeErr) {
+			if err == nil {
+				err = removeErr
+			}
+			continue
+		}
+		eventLog.size -= eventLog.sizes[segment]
+		delete(eventLog.sizes, segment)
+	}
+	eventLog.checkpoints[checkpoint] = segments[:1]
+	return err
+}
+
+// Delete function rewrites the segments without the impressions of the user, so none of them is left on the disk
+func (eventLog *FileEventLog) Delete(ctx context.Context, userID string) error {
+	eventLog.mu.Lock()
+	defer eventLog.mu.Unlock()
+	if eventLog.closed {
+		return fmt.Errorf(constants.ErrorMessageEventLogClosed, eventLog.Dir)
+	}
+	if err := eventLog.closeSegment(); err != nil {
+		return err
+	}
+	escapedUserID := url.PathEscape(userID)
+	for _, segment := range eventLog.getSegments() {
+		impressions, err := readSegment(eventLog.getPath(segment))
+		if err != nil {
+			return err
+		}
+		var kept []schema.Impression
+		for _, impression := range impressions {
+			if impression.UID != escapedUserID {
+				kept = append(kept, impression)
+			}
+		}
+		if len(kept) == len(impressions) {
+			continue
+		}
+		size, err := writeSegment(eventLog.getPath(segment), kept)
+		if err != nil {
+			return err
+		}
+		eventLog.size += size - eventLog.sizes[segment]
+		eventLog.sizes[segment] = size
+	}
+	return nil
+}
+
+// Size function returns the size in bytes of all the segments
+func (eventLog *FileEventLog) Size() int64 {
+	eventLog.mu.Lock()
+	defer eventLog.mu.Unlock()
+	return eventLog.size
+}
+
+// Close function syncs and closes the current segment, the segments are kept to be replayed
+func (eventLog *FileEventLog) Close() error {
+	eventLog.mu.Lock()
+	defer eventLog.mu.Unlock()
+	if eventLog.closed {
+		return nil
+	}
+	eventLog.closed = true
+	return eventLog.closeSegment()
+}
+
+// openSegment function creates the next segment and makes it current, the log must be locked
+func (eventLog *FileEventLog) openSegment() error {
+	segment := eventLog.nextSegment
+	file, err := os.OpenFile(eventLog.getPath(segment), os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	syncDirectory(eventLog.Dir)
+	eventLog.file = file
+	eventLog.segment = segment
+	eventLog.nextSegment++
+	eventLog.sizes[segment] = 0
+	eventLog.pending = append(eventLog.pending, segment)
+	return nil
+}
+
+// closeSegment function syncs and closes the current segment if any, the log must be locked
+func (eventLog *FileEventLog) closeSegment() error {
+	if eventLog.file == nil {
+		return nil
+	}
+	err := eventLog.file.Sync()
+	if closeErr := eventLog.file.Close(); err == nil {
+		err = closeErr
+	}
+	eventLog.file = nil
+	return err
+}
+
+// getSegments function returns all the segments on the disk in the order they were created, the log must be locked
+func (eventLog *FileEventLog) getSegments() []int64 {
+	segments := make([]int64, 0, len(eventLog.sizes))
+	for segment := range eventLog.sizes {
+		segments = append(segments, segment)
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i] < segments[j] })
+	return segments
+}
+
+// getPath function returns the path of the segment file, named after its zero padded sequence number
+func (eventLog *FileEventLog) getPath(segment int64) string {
+	return filepath.Join(eventLog.Dir, fmt.Sprintf("%020d%v", segment, constants.EventLogSegmentExtension))
+}
+
+// readSegment function returns the impressions of the segment file, a partial or invalid record is skipped
+func readSegment(path string) ([]schema.Impression, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var impressions []schema.Impression
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return impressions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var impression schema.Impression
+		if json.Unmarshal(line, &impression) == nil {
+			impressions = append(impressions, impression)
+		}
+	}
+}
+
+// writeSegment function writes the impressions to a temporary file which atomically replaces the segment file,
+// and returns its size
+func writeSegment(path string, impressions []schema.Impression) (int64, error) {
+	temporaryPath := path + ".tmp"
+	temporaryFile, err := os.OpenFile(temporaryPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, err
+	}
+	writer := bufio.NewWriter(temporaryFile)
+	var size int64
+	for _, impression := range impressions {
+		var line []byte
+		if line, err = json.Marshal(impression); err != nil {
+			break
+		}
+		line = append(line, '\n')
+		if _, err = writer.Write(line); err != nil {
+			break
+		}
+		size += int64(len(line))
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = temporaryFile.Sync()
+	}
+	if closeErr := temporaryFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporaryPath, path)
+	}
+	if err != nil {
+		os.Remove(temporaryPath)
+		return 0, err
+	}
+	syncDirectory(filepath.Dir(path))
+	return size, nil
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+)
+
+func getEventLogDir(t *testing.T) (string, func()) {
+	directory, err := ioutil.TempDir("", "vwo-event-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(directory, "events"), func() { os.RemoveAll(directory) }
+}
+
+func getImpressions(userID string, count int) []schema.Impression {
+	impressions := make([]schema.Impression, count)
+	for i := range impressions {
+		impressions[i] = schema.Impression{UID: userID, SID: strconv.Itoa(i), EventType: "track-user", ExperimentID: i}
+	}
+	return impressions
+}
+
+func TestFileEventLog(t *testing.T) {
+	assertOutput := assert.New(t)
+	dir, cleanup := getEventLogDir(t)
+	defer cleanup()
+
+	eventLog, err := NewFileEventLog(dir, FileEventLogConfig{})
+	assertOutput.Nil(err)
+	impressions, err := eventLog.Replay()
+	assertOutput.Nil(err)
+	assertOutput.Empty(impressions)
+
+	sent := getImpressions("sent", 2)
+	for _, impression := range sent {
+		assertOutput.Nil(eventLog.Append(impression))
+	}
+	checkpoint, err := eventLog.Seal()
+	assertOutput.Nil(err)
+	assertOutput.Nil(eventLog.Truncate(checkpoint))
+	assertOutput.Equal(int64(0), eventLog.Size())
+
+	failed := getImpressions("failed", 2)
+	for _, impression := range failed {
+		assertOutput.Nil(eventLog.Append(impression))
+	}
+	_, err = eventLog.Seal()
+	assertOutput.Nil(err)
+	queued := getImpressions("queued", 3)
+	for _, impression := range queued {
+		assertOutput.Nil(eventLog.Append(impression))
+	}
+	assertOutput.Nil(eventLog.Close())
+	assertOutput.Nil(eventLog.Close())
+	assertOutput.NotNil(eventLog.Append(queued[0]), "Closed log can not be written")
+
+	// the impressions not truncated are replayed after a restart, in the order they were appended
+	eventLog, err = NewFileEventLog(dir, FileEventLogConfig{})
+	assertOutput.Nil(err)
+	defer eventLog.Close()
+	impressions, err = eventLog.Replay()
+	assertOutput.Nil(err)
+	assertOutput.Equal(append(failed, queued...), impressions)
+
+	assertOutput.Nil(eventLog.Append(sent[0]))
+	checkpoint, _ = eventLog.Seal()
+	assertOutput.Nil(eventLog.Truncate(checkpoint))
+	assertOutput.Equal(int64(0), eventLog.Size())
+	files, _ := ioutil.ReadDir(dir)
+	assertOutput.Empty(files, "Truncated segments should be removed")
+}
+
+func TestFileEventLogRewrite(t *testing.T) {
+	assertOutput := assert.New(t)
+	dir, cleanup := getEventLogDir(t)
+	defer cleanup()
+
+	// every impression goes to its own segment
+	eventLog, err := NewFileEventLog(dir, FileEventLogConfig{MaxSegmentBytes: 1})
+	assertOutput.Nil(err)
+	impressions := getImpressions("user", 4)
+	for _, impression := range impressions {
+		assertOutput.Nil(eventLog.Append(impression))
+	}
+	checkpoint, err := eventLog.Seal()
+	assertOutput.Nil(err)
+	assertOutput.Nil(eventLog.Rewrite(checkpoint, impressions[2:]))
+	files, _ := ioutil.ReadDir(dir)
+	assertOutput.Len(files, 1, "Rewritten checkpoint should be kept in one segment")
+	assertOutput.Nil(eventLog.Close())
+
+	eventLog, err = NewFileEventLog(dir, FileEventLogConfig{})
+	assertOutput.Nil(err)
+	defer eventLog.Close()
+	replayed, err := eventLog.Replay()
+	assertOutput.Nil(err)
+	assertOutput.Equal(impressions[2:], replayed, "Only the impressions not sent should be replayed")
+
+	checkpoint, err = eventLog.Seal()
+	assertOutput.Nil(err)
+	assertOutput.Nil(eventLog.Rewrite(checkpoint, impressions[3:]))
+	assertOutput.Nil(eventLog.Rewrite(checkpoint, nil))
+	assertOutput.Equal(int64(0), eventLog.Size())
+	files, _ = ioutil.ReadDir(dir)
+	assertOutput.Empty(files, "Checkpoint without impression should be truncated")
+}
+
+func TestFileEventLogSizeCaps(t *testing.T) {
+	assertOutput := assert.New(t)
+	dir, cleanup := getEventLogDir(t)
+	defer cleanup()
+
+	impressions := getImpressions("user", 10)
+	eventLog, err := NewFileEventLog(dir, FileEventLogConfig{MaxBytes: 1000, MaxSegmentBytes: 300, NoSync: true})
+	assertOutput.Nil(err)
+	defer eventLog.Close()
+	appended := 0
+	for _, impression := range impressions {
+		if eventLog.Append(impression) != nil {
+			break
+		}
+		appended++
+	}
+	assertOutput.True(appended > 1 && appended < len(impressions), "Appends should fail once MaxBytes is reached")
+	assertOutput.True(eventLog.Size() <= 1000)
+	files, _ := ioutil.ReadDir(dir)
+	assertOutput.True(len(files) > 1, "Segments should be rotated at MaxSegmentBytes")
+	for _, file := range files {
+		assertOutput.True(file.Size() <= 300, file.Name())
+	}
+
+	replayed, err := eventLog.Replay()
+	assertOutput.Nil(err)
+	assertOutput.Equal(impressions[:appended], replayed)
+}
+
+func TestFileEventLogPartialRecord(t *testing.T) {
+	assertOutput := assert.New(t)
+	dir, cleanup := getEventLogDir(t)
+	defer cleanup()
+
+	eventLog, _ := NewFileEventLog(dir, FileEventLogConfig{})
+	impressions := getImpressions("user", 2)
+	eventLog.Append(impressions[0])
+	eventLog.Append(impressions[1])
+	eventLog.Close()
+	file, _ := os.OpenFile(eventLog.getPath(0), os.O_WRONLY|os.O_APPEND, 0644)
+	file.WriteString(`{"uId":"user","sId":`)
+	file.Close()
+
+	eventLog, err := NewFileEventLog(dir, FileEventLogConfig{})
+	assertOutput.Nil(err)
+	defer eventLog.Close()
+	replayed, err := eventLog.Replay()
+	assertOutput.Nil(err)
+	assertOutput.Equal(impressions, replayed, "A partial record left by a crash should be skipped")
+}
+
+func TestFileEventLogDelete(t *testing.T) {
+	assertOutput := assert.New(t)
+	dir, cleanup := getEventLogDir(t)
+	defer cleanup()
+
+	eventLog, _ := NewFileEventLog(dir, FileEventLogConfig{MaxSegmentBytes: 300})
+	defer eventLog.Close()
+	kept := getImpressions("otherUser", 3)
+	for i, impression := range getImpressions("user1", 3) {
+		eventLog.Append(impression)
+		eventLog.Append(kept[i])
+	}
+	eventLog.Append(schema.Impression{UID: "user%203", EventType: "push"})
+	checkpoint, _ := eventLog.Seal()
+
+	assertOutput.Nil(eventLog.Delete(context.Background(), "user1"))
+	assertOutput.Nil(eventLog.Delete(context.Background(), "user 3"), "The user ID of an impression is escaped")
+	replayed, _ := eventLog.Replay()
+	assertOutput.Equal(kept, replayed)
+	assertOutput.Nil(eventLog.Append(getImpressions("user2", 1)[0]), "Impressions should be appended after a delete")
+
+	assertOutput.Nil(eventLog.Truncate(checkpoint))
+	replayed, _ = eventLog.Replay()
+	assertOutput.Equal(getImpressions("user2", 1), replayed)
+}
@@ -0,0 +1,376 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+const fileUserStorage = "file_user_storage.go"
+
+// FileStorageConfig struct configures a FileUserStorage
+type FileStorageConfig struct {
+	// SyncMode is constants.FileStorageSyncAlways to fsync the file on every Set,
+	// constants.FileStorageSyncInterval to fsync it every SyncInterval seconds or constants.FileStorageSyncNone
+	SyncMode           string
+	SyncInterval       int
+	CompactionInterval int
+	Logger             interface{}
+}
+
+// SetDefaults sets the default value of every field which is not set
+func (config *FileStorageConfig) SetDefaults() {
+	if config.SyncMode == "" {
+		config.SyncMode = constants.FileStorageSyncInterval
+	}
+	if config.SyncInterval < 1 {
+		config.SyncInterval = constants.FileStorageDefaultSyncInterval
+	}
+	if config.CompactionInterval < 1 {
+		config.CompactionInterval = constants.FileStorageDefaultCompactionInterval
+	}
+}
+
+// FileUserStorage is a concurrency safe user storage persisting every assignment to an append-only file,
+// one JSON record per line, the file is periodically compacted to the latest record of every user and campaign
+type FileUserStorage struct {
+	Path   string
+	Config FileStorageConfig
+
+	mu        sync.RWMutex
+	file      *os.File
+	size      int64
+	lines     int
+	dirty     bool
+	records   map[string]schema.UserData
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewFileUserStorage loads the assignments stored in the file at path, creating it if needed,
+// and starts the periodic sync and compaction. The storage must be closed with Close
+func NewFileUserStorage(path string, config FileStorageConfig) (*FileUserStorage, error) {
+	config.SetDefaults()
+	if config.SyncMode != constants.FileStorageSyncAlways && config.SyncMode != constants.FileStorageSyncInterval && config.SyncMode != constants.FileStorageSyncNone {
+		return nil, fmt.Errorf(constants.ErrorMessageInvalidFileStorageSyncMode, config.SyncMode)
+	}
+
+	storage := &FileUserStorage{
+		Path:    path,
+		Config:  config,
+		records: make(map[string]schema.UserData),
+		done:    make(chan struct{}),
+	}
+	if err := storage.load(); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	storage.file = file
+
+	storage.wg.Add(1)
+	go storage.run()
+	return storage, nil
+}
+
+// Get function returns the stored data of the user for the campaign, or an empty UserData if none is stored
+func (storage *FileUserStorage) Get(ctx context.Context, userID, campaignKey string) (schema.UserData, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+	return storage.records[getKey(userID, campaignKey)], nil
+}
+
+// GetMany function returns the stored data of the user for every campaign having some
+func (storage *FileUserStorage) GetMany(ctx context.Context, userID string, campaignKeys []string) (map[string]schema.UserData, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+	userDatas := make(map[string]schema.UserData)
+	for _, campaignKey := range campaignKeys {
+		if userData, ok := storage.records[getKey(userID, campaignKey)]; ok {
+			userDatas[campaignKey] = userData
+		}
+	}
+	return userDatas, nil
+}
+
+// Set function appends the data of the user for the campaign to the file, and syncs it in the always sync mode
+func (storage *FileUserStorage) Set(ctx context.Context, userData schema.UserData) error {
+	return storage.SetMany(ctx, []schema.UserData{userData})
+}
+
+// SetMany function appends all the data to the file in a single write, and syncs it once in the always sync mode
+func (storage *FileUserStorage) SetMany(ctx context.Context, userDatas []schema.UserData) error {
+	var lines []byte
+	for _, userData := range userDatas {
+		line, err := json.Marshal(userData)
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	if storage.file == nil {
+		return fmt.Errorf(constants.ErrorMessageFileStorageClosed, storage.Path)
+	}
+	written, err := storage.file.Write(lines)
+	if err != nil {
+		if written > 0 {
+			// never leave a partial record the next ones would be appended to
+			storage.file.Truncate(storage.size)
+		}
+		return err
+	}
+	storage.size += int64(written)
+	storage.lines += len(userDatas)
+	for _, userData := range userDatas {
+		storage.records[getKey(userData.UserID, userData.CampaignKey)] = userData
+	}
+	storage.dirty = true
+	if storage.Config.SyncMode == constants.FileStorageSyncAlways {
+		return storage.sync()
+	}
+	return nil
+}
+
+// Delete function removes the data of the user for every campaign, the file is compacted at once
+// so none of the records of the user is left on the disk
+func (storage *FileUserStorage) Delete(ctx context.Context, userID string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	if storage.file == nil {
+		return fmt.Errorf(constants.ErrorMessageFileStorageClosed, storage.Path)
+	}
+	deleted := false
+	for key, userData := range storage.records {
+		if userData.UserID == userID {
+			delete(storage.records, key)
+			deleted = true
+		}
+	}
+	if !deleted {
+		return nil
+	}
+	return storage.compact()
+}
+
+// Sync function flushes the appended records to the disk
+func (storage *FileUserStorage) Sync() error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	return storage.sync()
+}
+
+// Compact function rewrites the file with the latest record of every user and campaign
+func (storage *FileUserStorage) Compact() error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	return storage.compact()
+}
+
+// Close function stops the periodic sync and compaction, syncs and closes the file
+func (storage *FileUserStorage) Close() error {
+	storage.mu.Lock()
+	if storage.file == nil {
+		storage.mu.Unlock()
+		return nil
+	}
+	storage.closeOnce.Do(func() { close(storage.done) })
+	storage.mu.Unlock()
+	storage.wg.Wait()
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	if storage.file == nil {
+		return nil
+	}
+	err := storage.sync()
+	if closeErr := storage.file.Close(); err == nil {
+		err = closeErr
+	}
+	storage.file = nil
+	return err
+}
+
+// run function syncs and compacts the file at the configured intervals until the storage is closed
+func (storage *FileUserStorage) run() {
+	defer storage.wg.Done()
+	compactionTicker := time.NewTicker(time.Duration(storage.Config.CompactionInterval) * time.Second)
+	defer compactionTicker.Stop()
+	var syncTicks <-chan time.Time
+	if storage.Config.SyncMode == constants.FileStorageSyncInterval {
+		syncTicker := time.NewTicker(time.Duration(storage.Config.SyncInterval) * time.Second)
+		defer syncTicker.Stop()
+		syncTicks = syncTicker.C
+	}
+
+	for {
+		select {
+		case <-storage.done:
+			return
+		case <-syncTicks:
+			if err := storage.Sync(); err != nil {
+				storage.logError(err)
+			}
+		case <-compactionTicker.C:
+			storage.mu.Lock()
+			var err error
+			if storage.lines >= constants.FileStorageCompactionRatio*len(storage.records) && storage.lines > len(storage.records) {
+				err = storage.compact()
+			}
+			storage.mu.Unlock()
+			if err != nil {
+				storage.logError(err)
+			}
+		}
+	}
+}
+
+// load function reads the records of the file, a partial last record left by a crash is truncated
+func (storage *FileUserStorage) load() error {
+	file, err := os.OpenFile(storage.Path, os.O_RDWR, 0644)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				return file.Truncate(storage.size)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		storage.size += int64(len(line))
+		storage.lines++
+		var userData schema.UserData
+		if json.Unmarshal(line, &userData) == nil {
+			storage.records[getKey(userData.UserID, userData.CampaignKey)] = userData
+		}
+	}
+}
+
+// sync function fsyncs the file if records were appended since the last sync, the storage must be locked
+func (storage *FileUserStorage) sync() error {
+	if !storage.dirty || storage.file == nil {
+		return nil
+	}
+	if err := storage.file.Sync(); err != nil {
+		return err
+	}
+	storage.dirty = false
+	return nil
+}
+
+// compact function writes the records to a temporary file which atomically replaces the file, the storage must be locked
+func (storage *FileUserStorage) compact() error {
+	if storage.file == nil {
+		return fmt.Errorf(constants.ErrorMessageFileStorageClosed, storage.Path)
+	}
+	temporaryPath := storage.Path + ".compact"
+	temporaryFile, err := os.OpenFile(temporaryPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(temporaryFile)
+	encoder := json.NewEncoder(writer)
+	for _, userData := range storage.records {
+		if err = encoder.Encode(userData); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = temporaryFile.Sync()
+	}
+	if closeErr := temporaryFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporaryPath, storage.Path)
+	}
+	if err != nil {
+		os.Remove(temporaryPath)
+		return err
+	}
+	syncDirectory(filepath.Dir(storage.Path))
+
+	file, err := os.OpenFile(storage.Path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	storage.file.Close()
+	storage.file = file
+	storage.dirty = false
+
+	if storage.Config.Logger != nil {
+		message := fmt.Sprintf(constants.InfoMessageFileStorageCompacted, storage.Path, storage.lines, len(storage.records))
+		utils.LogMessage(storage.Config.Logger, constants.Info, fileUserStorage, message)
+	}
+	storage.size = info.Size()
+	storage.lines = len(storage.records)
+	return nil
+}
+
+// logError function logs an error of the periodic sync or compaction
+func (storage *FileUserStorage) logError(err error) {
+	if storage.Config.Logger != nil {
+		message := fmt.Sprintf(constants.ErrorMessageFileStorageFailed, storage.Path, err.Error())
+		utils.LogMessage(storage.Config.Logger, constants.Error, fileUserStorage, message)
+	}
+}
+
+// syncDirectory function fsyncs the directory so a rename in it is durable, errors are ignored as not every platform supports it
+func syncDirectory(path string) {
+	directory, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	directory.Sync()
+	directory.Close()
+}
@@ -0,0 +1,250 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+)
+
+func getStoragePath(t *testing.T) (string, func()) {
+	directory, err := ioutil.TempDir("", "vwo-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(directory, "user_storage.jsonl"), func() { os.RemoveAll(directory) }
+}
+
+func TestFileUserStorage(t *testing.T) {
+	assertOutput := assert.New(t)
+	path, cleanup := getStoragePath(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	storage, err := NewFileUserStorage(path, FileStorageConfig{SyncMode: constants.FileStorageSyncAlways})
+	assertOutput.Nil(err)
+	userData, err := storage.Get(ctx, "user", "campaign")
+	assertOutput.Nil(err)
+	assertOutput.Equal(schema.UserData{}, userData)
+
+	expected := schema.UserData{UserID: "user", CampaignKey: "campaign", VariationName: "Control", GoalIdentifier: "goal"}
+	assertOutput.Nil(storage.Set(ctx, expected))
+	expected.VariationName = "Variation-1"
+	assertOutput.Nil(storage.Set(ctx, expected))
+	assertOutput.Nil(storage.Set(ctx, schema.UserData{UserID: "other user", CampaignKey: "campaign", VariationName: "Control"}))
+	userData, _ = storage.Get(ctx, "user", "campaign")
+	assertOutput.Equal(expected, userData)
+	assertOutput.Nil(storage.Close())
+	assertOutput.Nil(storage.Close())
+	assertOutput.NotNil(storage.Set(ctx, expected), "Closed storage can not be written")
+
+	// the assignments survive a restart
+	storage, err = NewFileUserStorage(path, FileStorageConfig{})
+	assertOutput.Nil(err)
+	defer storage.Close()
+	userData, _ = storage.Get(ctx, "user", "campaign")
+	assertOutput.Equal(expected, userData)
+	userData, _ = storage.Get(ctx, "other user", "campaign")
+	assertOutput.Equal("Control", userData.VariationName)
+	assertOutput.Equal(3, storage.lines)
+}
+
+func TestFileUserStoragePartialRecord(t *testing.T) {
+	assertOutput := assert.New(t)
+	path, cleanup := getStoragePath(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	content := `{"UserID":"user","CampaignKey":"campaign","VariationName":"Control","GoalIdentifier":""}` + "\n" +
+		"not a record\n" +
+		`{"UserID":"user","CampaignKey":"campaign","Variati`
+	assertOutput.Nil(ioutil.WriteFile(path, []byte(content), 0644))
+
+	storage, err := NewFileUserStorage(path, FileStorageConfig{SyncMode: constants.FileStorageSyncNone})
+	assertOutput.Nil(err)
+	userData, _ := storage.Get(ctx, "user", "campaign")
+	assertOutput.Equal("Control", userData.VariationName)
+
+	// records appended after a crash must not be glued to the partial record
+	assertOutput.Nil(storage.Set(ctx, schema.UserData{UserID: "user", CampaignKey: "campaign", VariationName: "Variation-1"}))
+	assertOutput.Nil(storage.Close())
+
+	storage, err = NewFileUserStorage(path, FileStorageConfig{})
+	assertOutput.Nil(err)
+	defer storage.Close()
+	userData, _ = storage.Get(ctx, "user", "campaign")
+	assertOutput.Equal("Variation-1", userData.VariationName)
+}
+
+func TestFileUserStorageCompact(t *testing.T) {
+	assertOutput := assert.New(t)
+	path, cleanup := getStoragePath(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	storage, err := NewFileUserStorage(path, FileStorageConfig{})
+	assertOutput.Nil(err)
+	for i := 0; i < 100; i++ {
+		storage.Set(ctx, schema.UserData{UserID: strconv.Itoa(i % 10), CampaignKey: "campaign", VariationName: "Variation-" + strconv.Itoa(i)})
+	}
+	assertOutput.Equal(100, storage.lines)
+
+	assertOutput.Nil(storage.Compact())
+	assertOutput.Equal(10, storage.lines)
+	content, err := ioutil.ReadFile(path)
+	assertOutput.Nil(err)
+	assertOutput.Equal(10, bytes.Count(content, []byte("\n")))
+	_, err = os.Stat(path + ".compact")
+	assertOutput.True(os.IsNotExist(err))
+
+	// appends go to the compacted file
+	assertOutput.Nil(storage.Set(ctx, schema.UserData{UserID: "new user", CampaignKey: "campaign", VariationName: "Control"}))
+	assertOutput.Nil(storage.Close())
+
+	storage, err = NewFileUserStorage(path, FileStorageConfig{})
+	assertOutput.Nil(err)
+	defer storage.Close()
+	assertOutput.Equal(11, storage.lines)
+	for i := 0; i < 10; i++ {
+		userData, _ := storage.Get(ctx, strconv.Itoa(i), "campaign")
+		assertOutput.Equal("Variation-"+strconv.Itoa(90+i), userData.VariationName)
+	}
+	userData, _ := storage.Get(ctx, "new user", "campaign")
+	assertOutput.Equal("Control", userData.VariationName)
+}
+
+func TestFileUserStorageDelete(t *testing.T) {
+	assertOutput := assert.New(t)
+	path, cleanup := getStoragePath(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	storage, err := NewFileUserStorage(path, FileStorageConfig{SyncMode: constants.FileStorageSyncNone})
+	assertOutput.Nil(err)
+	storage.Set(ctx, schema.UserData{UserID: "forgotten user", CampaignKey: "campaign", VariationName: "Control"})
+	storage.Set(ctx, schema.UserData{UserID: "forgotten user", CampaignKey: "other campaign", VariationName: "Control"})
+	storage.Set(ctx, schema.UserData{UserID: "user", CampaignKey: "campaign", VariationName: "Control"})
+	assertOutput.Nil(storage.Delete(ctx, "forgotten user"))
+	assertOutput.Nil(storage.Delete(ctx, "unknown user"))
+
+	content, err := ioutil.ReadFile(path)
+	assertOutput.Nil(err)
+	assertOutput.False(bytes.Contains(content, []byte("forgotten user")), "No record of the user should be left in the file")
+	assertOutput.Nil(storage.Close())
+	assertOutput.NotNil(storage.Delete(ctx, "user"), "Closed storage can not be written")
+
+	storage, err = NewFileUserStorage(path, FileStorageConfig{})
+	assertOutput.Nil(err)
+	defer storage.Close()
+	userData, _ := storage.Get(ctx, "forgotten user", "campaign")
+	assertOutput.Empty(userData.VariationName)
+	userData, _ = storage.Get(ctx, "user", "campaign")
+	assertOutput.Equal("Control", userData.VariationName)
+}
+
+func TestFileUserStorageInvalidSyncMode(t *testing.T) {
+	path, cleanup := getStoragePath(t)
+	defer cleanup()
+
+	_, err := NewFileUserStorage(path, FileStorageConfig{SyncMode: "sometimes"})
+	assert.NotNil(t, err)
+}
+
+func TestFileUserStorageConcurrency(t *testing.T) {
+	assertOutput := assert.New(t)
+	path, cleanup := getStoragePath(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	storage, err := NewFileUserStorage(path, FileStorageConfig{})
+	assertOutput.Nil(err)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				userID := strconv.Itoa(worker*1000 + j%50)
+				storage.Set(ctx, schema.UserData{UserID: userID, CampaignKey: "campaign", VariationName: "Control"})
+				storage.Get(ctx, userID, "campaign")
+				if j%100 == 0 {
+					storage.Compact()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assertOutput.Nil(storage.Close())
+
+	storage, err = NewFileUserStorage(path, FileStorageConfig{})
+	assertOutput.Nil(err)
+	defer storage.Close()
+	assertOutput.Len(storage.records, 400)
+}
+
+func TestFileUserStorageConcurrentClose(t *testing.T) {
+	assertOutput := assert.New(t)
+	path, cleanup := getStoragePath(t)
+	defer cleanup()
+
+	storage, err := NewFileUserStorage(path, FileStorageConfig{})
+	assertOutput.Nil(err)
+	assertOutput.Nil(storage.Set(context.Background(), schema.UserData{UserID: "user", CampaignKey: "campaign", VariationName: "Control"}))
+	start := make(chan struct{})
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		go func() {
+			<-start
+			errs <- storage.Close()
+		}()
+	}
+	close(start)
+	for i := 0; i < 50; i++ {
+		assertOutput.Nil(<-errs)
+	}
+}
+
+func TestFileUserStorageMany(t *testing.T) {
+	assertOutput := assert.New(t)
+	path, cleanup := getStoragePath(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	storage, err := NewFileUserStorage(path, FileStorageConfig{SyncMode: constants.FileStorageSyncAlways})
+	assertOutput.Nil(err)
+	defer storage.Close()
+	userDatas := []schema.UserData{
+		{UserID: "user", CampaignKey: "first", VariationName: "Control"},
+		{UserID: "user", CampaignKey: "second", VariationName: "Variation-1"},
+	}
+	assertOutput.Nil(storage.SetMany(ctx, userDatas))
+	assertOutput.Equal(2, storage.lines)
+
+	stored, err := storage.GetMany(ctx, "user", []string{"first", "second", "third"})
+	assertOutput.Nil(err)
+	assertOutput.Equal(map[string]schema.UserData{"first": userDatas[0], "second": userDatas[1]}, stored)
+}
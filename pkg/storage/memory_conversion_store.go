@@ -0,0 +1,134 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+)
+
+// MemoryConversionStore is a concurrency safe in-memory conversion store, it keeps at most MaxEntries conversions,
+// evicting the least recently used ones, and forgets every conversion TTL after it was saved. A user whose conversion
+// is forgotten is tracked again on the goal. The fields must be set before the store is first used,
+// the zero value is an unbounded store
+type MemoryConversionStore struct {
+	MaxEntries int
+	TTL        time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	now     func() time.Time
+}
+
+// conversionEntry struct is an element of the LRU list of the store
+type conversionEntry struct {
+	key        string
+	conversion schema.Conversion
+	expiresAt  time.Time
+}
+
+// NewMemoryConversionStore returns an empty store keeping at most maxEntries conversions, every conversion is kept
+// for ttl, or until evicted if ttl is 0. A maxEntries of 0 does not bound the store
+func NewMemoryConversionStore(maxEntries int, ttl time.Duration) *MemoryConversionStore {
+	return &MemoryConversionStore{
+		MaxEntries: maxEntries,
+		TTL:        ttl,
+	}
+}
+
+// GetConversion function returns the first conversion of the user on the goal
+func (store *MemoryConversionStore) GetConversion(ctx context.Context, userID, campaignKey, goalIdentifier string) (schema.Conversion, bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	element := store.get(getConversionKey(userID, campaignKey, goalIdentifier))
+	if element == nil {
+		return schema.Conversion{}, false, nil
+	}
+	return element.Value.(*conversionEntry).conversion, true, nil
+}
+
+// AddConversion function saves the conversion unless the user already converted on the goal,
+// evicting the least recently used conversion if the store is full
+func (store *MemoryConversionStore) AddConversion(ctx context.Context, conversion schema.Conversion) (schema.Conversion, bool, error) {
+	key := getConversionKey(conversion.UserID, conversion.CampaignKey, conversion.GoalIdentifier)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if element := store.get(key); element != nil {
+		return element.Value.(*conversionEntry).conversion, false, nil
+	}
+
+	entry := &conversionEntry{key: key, conversion: conversion}
+	if store.TTL > 0 {
+		entry.expiresAt = store.now().Add(store.TTL)
+	}
+	store.entries[key] = store.lru.PushFront(entry)
+	if store.MaxEntries > 0 && store.lru.Len() > store.MaxEntries {
+		store.remove(store.lru.Back())
+	}
+	return conversion, true, nil
+}
+
+// Delete function removes the conversions of the user on every goal
+func (store *MemoryConversionStore) Delete(ctx context.Context, userID string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for _, element := range store.entries {
+		if element.Value.(*conversionEntry).conversion.UserID == userID {
+			store.remove(element)
+		}
+	}
+	return nil
+}
+
+// get function returns the element of the key, nil if there is none or it expired. The store must be locked,
+// it is initialized on first use
+func (store *MemoryConversionStore) get(key string) *list.Element {
+	if store.entries == nil {
+		store.entries = make(map[string]*list.Element)
+		store.lru = list.New()
+		if store.now == nil {
+			store.now = time.Now
+		}
+	}
+	element, ok := store.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*conversionEntry)
+	if !entry.expiresAt.IsZero() && !store.now().Before(entry.expiresAt) {
+		store.remove(element)
+		return nil
+	}
+	store.lru.MoveToFront(element)
+	return element
+}
+
+// remove function deletes the element from the store, the store must be locked
+func (store *MemoryConversionStore) remove(element *list.Element) {
+	store.lru.Remove(element)
+	delete(store.entries, element.Value.(*conversionEntry).key)
+}
+
+// getConversionKey function returns the key of the conversion of a user on a goal of a campaign
+func getConversionKey(userID, campaignKey, goalIdentifier string) string {
+	return getKey(userID, campaignKey) + "\x00" + goalIdentifier
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+)
+
+func TestMemoryConversionStore(t *testing.T) {
+	assertOutput := assert.New(t)
+	store := NewMemoryConversionStore(0, 0)
+	ctx := context.Background()
+
+	_, ok, err := store.GetConversion(ctx, "user", "campaign", "goal")
+	assertOutput.Nil(err)
+	assertOutput.False(ok)
+
+	first := schema.Conversion{UserID: "user", CampaignKey: "campaign", GoalIdentifier: "goal", FirstConvertedAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	conversion, isFirst, err := store.AddConversion(ctx, first)
+	assertOutput.Nil(err)
+	assertOutput.True(isFirst)
+	assertOutput.Equal(first, conversion)
+
+	second := first
+	second.FirstConvertedAt = first.FirstConvertedAt.Add(time.Hour)
+	conversion, isFirst, _ = store.AddConversion(ctx, second)
+	assertOutput.False(isFirst)
+	assertOutput.Equal(first, conversion)
+
+	conversion, ok, _ = store.GetConversion(ctx, "user", "campaign", "goal")
+	assertOutput.True(ok)
+	assertOutput.Equal(first, conversion)
+
+	// goal identifiers are not split
+	_, isFirst, _ = store.AddConversion(ctx, schema.Conversion{UserID: "user", CampaignKey: "campaign", GoalIdentifier: "goal_vwo_other"})
+	assertOutput.True(isFirst)
+	_, ok, _ = store.GetConversion(ctx, "user", "campaign", "other")
+	assertOutput.False(ok)
+}
+
+func TestMemoryConversionStoreDelete(t *testing.T) {
+	assertOutput := assert.New(t)
+	store := NewMemoryConversionStore(0, 0)
+	ctx := context.Background()
+
+	store.AddConversion(ctx, schema.Conversion{UserID: "user", CampaignKey: "campaign", GoalIdentifier: "goal"})
+	store.AddConversion(ctx, schema.Conversion{UserID: "user", CampaignKey: "other campaign", GoalIdentifier: "goal"})
+	store.AddConversion(ctx, schema.Conversion{UserID: "other user", CampaignKey: "campaign", GoalIdentifier: "goal"})
+	assertOutput.Nil(store.Delete(ctx, "user"))
+
+	_, ok, _ := store.GetConversion(ctx, "user", "campaign", "goal")
+	assertOutput.False(ok)
+	_, ok, _ = store.GetConversion(ctx, "user", "other campaign", "goal")
+	assertOutput.False(ok)
+	_, ok, _ = store.GetConversion(ctx, "other user", "campaign", "goal")
+	assertOutput.True(ok)
+}
+
+func TestMemoryConversionStoreConcurrency(t *testing.T) {
+	store := NewMemoryConversionStore(0, 0)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	firsts := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, isFirst, _ := store.AddConversion(ctx, schema.Conversion{UserID: "user", CampaignKey: "campaign", GoalIdentifier: "goal"})
+			if isFirst {
+				mu.Lock()
+				firsts++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, firsts)
+}
+
+func TestMemoryConversionStoreEviction(t *testing.T) {
+	assertOutput := assert.New(t)
+	store := NewMemoryConversionStore(2, 0)
+	ctx := context.Background()
+
+	store.AddConversion(ctx, schema.Conversion{UserID: "first", CampaignKey: "campaign", GoalIdentifier: "goal"})
+	store.AddConversion(ctx, schema.Conversion{UserID: "second", CampaignKey: "campaign", GoalIdentifier: "goal"})
+	store.GetConversion(ctx, "first", "campaign", "goal")
+	store.AddConversion(ctx, schema.Conversion{UserID: "third", CampaignKey: "campaign", GoalIdentifier: "goal"})
+
+	_, ok, _ := store.GetConversion(ctx, "first", "campaign", "goal")
+	assertOutput.True(ok, "Recently read conversion should be kept")
+	_, ok, _ = store.GetConversion(ctx, "second", "campaign", "goal")
+	assertOutput.False(ok, "Least recently used conversion should be evicted")
+	_, isFirst, _ := store.AddConversion(ctx, schema.Conversion{UserID: "second", CampaignKey: "campaign", GoalIdentifier: "goal"})
+	assertOutput.True(isFirst, "Evicted conversion should be saved again")
+}
+
+func TestMemoryConversionStoreTTL(t *testing.T) {
+	assertOutput := assert.New(t)
+	store := NewMemoryConversionStore(0, time.Hour)
+	currentTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return currentTime }
+	ctx := context.Background()
+
+	store.AddConversion(ctx, schema.Conversion{UserID: "user", CampaignKey: "campaign", GoalIdentifier: "goal"})
+	currentTime = currentTime.Add(59 * time.Minute)
+	_, ok, _ := store.GetConversion(ctx, "user", "campaign", "goal")
+	assertOutput.True(ok)
+
+	currentTime = currentTime.Add(time.Minute)
+	_, ok, _ = store.GetConversion(ctx, "user", "campaign", "goal")
+	assertOutput.False(ok)
+	_, isFirst, _ := store.AddConversion(ctx, schema.Conversion{UserID: "user", CampaignKey: "campaign", GoalIdentifier: "goal"})
+	assertOutput.True(isFirst)
+}
+
+func TestMemoryConversionStoreZeroValue(t *testing.T) {
+	assertOutput := assert.New(t)
+	var store MemoryConversionStore
+	ctx := context.Background()
+
+	assertOutput.Nil(store.Delete(ctx, "user"))
+	_, ok, err := store.GetConversion(ctx, "user", "campaign", "goal")
+	assertOutput.Nil(err)
+	assertOutput.False(ok)
+	_, isFirst, err := store.AddConversion(ctx, schema.Conversion{UserID: "user", CampaignKey: "campaign", GoalIdentifier: "goal"})
+	assertOutput.Nil(err)
+	assertOutput.True(isFirst)
+}
@@ -0,0 +1,198 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"container/list"
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+)
+
+// MemoryUserStorage is a concurrency safe in-memory user storage, it keeps at most MaxEntries assignments,
+// evicting the least recently used ones, and forgets every assignment TTL after it was saved.
+// The fields must be set before the storage is first used, the zero value is an unbounded storage
+type MemoryUserStorage struct {
+	MaxEntries int
+	TTL        time.Duration
+
+	initOnce sync.Once
+	shards   []*memoryShard
+	now      func() time.Time
+}
+
+// MemoryStats struct holds the counters of a MemoryUserStorage
+type MemoryStats struct {
+	Hits        int64
+	Misses      int64
+	Evictions   int64
+	Expirations int64
+	Entries     int
+}
+
+// memoryShard struct is a mutex protected LRU list of entries
+type memoryShard struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+	stats      MemoryStats
+}
+
+// memoryEntry struct is an element of the LRU list of a shard
+type memoryEntry struct {
+	key       string
+	userData  schema.UserData
+	expiresAt time.Time
+}
+
+// NewMemoryUserStorage returns a storage keeping at most maxEntries assignments, every assignment is kept
+// for ttl, or until evicted if ttl is 0. A maxEntries of 0 does not bound the storage
+func NewMemoryUserStorage(maxEntries int, ttl time.Duration) *MemoryUserStorage {
+	storage := &MemoryUserStorage{
+		MaxEntries: maxEntries,
+		TTL:        ttl,
+	}
+	storage.init()
+	return storage
+}
+
+// init function creates the shards once, sized from MaxEntries
+func (storage *MemoryUserStorage) init() {
+	storage.initOnce.Do(func() {
+		if storage.now == nil {
+			storage.now = time.Now
+		}
+		shardEntries := 0
+		if storage.MaxEntries > 0 {
+			// every shard keeps at least one entry, so the storage may hold up to MemoryStorageShards entries
+			shardEntries = (storage.MaxEntries + constants.MemoryStorageShards - 1) / constants.MemoryStorageShards
+		}
+		storage.shards = make([]*memoryShard, constants.MemoryStorageShards)
+		for i := range storage.shards {
+			storage.shards[i] = &memoryShard{
+				maxEntries: shardEntries,
+				entries:    make(map[string]*list.Element),
+				lru:        list.New(),
+			}
+		}
+	})
+}
+
+// Get function returns the stored data of the user for the campaign, or an empty UserData if none is stored or it expired
+func (storage *MemoryUserStorage) Get(ctx context.Context, userID, campaignKey string) (schema.UserData, error) {
+	key := getKey(userID, campaignKey)
+	shard := storage.getShard(key)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	element, ok := shard.entries[key]
+	if !ok {
+		shard.stats.Misses++
+		return schema.UserData{}, nil
+	}
+	entry := element.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && !storage.now().Before(entry.expiresAt) {
+		shard.remove(element)
+		shard.stats.Expirations++
+		shard.stats.Misses++
+		return schema.UserData{}, nil
+	}
+	shard.lru.MoveToFront(element)
+	shard.stats.Hits++
+	return entry.userData, nil
+}
+
+// Set function saves the data of the user for the campaign, evicting the least recently used entry if the shard is full
+func (storage *MemoryUserStorage) Set(ctx context.Context, userData schema.UserData) error {
+	key := getKey(userData.UserID, userData.CampaignKey)
+	shard := storage.getShard(key)
+	var expiresAt time.Time
+	if storage.TTL > 0 {
+		expiresAt = storage.now().Add(storage.TTL)
+	}
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if element, ok := shard.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.userData = userData
+		entry.expiresAt = expiresAt
+		shard.lru.MoveToFront(element)
+		return nil
+	}
+	shard.entries[key] = shard.lru.PushFront(&memoryEntry{key: key, userData: userData, expiresAt: expiresAt})
+	if shard.maxEntries > 0 && shard.lru.Len() > shard.maxEntries {
+		shard.remove(shard.lru.Back())
+		shard.stats.Evictions++
+	}
+	return nil
+}
+
+// Delete function removes the data of the user for every campaign
+func (storage *MemoryUserStorage) Delete(ctx context.Context, userID string) error {
+	storage.init()
+	for _, shard := range storage.shards {
+		shard.mu.Lock()
+		for _, element := range shard.entries {
+			if element.Value.(*memoryEntry).userData.UserID == userID {
+				shard.remove(element)
+			}
+		}
+		shard.mu.Unlock()
+	}
+	return nil
+}
+
+// Stats function returns the counters of the storage summed over all shards
+func (storage *MemoryUserStorage) Stats() MemoryStats {
+	storage.init()
+	var stats MemoryStats
+	for _, shard := range storage.shards {
+		shard.mu.Lock()
+		stats.Hits += shard.stats.Hits
+		stats.Misses += shard.stats.Misses
+		stats.Evictions += shard.stats.Evictions
+		stats.Expirations += shard.stats.Expirations
+		stats.Entries += shard.lru.Len()
+		shard.mu.Unlock()
+	}
+	return stats
+}
+
+// getShard function returns the shard owning the key, the shards are created on first use
+func (storage *MemoryUserStorage) getShard(key string) *memoryShard {
+	storage.init()
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return storage.shards[hash.Sum32()%uint32(len(storage.shards))]
+}
+
+// remove function deletes the element from the shard, the shard must be locked
+func (shard *memoryShard) remove(element *list.Element) {
+	shard.lru.Remove(element)
+	delete(shard.entries, element.Value.(*memoryEntry).key)
+}
+
+// getKey function returns the key of the data of a user for a campaign
+func getKey(userID, campaignKey string) string {
+	return campaignKey + "\x00" + userID
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/core"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+	"github.com/wingify/vwo-go-sdk/pkg/utils"
+)
+
+func TestMemoryUserStorage(t *testing.T) {
+	assertOutput := assert.New(t)
+	storage := NewMemoryUserStorage(0, 0)
+	ctx := context.Background()
+
+	userData, err := storage.Get(ctx, "user", "campaign")
+	assertOutput.Nil(err)
+	assertOutput.Equal(schema.UserData{}, userData)
+
+	expected := schema.UserData{UserID: "user", CampaignKey: "campaign", VariationName: "Control", GoalIdentifier: "goal"}
+	assertOutput.Nil(storage.Set(ctx, expected))
+	userData, err = storage.Get(ctx, "user", "campaign")
+	assertOutput.Nil(err)
+	assertOutput.Equal(expected, userData)
+
+	expected.VariationName = "Variation-1"
+	assertOutput.Nil(storage.Set(ctx, expected))
+	userData, _ = storage.Get(ctx, "user", "campaign")
+	assertOutput.Equal("Variation-1", userData.VariationName)
+
+	userData, _ = storage.Get(ctx, "user", "other campaign")
+	assertOutput.Empty(userData.VariationName)
+
+	assertOutput.Equal(MemoryStats{Hits: 2, Misses: 2, Entries: 1}, storage.Stats())
+}
+
+func TestMemoryUserStorageDelete(t *testing.T) {
+	assertOutput := assert.New(t)
+	storage := NewMemoryUserStorage(0, 0)
+	ctx := context.Background()
+
+	for i := 0; i < 50; i++ {
+		campaignKey := "campaign" + strconv.Itoa(i)
+		storage.Set(ctx, schema.UserData{UserID: "user", CampaignKey: campaignKey, VariationName: "Control"})
+		storage.Set(ctx, schema.UserData{UserID: "other user", CampaignKey: campaignKey, VariationName: "Control"})
+	}
+	assertOutput.Nil(storage.Delete(ctx, "user"))
+	assertOutput.Equal(50, storage.Stats().Entries)
+	userData, _ := storage.Get(ctx, "user", "campaign0")
+	assertOutput.Empty(userData.VariationName)
+	userData, _ = storage.Get(ctx, "other user", "campaign0")
+	assertOutput.Equal("Control", userData.VariationName)
+}
+
+func TestMemoryUserStorageEviction(t *testing.T) {
+	assertOutput := assert.New(t)
+	storage := NewMemoryUserStorage(constants.MemoryStorageShards, 0)
+	ctx := context.Background()
+
+	for i := 0; i < 1000; i++ {
+		storage.Set(ctx, schema.UserData{UserID: strconv.Itoa(i), CampaignKey: "campaign", VariationName: "Control"})
+	}
+	stats := storage.Stats()
+	assertOutput.True(stats.Entries <= constants.MemoryStorageShards)
+	assertOutput.Equal(int64(1000-stats.Entries), stats.Evictions)
+
+	// the last user saved in a shard is its most recently used entry
+	userData, _ := storage.Get(ctx, "999", "campaign")
+	assertOutput.Equal("Control", userData.VariationName)
+	userData, _ = storage.Get(ctx, "0", "campaign")
+	assertOutput.Empty(userData.VariationName)
+}
+
+func TestMemoryUserStorageZeroValue(t *testing.T) {
+	assertOutput := assert.New(t)
+	ctx := context.Background()
+
+	storage := &MemoryUserStorage{}
+	assertOutput.Nil(storage.Set(ctx, schema.UserData{UserID: "user", CampaignKey: "campaign", VariationName: "Control"}))
+	userData, _ := storage.Get(ctx, "user", "campaign")
+	assertOutput.Equal("Control", userData.VariationName)
+	assertOutput.Nil(storage.Delete(ctx, "user"))
+	assertOutput.Equal(0, storage.Stats().Entries)
+
+	storage = &MemoryUserStorage{MaxEntries: constants.MemoryStorageShards}
+	for i := 0; i < 1000; i++ {
+		storage.Set(ctx, schema.UserData{UserID: strconv.Itoa(i), CampaignKey: "campaign", VariationName: "Control"})
+	}
+	assertOutput.True(storage.Stats().Entries <= constants.MemoryStorageShards, "MaxEntries should be honoured without the constructor")
+}
+
+func TestMemoryUserStorageLeastRecentlyUsed(t *testing.T) {
+	assertOutput := assert.New(t)
+	storage := NewMemoryUserStorage(2*constants.MemoryStorageShards, 0)
+	ctx := context.Background()
+
+	// find three users of the same shard
+	var userIDs []string
+	shard := storage.getShard(getKey("0", "campaign"))
+	for i := 0; len(userIDs) < 3; i++ {
+		if storage.getShard(getKey(strconv.Itoa(i), "campaign")) == shard {
+			userIDs = append(userIDs, strconv.Itoa(i))
+		}
+	}
+
+	storage.Set(ctx, schema.UserData{UserID: userIDs[0], CampaignKey: "campaign", VariationName: "Control"})
+	storage.Set(ctx, schema.UserData{UserID: userIDs[1], CampaignKey: "campaign", VariationName: "Control"})
+	storage.Get(ctx, userIDs[0], "campaign")
+	storage.Set(ctx, schema.UserData{UserID: userIDs[2], CampaignKey: "campaign", VariationName: "Control"})
+
+	userData, _ := storage.Get(ctx, userIDs[0], "campaign")
+	assertOutput.Equal("Control", userData.VariationName, "Recently read entry should be kept")
+	userData, _ = storage.Get(ctx, userIDs[1], "campaign")
+	assertOutput.Empty(userData.VariationName, "Least recently used entry should be evicted")
+	assertOutput.Equal(int64(1), storage.Stats().Evictions)
+}
+
+func TestMemoryUserStorageTTL(t *testing.T) {
+	assertOutput := assert.New(t)
+	storage := NewMemoryUserStorage(0, time.Hour)
+	currentTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	storage.now = func() time.Time { return currentTime }
+	ctx := context.Background()
+
+	storage.Set(ctx, schema.UserData{UserID: "user", CampaignKey: "campaign", VariationName: "Control"})
+	currentTime = currentTime.Add(59 * time.Minute)
+	userData, _ := storage.Get(ctx, "user", "campaign")
+	assertOutput.Equal("Control", userData.VariationName)
+
+	currentTime = currentTime.Add(time.Minute)
+	userData, _ = storage.Get(ctx, "user", "campaign")
+	assertOutput.Empty(userData.VariationName)
+	assertOutput.Equal(MemoryStats{Hits: 1, Misses: 1, Expirations: 1}, storage.Stats())
+}
+
+func TestMemoryUserStorageConcurrency(t *testing.T) {
+	assertOutput := assert.New(t)
+	storage := NewMemoryUserStorage(500, time.Minute)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				userID := strconv.Itoa(worker*1000 + j)
+				storage.Set(ctx, schema.UserData{UserID: userID, CampaignKey: "campaign", VariationName: "Control"})
+				storage.Get(ctx, userID, "campaign")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := storage.Stats()
+	assertOutput.Equal(int64(8000), stats.Hits+stats.Misses)
+	assertOutput.Equal(int64(8000), stats.Evictions+int64(stats.Entries))
+}
+
+func TestMemoryUserStorageWithGetVariation(t *testing.T) {
+	assertOutput := assert.New(t)
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_100_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	campaign.Variations = utils.GetVariationAllocationRanges(vwoInstance, campaign.Variations)
+	storage := NewMemoryUserStorage(100, 0)
+	vwoInstance.UserStorage = storage
+
+	variation, _, err := core.GetVariation(vwoInstance, testdata.ValidUser, campaign, "", schema.Options{})
+	assertOutput.Nil(err)
+	variationName, _ := core.GetVariationFromUserStorage(vwoInstance, testdata.ValidUser, campaign, schema.Options{})
+	assertOutput.Equal(variation.Name, variationName)
+}
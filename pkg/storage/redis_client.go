@@ -0,0 +1,303 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+)
+
+// RedisClient interface sends a command to Redis and returns its reply, replies are nil, string, int64 or []interface{},
+// an error reply is returned as a RedisError. Any Redis client library can be wrapped to implement it
+type RedisClient interface {
+	Do(ctx context.Context, args ...interface{}) (interface{}, error)
+}
+
+// RedisPipeliner interface is optionally implemented by a RedisClient able to send several commands in a single round trip,
+// the reply or RedisError of every command is returned in order
+type RedisPipeliner interface {
+	Pipeline(ctx context.Context, commands [][]interface{}) ([]interface{}, error)
+}
+
+// RedisError is an error reply of Redis
+type RedisError string
+
+// Error function returns the error message of Redis
+func (err RedisError) Error() string {
+	return string(err)
+}
+
+// RedisClientConfig struct configures the connections of a RESPClient, IOTimeout bounds the commands sent with a context
+// without deadline
+type RedisClientConfig struct {
+	Address     string
+	Password    string
+	DB          int
+	PoolSize    int
+	DialTimeout time.Duration
+	IOTimeout   time.Duration
+}
+
+// SetDefaults sets the default value of every field which is not set
+func (config *RedisClientConfig) SetDefaults() {
+	if config.Address == "" {
+		config.Address = constants.RedisDefaultAddress
+	}
+	if config.PoolSize < 1 {
+		config.PoolSize = constants.RedisDefaultPoolSize
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = constants.RedisDefaultDialTimeout * time.Second
+	}
+	if config.IOTimeout <= 0 {
+		config.IOTimeout = constants.RedisDefaultIOTimeout * time.Second
+	}
+}
+
+// RESPClient is a minimal RedisClient speaking the Redis protocol over TCP, it keeps up to PoolSize idle connections
+type RESPClient struct {
+	Config RedisClientConfig
+
+	idle chan *redisConn
+}
+
+// redisConn struct is a connection to Redis with its buffered reader
+type redisConn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	ioTimeout time.Duration
+}
+
+// NewRESPClient returns a client connecting to Redis as per the config, connections are opened on demand
+func NewRESPClient(config RedisClientConfig) *RESPClient {
+	config.SetDefaults()
+	return &RESPClient{
+		Config: config,
+		idle:   make(chan *redisConn, config.PoolSize),
+	}
+}
+
+// Do function sends the command and returns its reply, the deadline of the context, or the IOTimeout if it has none,
+// applies to the whole command
+func (client *RESPClient) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	conn, err := client.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(ctx, args...)
+	if _, ok := err.(RedisError); err != nil && !ok {
+		// the state of the connection is unknown after a network error
+		conn.conn.Close()
+		return nil, err
+	}
+	client.putConn(conn)
+	return reply, err
+}
+
+// Pipeline function sends all the commands before reading their replies, an error reply is returned as a RedisError in the replies
+func (client *RESPClient) Pipeline(ctx context.Context, commands [][]interface{}) ([]interface{}, error) {
+	conn, err := client.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := conn.pipeline(ctx, commands)
+	if err != nil {
+		conn.conn.Close()
+		return nil, err
+	}
+	client.putConn(conn)
+	return replies, nil
+}
+
+// Close function closes the idle connections
+func (client *RESPClient) Close() error {
+	for {
+		select {
+		case conn := <-client.idle:
+			conn.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// getConn function returns an idle connection or opens a new one, authenticated and with the DB selected
+func (client *RESPClient) getConn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-client.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: client.Config.DialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", client.Config.Address)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{conn: netConn, reader: bufio.NewReader(netConn), ioTimeout: client.Config.IOTimeout}
+	if client.Config.Password != "" {
+		if _, err := conn.do(ctx, "AUTH", client.Config.Password); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	if client.Config.DB != 0 {
+		if _, err := conn.do(ctx, "SELECT", client.Config.DB); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// putConn function keeps the connection idle, or closes it if the pool is full
+func (client *RESPClient) putConn(conn *redisConn) {
+	select {
+	case client.idle <- conn:
+	default:
+		conn.conn.Close()
+	}
+}
+
+// do function writes the command and reads the reply
+func (conn *redisConn) do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	if err := conn.conn.SetDeadline(conn.getDeadline(ctx)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.conn.Write(encodeCommand(nil, args)); err != nil {
+		return nil, err
+	}
+	return readReply(conn.reader)
+}
+
+// pipeline function writes all the commands and reads their replies
+func (conn *redisConn) pipeline(ctx context.Context, commands [][]interface{}) ([]interface{}, error) {
+	if err := conn.conn.SetDeadline(conn.getDeadline(ctx)); err != nil {
+		return nil, err
+	}
+	var buffer []byte
+	for _, args := range commands {
+		buffer = encodeCommand(buffer, args)
+	}
+	if _, err := conn.conn.Write(buffer); err != nil {
+		return nil, err
+	}
+
+	replies := make([]interface{}, len(commands))
+	for i := range replies {
+		reply, err := readReply(conn.reader)
+		if _, ok := err.(RedisError); err != nil && !ok {
+			return nil, err
+		}
+		if err != nil {
+			reply = err
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+// getDeadline function returns the deadline of the context, or the end of the IOTimeout if it has none
+func (conn *redisConn) getDeadline(ctx context.Context) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline
+	}
+	return time.Now().Add(conn.ioTimeout)
+}
+
+// encodeCommand function appends the command encoded as an array of bulk strings to the buffer
+func encodeCommand(buffer []byte, args []interface{}) []byte {
+	buffer = append(buffer, "*"+strconv.Itoa(len(args))+"\r\n"...)
+	for _, arg := range args {
+		var value string
+		switch typedArg := arg.(type) {
+		case string:
+			value = typedArg
+		case []byte:
+			value = string(typedArg)
+		case int:
+			value = strconv.Itoa(typedArg)
+		case int64:
+			value = strconv.FormatInt(typedArg, 10)
+		default:
+			value = fmt.Sprint(typedArg)
+		}
+		buffer = append(buffer, "$"+strconv.Itoa(len(value))+"\r\n"+value+"\r\n"...)
+	}
+	return buffer
+}
+
+// readReply function reads a reply of the Redis protocol
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New(constants.ErrorMessageRedisInvalidReply)
+	}
+	payload := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, RedisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		length, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		value := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		return string(value[:length]), nil
+	case '*':
+		length, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		values := make([]interface{}, length)
+		for i := range values {
+			if values[i], err = readReply(reader); err != nil {
+				if _, ok := err.(RedisError); !ok {
+					return nil, err
+				}
+				values[i] = err
+			}
+		}
+		return values, nil
+	}
+	return nil, errors.New(constants.ErrorMessageRedisInvalidReply)
+}
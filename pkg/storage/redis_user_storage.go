@@ -0,0 +1,266 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+)
+
+// RedisUserStorage is a user storage keeping the assignments of every user in a single Redis hash, namespaced by the account
+// ID and SDK key of the settings file, so instances of different accounts and environments can share a Redis. Every
+// command touches the key of a single user, so the storage works with Redis Cluster too
+type RedisUserStorage struct {
+	Client    RedisClient
+	Namespace string
+	TTL       time.Duration
+}
+
+// NewRedisUserStorage returns a storage keeping the assignments of the settings file account in Redis,
+// the assignments of a user expire ttl after the last one was saved, or never if ttl is 0
+func NewRedisUserStorage(client RedisClient, settingsFile schema.SettingsFile, ttl time.Duration) *RedisUserStorage {
+	return &RedisUserStorage{
+		Client:    client,
+		Namespace: strings.Join([]string{constants.RedisKeyPrefix, strconv.Itoa(settingsFile.AccountID), settingsFile.SDKKey}, ":"),
+		TTL:       ttl,
+	}
+}
+
+// Get function returns the stored data of the user for the campaign, or an empty UserData if none is stored
+func (storage *RedisUserStorage) Get(ctx context.Context, userID, campaignKey string) (schema.UserData, error) {
+	args := []interface{}{"HMGET", storage.getKey(userID)}
+	for _, name := range userDataFields {
+		args = append(args, getField(campaignKey, name))
+	}
+	reply, err := storage.Client.Do(ctx, args...)
+	if err != nil {
+		return schema.UserData{}, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != len(userDataFields) {
+		return schema.UserData{}, fmt.Errorf(constants.ErrorMessageRedisUnexpectedReply, reply, "HMGET")
+	}
+	fields := make(map[string]string)
+	for i, value := range values {
+		if value, ok := value.(string); ok {
+			fields[userDataFields[i]] = value
+		}
+	}
+	if len(fields) == 0 {
+		return schema.UserData{}, nil
+	}
+	return parseUserData(userID, campaignKey, fields), nil
+}
+
+// Set function saves the data of the user for the campaign and sets the expiry of the user at once
+func (storage *RedisUserStorage) Set(ctx context.Context, userData schema.UserData) error {
+	return storage.SetMany(ctx, []schema.UserData{userData})
+}
+
+// Delete function removes the data of the user for every campaign
+func (storage *RedisUserStorage) Delete(ctx context.Context, userID string) error {
+	_, err := storage.Client.Do(ctx, "DEL", storage.getKey(userID))
+	return err
+}
+
+// GetMany function returns the stored data of the user for every campaign having some, in a single command
+func (storage *RedisUserStorage) GetMany(ctx context.Context, userID string, campaignKeys []string) (map[string]schema.UserData, error) {
+	reply, err := storage.Client.Do(ctx, "HGETALL", storage.getKey(userID))
+	if err != nil {
+		return nil, err
+	}
+	hash, err := parseHash(reply, "HGETALL")
+	if err != nil {
+		return nil, err
+	}
+
+	userDatas := make(map[string]schema.UserData)
+	for _, campaignKey := range campaignKeys {
+		fields := make(map[string]string)
+		for _, name := range userDataFields {
+			if value, ok := hash[getField(campaignKey, name)]; ok {
+				fields[name] = value
+			}
+		}
+		if len(fields) > 0 {
+			userDatas[campaignKey] = parseUserData(userID, campaignKey, fields)
+		}
+	}
+	return userDatas, nil
+}
+
+// SetMany function saves all the data and sets the expiry of their users, every user is saved in a MULTI/EXEC
+// transaction so that its hash never stays without expiry. The transactions are sent in a single round trip if the
+// client implements RedisPipeliner, a client which does not is sent the commands one by one without transaction
+func (storage *RedisUserStorage) SetMany(ctx context.Context, userDatas []schema.UserData) error {
+	var keys []string
+	commandsByKey := make(map[string][][]interface{})
+	for _, userData := range userDatas {
+		key := storage.getKey(userData.UserID)
+		if _, ok := commandsByKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		args := []interface{}{"HSET", key}
+		for _, field := range getHashFields(userData) {
+			args = append(args, getField(userData.CampaignKey, field[0]), field[1])
+		}
+		commandsByKey[key] = append(commandsByKey[key], args)
+	}
+
+	_, isPipeliner := storage.Client.(RedisPipeliner)
+	var commands [][]interface{}
+	var execs []int
+	for _, key := range keys {
+		keyCommands := commandsByKey[key]
+		if storage.TTL > 0 {
+			keyCommands = append(keyCommands, []interface{}{"PEXPIRE", key, int64(storage.TTL / time.Millisecond)})
+		}
+		transaction := isPipeliner && len(keyCommands) > 1
+		if transaction {
+			commands = append(commands, []interface{}{"MULTI"})
+		}
+		commands = append(commands, keyCommands...)
+		if transaction {
+			execs = append(execs, len(commands))
+			commands = append(commands, []interface{}{"EXEC"})
+		}
+	}
+	replies, err := storage.doAll(ctx, commands)
+	if err != nil {
+		return err
+	}
+	for _, exec := range execs {
+		results, ok := replies[exec].([]interface{})
+		if !ok {
+			return fmt.Errorf(constants.ErrorMessageRedisUnexpectedReply, replies[exec], "EXEC")
+		}
+		for _, result := range results {
+			if err, ok := result.(RedisError); ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// doAll function sends the commands through a pipeline if the client supports it, or one by one,
+// the first error reply is returned as error
+func (storage *RedisUserStorage) doAll(ctx context.Context, commands [][]interface{}) ([]interface{}, error) {
+	if pipeliner, ok := storage.Client.(RedisPipeliner); ok {
+		replies, err := pipeliner.Pipeline(ctx, commands)
+		if err != nil {
+			return nil, err
+		}
+		for _, reply := range replies {
+			if err, ok := reply.(RedisError); ok {
+				return nil, err
+			}
+		}
+		return replies, nil
+	}
+
+	replies := make([]interface{}, len(commands))
+	for i, args := range commands {
+		reply, err := storage.Client.Do(ctx, args...)
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+// getKey function returns the Redis key of the hash of a user, the user ID is escaped so that
+// the key of a user ID containing the separator cannot be the key of another one
+func (storage *RedisUserStorage) getKey(userID string) string {
+	return storage.Namespace + ":" + escapeKey(userID)
+}
+
+// getField function returns the field of the user hash keeping a field of the data for a campaign,
+// the campaign key is escaped so that the field of a campaign key containing the separator cannot be another one
+func getField(campaignKey, name string) string {
+	return escapeKey(campaignKey) + ":" + name
+}
+
+// keyReplacer escapes the separator of the key components, and the escape character itself
+var keyReplacer = strings.NewReplacer("%", "%25", ":", "%3A")
+
+// escapeKey function escapes a component of a Redis key so that it contains no separator,
+// the components without separator nor escape character are kept as they are
+func escapeKey(value string) string {
+	return keyReplacer.Replace(value)
+}
+
+// userDataFields are the names of the fields kept for the data of a campaign
+var userDataFields = []string{"variationName", "goalIdentifier", "campaignId", "variationId", "sdkVersion", "assignedAt"}
+
+// getHashFields function returns the names and values of the fields of the data
+func getHashFields(userData schema.UserData) [][2]string {
+	fields := [][2]string{
+		{"variationName", userData.VariationName},
+		{"goalIdentifier", userData.GoalIdentifier},
+		{"campaignId", strconv.Itoa(userData.CampaignID)},
+		{"variationId", strconv.Itoa(userData.VariationID)},
+		{"sdkVersion", userData.SDKVersion},
+	}
+	if !userData.AssignedAt.IsZero() {
+		fields = append(fields, [2]string{"assignedAt", userData.AssignedAt.UTC().Format(time.RFC3339Nano)})
+	}
+	return fields
+}
+
+// parseUserData function returns the data of the hash fields, the fields stored by previous versions may be missing
+func parseUserData(userID, campaignKey string, fields map[string]string) schema.UserData {
+	userData := schema.UserData{
+		UserID:         userID,
+		CampaignKey:    campaignKey,
+		VariationName:  fields["variationName"],
+		GoalIdentifier: fields["goalIdentifier"],
+		SDKVersion:     fields["sdkVersion"],
+	}
+	userData.CampaignID, _ = strconv.Atoi(fields["campaignId"])
+	userData.VariationID, _ = strconv.Atoi(fields["variationId"])
+	userData.AssignedAt, _ = time.Parse(time.RFC3339Nano, fields["assignedAt"])
+	return userData
+}
+
+// parseHash function converts the flat field and value array replied for a hash to a map
+func parseHash(reply interface{}, command string) (map[string]string, error) {
+	if reply == nil {
+		return nil, nil
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values)%2 != 0 {
+		return nil, fmt.Errorf(constants.ErrorMessageRedisUnexpectedReply, reply, command)
+	}
+	fields := make(map[string]string, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		field, okField := values[i].(string)
+		value, okValue := values[i+1].(string)
+		if !okField || !okValue {
+			return nil, fmt.Errorf(constants.ErrorMessageRedisUnexpectedReply, reply, command)
+		}
+		fields[field] = value
+	}
+	return fields, nil
+}
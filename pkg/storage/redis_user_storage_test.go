@@ -0,0 +1,349 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/mocks"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+)
+
+func getRedisServer(t *testing.T) *mocks.MockRedisServer {
+	server, err := mocks.NewMockRedisServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func TestRedisUserStorage(t *testing.T) {
+	assertOutput := assert.New(t)
+	server := getRedisServer(t)
+	defer server.Close()
+	client := NewRESPClient(RedisClientConfig{Address: server.Address()})
+	defer client.Close()
+	ctx := context.Background()
+
+	settingsFile := schema.SettingsFile{AccountID: 12345, SDKKey: "sdkKey"}
+	storage := NewRedisUserStorage(client, settingsFile, 0)
+	assertOutput.Equal("vwo:12345:sdkKey", storage.Namespace)
+
+	userData, err := storage.Get(ctx, "user", "campaign")
+	assertOutput.Nil(err)
+	assertOutput.Equal(schema.UserData{}, userData)
+
+	expected := schema.UserData{UserID: "user", CampaignKey: "campaign", VariationName: "Control", GoalIdentifier: "goal"}
+	assertOutput.Nil(storage.Set(ctx, expected))
+	userData, err = storage.Get(ctx, "user", "campaign")
+	assertOutput.Nil(err)
+	assertOutput.Equal(expected, userData)
+	assertOutput.Equal([]string{"vwo:12345:sdkKey:user"}, server.Keys())
+	assertOutput.Equal(time.Duration(0), server.TTL("vwo:12345:sdkKey:user"))
+
+	// the campaigns of a user are kept in the same hash
+	other := schema.UserData{UserID: "user", CampaignKey: "other campaign", VariationName: "Variation-1"}
+	assertOutput.Nil(storage.Set(ctx, other))
+	userData, _ = storage.Get(ctx, "user", "campaign")
+	assertOutput.Equal(expected, userData)
+	userData, _ = storage.Get(ctx, "user", "other campaign")
+	assertOutput.Equal(other, userData)
+	assertOutput.Len(server.Keys(), 1)
+
+	// the same user in another environment has its own assignment
+	otherStorage := NewRedisUserStorage(client, schema.SettingsFile{AccountID: 12345, SDKKey: "otherSDKKey"}, 0)
+	userData, _ = otherStorage.Get(ctx, "user", "campaign")
+	assertOutput.Empty(userData.VariationName)
+}
+
+func TestRedisUserStorageTTL(t *testing.T) {
+	assertOutput := assert.New(t)
+	server := getRedisServer(t)
+	defer server.Close()
+	client := NewRESPClient(RedisClientConfig{Address: server.Address()})
+	defer client.Close()
+	ctx := context.Background()
+
+	storage := NewRedisUserStorage(client, schema.SettingsFile{AccountID: 1, SDKKey: "sdkKey"}, time.Hour)
+	assertOutput.Nil(storage.Set(ctx, schema.UserData{UserID: "user", CampaignKey: "campaign", VariationName: "Control"}))
+	ttl := server.TTL("vwo:1:sdkKey:user")
+	assertOutput.True(ttl > 59*time.Minute && ttl <= time.Hour)
+	assertOutput.Equal([]string{"MULTI", "HSET", "PEXPIRE", "EXEC"}, server.Commands(), "The expiry should be set in the same transaction")
+
+	storage.TTL = 50 * time.Millisecond
+	assertOutput.Nil(storage.Set(ctx, schema.UserData{UserID: "user", CampaignKey: "campaign", VariationName: "Control"}))
+	time.Sleep(100 * time.Millisecond)
+	userData, err := storage.Get(ctx, "user", "campaign")
+	assertOutput.Nil(err)
+	assertOutput.Empty(userData.VariationName)
+}
+
+func TestRedisUserStorageErrors(t *testing.T) {
+	assertOutput := assert.New(t)
+	server := getRedisServer(t)
+	server.SetPassword("secret")
+	defer server.Close()
+	ctx := context.Background()
+
+	client := NewRESPClient(RedisClientConfig{Address: server.Address(), Password: "wrong"})
+	storage := NewRedisUserStorage(client, schema.SettingsFile{AccountID: 1, SDKKey: "sdkKey"}, 0)
+	_, err := storage.Get(ctx, "user", "campaign")
+	assertOutput.IsType(RedisError(""), err)
+
+	client = NewRESPClient(RedisClientConfig{Address: server.Address(), Password: "secret", DB: 2})
+	defer client.Close()
+	storage.Client = client
+	assertOutput.Nil(storage.Set(ctx, schema.UserData{UserID: "user", CampaignKey: "campaign", VariationName: "Control"}))
+
+	_, err = client.Do(ctx, "UNKNOWN")
+	assertOutput.Equal(RedisError("ERR unknown command 'UNKNOWN'"), err)
+	reply, err := client.Do(ctx, "PING")
+	assertOutput.Nil(err, "Connection should be reused after an error reply")
+	assertOutput.Equal("PONG", reply)
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	client.Close()
+	_, err = storage.Get(canceledCtx, "user", "campaign")
+	assertOutput.NotNil(err)
+
+	server.Close()
+	_, err = NewRedisUserStorage(NewRESPClient(RedisClientConfig{Address: server.Address()}), schema.SettingsFile{}, 0).Get(ctx, "user", "campaign")
+	assertOutput.NotNil(err)
+}
+
+func TestRedisUserStorageDelete(t *testing.T) {
+	assertOutput := assert.New(t)
+	server := getRedisServer(t)
+	defer server.Close()
+	client := NewRESPClient(RedisClientConfig{Address: server.Address()})
+	defer client.Close()
+	ctx := context.Background()
+
+	storage := NewRedisUserStorage(client, schema.SettingsFile{AccountID: 12345, SDKKey: "sdkKey"}, 0)
+	otherStorage := NewRedisUserStorage(client, schema.SettingsFile{AccountID: 12345, SDKKey: "otherSDKKey"}, 0)
+	for i := 0; i < 150; i++ {
+		campaignKey := "campaign" + strconv.Itoa(i)
+		storage.Set(ctx, schema.UserData{UserID: "user*", CampaignKey: campaignKey, VariationName: "Control"})
+		storage.Set(ctx, schema.UserData{UserID: "other user*", CampaignKey: campaignKey, VariationName: "Control"})
+	}
+	storage.Set(ctx, schema.UserData{UserID: "user", CampaignKey: "campaign0", VariationName: "Control"})
+	otherStorage.Set(ctx, schema.UserData{UserID: "user*", CampaignKey: "campaign0", VariationName: "Control"})
+
+	commands := len(server.Commands())
+	assertOutput.Nil(storage.Delete(ctx, "user*"))
+	assertOutput.Equal([]string{"DEL"}, server.Commands()[commands:], "The keys of the user should not be scanned")
+	assertOutput.Equal([]string{"vwo:12345:otherSDKKey:user*", "vwo:12345:sdkKey:other user*", "vwo:12345:sdkKey:user"}, server.Keys(),
+		"Keys of the other users and environments should be kept")
+	userData, _ := storage.Get(ctx, "user*", "campaign1")
+	assertOutput.Empty(userData.VariationName)
+	userData, _ = storage.Get(ctx, "other user*", "campaign1")
+	assertOutput.Equal("Control", userData.VariationName)
+	userData, _ = storage.Get(ctx, "user", "campaign0")
+	assertOutput.Equal("Control", userData.VariationName)
+	userData, _ = otherStorage.Get(ctx, "user*", "campaign0")
+	assertOutput.Equal("Control", userData.VariationName)
+}
+
+func TestRedisUserStorageKeysWithSeparator(t *testing.T) {
+	assertOutput := assert.New(t)
+	server := getRedisServer(t)
+	defer server.Close()
+	client := NewRESPClient(RedisClientConfig{Address: server.Address()})
+	defer client.Close()
+	ctx := context.Background()
+
+	storage := NewRedisUserStorage(client, schema.SettingsFile{AccountID: 12345, SDKKey: "sdkKey"}, 0)
+	storage.Set(ctx, schema.UserData{UserID: "b:c", CampaignKey: "a", VariationName: "Control"})
+	storage.Set(ctx, schema.UserData{UserID: "c", CampaignKey: "a:b", VariationName: "Variation-1"})
+	storage.Set(ctx, schema.UserData{UserID: "x:user", CampaignKey: "campaign", VariationName: "Control"})
+	storage.Set(ctx, schema.UserData{UserID: "user", CampaignKey: "campaign", VariationName: "Variation-1"})
+	storage.Set(ctx, schema.UserData{UserID: "user%3A", CampaignKey: "campaign", VariationName: "Control"})
+	assertOutput.Len(server.Keys(), 5, "Keys of IDs containing the separator should not collide")
+
+	userData, _ := storage.Get(ctx, "b:c", "a")
+	assertOutput.Equal("Control", userData.VariationName)
+	userData, _ = storage.Get(ctx, "c", "a:b")
+	assertOutput.Equal("Variation-1", userData.VariationName)
+
+	assertOutput.Nil(storage.Delete(ctx, "user"))
+	userData, _ = storage.Get(ctx, "user", "campaign")
+	assertOutput.Empty(userData.VariationName)
+	userData, _ = storage.Get(ctx, "x:user", "campaign")
+	assertOutput.Equal("Control", userData.VariationName, "Keys of users whose ID ends with the deleted one should be kept")
+	userData, _ = storage.Get(ctx, "user%3A", "campaign")
+	assertOutput.Equal("Control", userData.VariationName)
+
+	assertOutput.Nil(storage.Delete(ctx, "x:user"))
+	userData, _ = storage.Get(ctx, "x:user", "campaign")
+	assertOutput.Empty(userData.VariationName)
+	assertOutput.Len(server.Keys(), 3)
+}
+
+func TestRESPClientConcurrency(t *testing.T) {
+	assertOutput := assert.New(t)
+	server := getRedisServer(t)
+	defer server.Close()
+	client := NewRESPClient(RedisClientConfig{Address: server.Address(), PoolSize: 2})
+	defer client.Close()
+	storage := NewRedisUserStorage(client, schema.SettingsFile{AccountID: 1, SDKKey: "sdkKey"}, 0)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				userID := strconv.Itoa(worker*100 + j)
+				assertOutput.Nil(storage.Set(ctx, schema.UserData{UserID: userID, CampaignKey: "campaign", VariationName: "Variation-" + userID}))
+				userData, err := storage.Get(ctx, userID, "campaign")
+				assertOutput.Nil(err)
+				assertOutput.Equal("Variation-"+userID, userData.VariationName)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assertOutput.Len(server.Keys(), 400)
+}
+
+func TestReadReply(t *testing.T) {
+	assertOutput := assert.New(t)
+	testCases := []struct {
+		reply    string
+		expected interface{}
+	}{
+		{"+OK\r\n", "OK"},
+		{":42\r\n", int64(42)},
+		{"$5\r\nhello\r\n", "hello"},
+		{"$0\r\n\r\n", ""},
+		{"$-1\r\n", nil},
+		{"*-1\r\n", nil},
+		{"*3\r\n$1\r\na\r\n:1\r\n*1\r\n+b\r\n", []interface{}{"a", int64(1), []interface{}{"b"}}},
+		{"*2\r\n-ERR failed\r\n+OK\r\n", []interface{}{RedisError("ERR failed"), "OK"}},
+	}
+	for _, testCase := range testCases {
+		reply, err := readReply(bufio.NewReader(strings.NewReader(testCase.reply)))
+		assertOutput.Nil(err, testCase.reply)
+		assertOutput.Equal(testCase.expected, reply, testCase.reply)
+	}
+
+	_, err := readReply(bufio.NewReader(strings.NewReader("-ERR failed\r\n")))
+	assertOutput.Equal(RedisError("ERR failed"), err)
+	_, err = readReply(bufio.NewReader(strings.NewReader("?\r\n")))
+	assertOutput.NotNil(err)
+	_, err = readReply(bufio.NewReader(strings.NewReader("$5\r\nhel")))
+	assertOutput.NotNil(err)
+}
+
+type unpipelinedClient struct {
+	client *RESPClient
+}
+
+func (client unpipelinedClient) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	return client.client.Do(ctx, args...)
+}
+
+func TestRedisUserStorageMany(t *testing.T) {
+	assertOutput := assert.New(t)
+	server := getRedisServer(t)
+	defer server.Close()
+	client := NewRESPClient(RedisClientConfig{Address: server.Address()})
+	defer client.Close()
+	ctx := context.Background()
+
+	userDatas := []schema.UserData{
+		{UserID: "user", CampaignKey: "first", VariationName: "Control"},
+		{UserID: "user", CampaignKey: "second", VariationName: "Variation-1", GoalIdentifier: "goal"},
+	}
+	for _, redisClient := range []RedisClient{client, unpipelinedClient{client}} {
+		storage := NewRedisUserStorage(redisClient, schema.SettingsFile{AccountID: 1, SDKKey: "sdkKey"}, time.Hour)
+		assertOutput.Nil(storage.SetMany(ctx, userDatas))
+		assertOutput.True(server.TTL("vwo:1:sdkKey:user") > 59*time.Minute)
+
+		stored, err := storage.GetMany(ctx, "user", []string{"first", "second", "third"})
+		assertOutput.Nil(err)
+		assertOutput.Equal(map[string]schema.UserData{"first": userDatas[0], "second": userDatas[1]}, stored)
+	}
+
+	replies, err := client.Pipeline(ctx, [][]interface{}{{"PING"}, {"UNKNOWN"}, {"PING"}})
+	assertOutput.Nil(err)
+	assertOutput.Equal([]interface{}{"PONG", RedisError("ERR unknown command 'UNKNOWN'"), "PONG"}, replies)
+
+	storage := NewRedisUserStorage(client, schema.SettingsFile{}, 0)
+	_, err = storage.doAll(ctx, [][]interface{}{{"PING"}, {"UNKNOWN"}})
+	assertOutput.Equal(RedisError("ERR unknown command 'UNKNOWN'"), err)
+}
+
+func TestRedisUserStorageSetManyTransactions(t *testing.T) {
+	assertOutput := assert.New(t)
+	server := getRedisServer(t)
+	defer server.Close()
+	client := NewRESPClient(RedisClientConfig{Address: server.Address()})
+	defer client.Close()
+	ctx := context.Background()
+
+	storage := NewRedisUserStorage(client, schema.SettingsFile{AccountID: 1, SDKKey: "sdkKey"}, time.Hour)
+	assertOutput.Nil(storage.SetMany(ctx, []schema.UserData{
+		{UserID: "first", CampaignKey: "campaign", VariationName: "Control"},
+		{UserID: "second", CampaignKey: "campaign", VariationName: "Control"},
+		{UserID: "first", CampaignKey: "other campaign", VariationName: "Variation-1"},
+	}))
+	assertOutput.Equal([]string{"MULTI", "HSET", "HSET", "PEXPIRE", "EXEC", "MULTI", "HSET", "PEXPIRE", "EXEC"}, server.Commands(),
+		"Every user should be saved in its own transaction")
+	assertOutput.True(server.TTL("vwo:1:sdkKey:second") > 59*time.Minute)
+}
+
+func TestRESPClientIOTimeout(t *testing.T) {
+	assertOutput := assert.New(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	// the server accepts the connections and never replies
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	client := NewRESPClient(RedisClientConfig{Address: listener.Addr().String(), IOTimeout: 50 * time.Millisecond})
+	defer client.Close()
+	start := time.Now()
+	_, err = client.Do(context.Background(), "PING")
+	assertOutput.NotNil(err)
+	assertOutput.True(time.Since(start) < time.Second, "Command should time out without a context deadline")
+	_, err = client.Pipeline(context.Background(), [][]interface{}{{"PING"}})
+	assertOutput.NotNil(err)
+
+	config := RedisClientConfig{}
+	config.SetDefaults()
+	assertOutput.Equal(5*time.Second, config.IOTimeout)
+}
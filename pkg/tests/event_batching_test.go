@@ -18,16 +18,25 @@ package tests
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/wingify/vwo-go-sdk/pkg/api"
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
 	"github.com/wingify/vwo-go-sdk/pkg/mocks"
 	"github.com/wingify/vwo-go-sdk/pkg/request"
 	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/storage"
 	"github.com/wingify/vwo-go-sdk/pkg/testdata"
 	"github.com/wingify/vwo-go-sdk/pkg/utils"
 )
@@ -46,7 +55,7 @@ func GetVWOInstance(batchSize int, batchInterval int) api.VWOInstance {
 
 	instance.IsBatchingEnabled = true
 
-	instance.BatchEventQueue = schema.BatchEventQueue{
+	instance.BatchEventQueue = &schema.BatchEventQueue{
 		RequestTimeInterval: batchInterval,
 		EventsPerRequest:    batchSize,
 	}
@@ -111,3 +120,543 @@ func TestFlushQueueOnTimerExpire(t *testing.T) {
 	time.Sleep(time.Duration(batchInterval+1) * time.Second)
 	assertOutput.Nil(instance.BatchEventQueue.GetBatchImpressions())
 }
+
+func TestForgetUserRemovesQueuedEvents(t *testing.T) {
+	assertOutput := assert.New(t)
+	batchSize, batchInterval := 10, 5
+	instance := GetVWOInstance(batchSize, batchInterval)
+	instance.UserStorage = storage.NewMemoryUserStorage(0, 0)
+
+	forgottenUserID, userID, campaignKey := "forgotten user", testdata.GetRandomUser(), "AB_T_100_W_33_33_33"
+	assertOutput.NotEmpty(instance.Activate(campaignKey, forgottenUserID, nil))
+	instance.Push(testdata.ValidTagKey, testdata.ValidTagValue, forgottenUserID)
+	instance.Push(testdata.ValidTagKey, testdata.ValidTagValue, userID)
+
+	assertOutput.Nil(instance.ForgetUser(forgottenUserID))
+	impressions := instance.BatchEventQueue.GetBatchImpressions()
+	assertOutput.Len(impressions, 1)
+	assertOutput.Equal(userID, impressions[0].UID)
+	userData, _ := instance.UserStorage.(schema.UserStorage).Get(context.Background(), forgottenUserID, campaignKey)
+	assertOutput.Empty(userData.VariationName)
+	assertOutput.Equal(1, instance.BatchEventQueue.RemoveUserImpressions(userID))
+}
+
+func TestNoTrackingConsent(t *testing.T) {
+	assertOutput := assert.New(t)
+	batchSize, batchInterval := 10, 5
+	instance := GetVWOInstance(batchSize, batchInterval)
+	options := map[string]interface{}{"trackingConsent": false}
+
+	userID, campaignKey, goalIdentifier := testdata.GetRandomUser(), "AB_T_100_W_33_33_33", "GOAL_2"
+	assertOutput.NotEmpty(instance.Activate(campaignKey, userID, options), "Variation should be evaluated")
+	result := instance.Track(campaignKey, userID, goalIdentifier, options)
+	assertOutput.Len(result, 1)
+	assertOutput.False(result[0].TrackValue)
+	assertOutput.False(instance.Push(testdata.ValidTagKey, testdata.ValidTagValue, userID, options))
+	assertOutput.Empty(instance.BatchEventQueue.GetBatchImpressions())
+
+	options["trackingConsent"] = true
+	assertOutput.True(instance.Push(testdata.ValidTagKey, testdata.ValidTagValue, userID, options))
+	assertOutput.Equal(1, instance.BatchEventQueue.RemoveUserImpressions(userID))
+}
+
+func TestTrackAfterTrackingConsent(t *testing.T) {
+	assertOutput := assert.New(t)
+	instance := GetVWOInstance(10, 5)
+	instance.UserStorage = storage.NewMemoryUserStorage(0, 0)
+
+	userID, campaignKey, goalIdentifier := testdata.GetRandomUser(), "AB_T_100_W_33_33_33", "GOAL_2"
+	result := instance.Track(campaignKey, userID, goalIdentifier, map[string]interface{}{"trackingConsent": false})
+	assertOutput.False(result[0].TrackValue)
+	assertOutput.Empty(instance.BatchEventQueue.GetBatchImpressions())
+
+	result = instance.Track(campaignKey, userID, goalIdentifier, map[string]interface{}{"trackingConsent": true})
+	assertOutput.True(result[0].TrackValue, "The goal should be tracked once the user consents")
+	impressions := instance.BatchEventQueue.GetBatchImpressions()
+	assertOutput.Len(impressions, 1)
+	assertOutput.Equal(constants.EventsTrackGoal, impressions[0].EventType)
+}
+
+type failingDispatcher struct {
+	fail bool
+}
+
+func (dispatcher failingDispatcher) Dispatch(ctx context.Context, event schema.Event) error {
+	if dispatcher.fail {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func TestEventLogReplay(t *testing.T) {
+	assertOutput := assert.New(t)
+	dir, err := ioutil.TempDir("", "vwo-event-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	userID := testdata.GetRandomUser()
+
+	launch := func(dispatcher schema.EventDispatcher, flushed chan []map[string]interface{}) (*api.VWOInstance, *storage.FileEventLog) {
+		eventLog, err := storage.NewFileEventLog(dir, storage.FileEventLogConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		instance := GetVWOInstance(10, 60)
+		vwo, err := instance.Init(
+			api.WithEventDispatcher(dispatcher),
+			api.WithEventLog(eventLog),
+			api.WithBatchEventQueue(api.BatchConfig{EventsPerRequest: 10, RequestTimeInterval: 60}, func(err error, batch []map[string]interface{}) {
+				flushed <- batch
+			}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vwo, eventLog
+	}
+
+	// the queued events are persisted before the process stops
+	flushed := make(chan []map[string]interface{}, 1)
+	vwo, eventLog := launch(failingDispatcher{}, flushed)
+	for i := 0; i < 3; i++ {
+		assertOutput.True(vwo.Push(testdata.ValidTagKey, testdata.ValidTagValue, userID))
+	}
+	assertOutput.True(eventLog.Size() > 0)
+	eventLog.Close()
+
+	// the events are replayed on launch, and kept if they can not be sent
+	vwo, eventLog = launch(failingDispatcher{fail: true}, flushed)
+	assertOutput.Len(<-flushed, 3)
+	assertOutput.True(eventLog.Size() > 0, "Events not sent should be kept in the log")
+	eventLog.Close()
+
+	_, eventLog = launch(failingDispatcher{}, flushed)
+	defer eventLog.Close()
+	assertOutput.Len(<-flushed, 3)
+	assertOutput.Equal(int64(0), eventLog.Size(), "Sent events should be truncated")
+}
+
+type countingDispatcher struct {
+	mu     sync.Mutex
+	events int
+	err    error
+}
+
+func (dispatcher *countingDispatcher) Dispatch(ctx context.Context, event schema.Event) error {
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+	dispatcher.events++
+	return dispatcher.err
+}
+
+func TestCloseFlushesBatchedEvents(t *testing.T) {
+	assertOutput := assert.New(t)
+	userID := testdata.GetRandomUser()
+	for _, dispatchErr := range []error{nil, errors.New("unavailable")} {
+		dispatcher := &countingDispatcher{err: dispatchErr}
+		instance := GetVWOInstance(10, 60)
+		vwo, err := instance.Init(api.WithEventDispatcher(dispatcher), api.WithBatchEventQueue(api.BatchConfig{EventsPerRequest: 10, RequestTimeInterval: 60}, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			vwo.Push(testdata.ValidTagKey, testdata.ValidTagValue, userID)
+		}
+
+		err = vwo.Close(context.Background())
+		assertOutput.Equal(3, dispatcher.events, "Queued events should be flushed on close")
+		if dispatchErr == nil {
+			assertOutput.Nil(err)
+			continue
+		}
+		undelivered, ok := err.(*schema.UndeliveredEventsError)
+		assertOutput.True(ok)
+		assertOutput.Len(undelivered.Events, 3)
+		assertOutput.Equal(userID, undelivered.Events[0].UserID)
+		assertOutput.Equal(dispatchErr, undelivered.Err)
+	}
+}
+
+func TestBatchEventQueueConcurrentTrack(t *testing.T) {
+	assertOutput := assert.New(t)
+	dispatcher := &countingDispatcher{}
+	var mu sync.Mutex
+	var batchSizes []int
+	instance := GetVWOInstance(10, 60)
+	vwo, err := instance.Init(api.WithEventDispatcher(dispatcher), api.WithBatchEventQueue(api.BatchConfig{EventsPerRequest: 10, RequestTimeInterval: 60}, func(err error, batch []map[string]interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		batchSizes = append(batchSizes, len(batch))
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	campaignKey, goalIdentifier := "AB_T_100_W_33_33_33", "GOAL_2"
+	var wg sync.WaitGroup
+	removed := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				userID := testdata.GetRandomUser()
+				vwo.Track(campaignKey, userID, goalIdentifier, nil)
+				if j%10 == 0 {
+					vwo.BatchEventQueue.GetBatchImpressions()
+					count := vwo.BatchEventQueue.RemoveUserImpressions(userID)
+					mu.Lock()
+					removed += count
+					mu.Unlock()
+					vwo.FlushEvents()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assertOutput.Nil(vwo.Close(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	sent := 0
+	for _, size := range batchSizes {
+		assertOutput.True(size >= 1 && size <= 10, "Batches should hold at most EventsPerRequest events")
+		sent += size
+	}
+	assertOutput.Equal(dispatcher.events, sent)
+	assertOutput.Equal(1000, sent+removed, "Only the impressions of the removed users should not be sent")
+	vwo.Track(campaignKey, testdata.GetRandomUser(), goalIdentifier, nil)
+	assertOutput.Nil(vwo.BatchEventQueue.GetBatchImpressions(), "Closed queue should drop the impressions")
+}
+
+func TestBatchEventQueueRequestTimeInterval(t *testing.T) {
+	assertOutput := assert.New(t)
+	flushed := make(chan int, 10)
+	queue := &schema.BatchEventQueue{
+		RequestTimeInterval: 1,
+		EventsPerRequest:    100,
+		FlushCallBack: func(err error, batch []map[string]interface{}) {
+			flushed <- len(batch)
+		},
+	}
+	assertOutput.Nil(queue.Start(&countingDispatcher{}))
+	defer queue.Close(context.Background())
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		queue.AddToBatch(schema.Impression{UID: "user", EventType: "EVENTS_PUSH"})
+		time.Sleep(300 * time.Millisecond)
+	}
+	select {
+	case size := <-flushed:
+		assertOutput.True(size >= 3 && size <= 4, "Batch should be sent RequestTimeInterval after its first impression")
+		assertOutput.True(time.Since(start) < 1900*time.Millisecond)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Batch should be sent although impressions keep being queued")
+	}
+}
+
+func TestBatchEventQueueReplayInRequests(t *testing.T) {
+	assertOutput := assert.New(t)
+	dir, err := ioutil.TempDir("", "vwo-event-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	eventLog, err := storage.NewFileEventLog(dir, storage.FileEventLogConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer eventLog.Close()
+	for i := 0; i < 5; i++ {
+		eventLog.Append(schema.Impression{UID: "user", EventType: "EVENTS_PUSH"})
+	}
+
+	var batchSizes []int
+	queue := &schema.BatchEventQueue{
+		RequestTimeInterval: 60,
+		EventsPerRequest:    2,
+		EventLog:            eventLog,
+		FlushCallBack: func(err error, batch []map[string]interface{}) {
+			batchSizes = append(batchSizes, len(batch))
+		},
+	}
+	assertOutput.Nil(queue.Start(&countingDispatcher{}))
+	assertOutput.Nil(queue.Close(context.Background()))
+	assertOutput.Equal([]int{2, 2, 1}, batchSizes, "Replayed impressions should be sent in requests of EventsPerRequest events")
+	assertOutput.Equal(int64(0), eventLog.Size())
+}
+
+// failingUserDispatcher fails to send the events of the user
+type failingUserDispatcher struct {
+	userID string
+}
+
+func (dispatcher failingUserDispatcher) Dispatch(ctx context.Context, event schema.Event) error {
+	if event.UserID == dispatcher.userID {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func TestBatchEventQueueCheckpointEachRequest(t *testing.T) {
+	assertOutput := assert.New(t)
+	dir, err := ioutil.TempDir("", "vwo-event-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	eventLog, err := storage.NewFileEventLog(dir, storage.FileEventLogConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer eventLog.Close()
+	var impressions []schema.Impression
+	for i := 0; i < 5; i++ {
+		impression := schema.Impression{UID: "user-" + strconv.Itoa(i), EventType: "EVENTS_PUSH"}
+		eventLog.Append(impression)
+		impressions = append(impressions, impression)
+	}
+
+	// the second request of the replayed impressions fails
+	queue := &schema.BatchEventQueue{RequestTimeInterval: 60, EventsPerRequest: 2, EventLog: eventLog}
+	assertOutput.Nil(queue.Start(failingUserDispatcher{userID: "user-2"}))
+	queue.Close(context.Background())
+
+	persisted, err := storage.NewFileEventLog(dir, storage.FileEventLogConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := persisted.Replay()
+	assertOutput.Nil(err)
+	assertOutput.Equal(impressions[2:4], replayed, "Only the impressions of the failed request should be kept")
+}
+
+// stalledDispatcher never returns before it is released, whatever its context, started is told of every dispatch
+type stalledDispatcher struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (dispatcher stalledDispatcher) Dispatch(ctx context.Context, event schema.Event) error {
+	select {
+	case dispatcher.started <- struct{}{}:
+	default:
+	}
+	<-dispatcher.release
+	return nil
+}
+
+func TestBatchEventQueueStalledDispatcher(t *testing.T) {
+	assertOutput := assert.New(t)
+	dispatcher := stalledDispatcher{started: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(dispatcher.release)
+	queue := &schema.BatchEventQueue{RequestTimeInterval: 60, EventsPerRequest: 1}
+	assertOutput.Nil(queue.Start(dispatcher))
+	queue.AddToBatch(schema.Impression{UID: "user", EventType: "EVENTS_PUSH"})
+	<-dispatcher.started
+
+	added := make(chan struct{})
+	go func() {
+		for i := 1; i < 30; i++ {
+			queue.AddToBatch(schema.Impression{UID: "user", EventType: "EVENTS_PUSH"})
+		}
+		close(added)
+	}()
+	select {
+	case <-added:
+	case <-time.After(2 * time.Second):
+		t.Fatal("AddToBatch should not wait for a stalled dispatcher")
+	}
+	assertOutput.Equal(0, queue.Dropped())
+	assertOutput.Len(queue.GetBatchImpressions(), 30-1-constants.BatchMaxPendingBatches, "Impressions should stay queued while too many batches are waiting")
+
+	for i := 0; i < constants.BatchMaxQueuedEvents; i++ {
+		queue.AddToBatch(schema.Impression{UID: "user", EventType: "EVENTS_PUSH"})
+	}
+	assertOutput.Equal(30-1-constants.BatchMaxPendingBatches, queue.Dropped(), "Impressions should be dropped once the queue is full")
+	assertOutput.Len(queue.GetBatchImpressions(), constants.BatchMaxQueuedEvents)
+}
+
+func TestBatchEventQueueCloseDeadline(t *testing.T) {
+	assertOutput := assert.New(t)
+	dispatcher := stalledDispatcher{release: make(chan struct{})}
+	defer close(dispatcher.release)
+	queue := &schema.BatchEventQueue{RequestTimeInterval: 60, EventsPerRequest: 1}
+	assertOutput.Nil(queue.Start(dispatcher))
+	for i := 0; i < 30; i++ {
+		queue.AddToBatch(schema.Impression{UID: "user", EventType: "EVENTS_PUSH"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := queue.Close(ctx)
+	assertOutput.True(time.Since(start) < time.Second, "Close should return once its context is done")
+	undelivered, ok := err.(*schema.UndeliveredEventsError)
+	assertOutput.True(ok)
+	assertOutput.Equal(context.DeadlineExceeded, undelivered.Err)
+	assertOutput.True(undelivered.InFlight > 0, "The events being sent should be reported")
+	assertOutput.Nil(queue.Close(context.Background()), "Close should only stop the queue once")
+}
+
+// unreadableEventLog can not replay the impressions persisted
+type unreadableEventLog struct{}
+
+func (unreadableEventLog) Append(impression schema.Impression) error { return nil }
+func (unreadableEventLog) Replay() ([]schema.Impression, error) {
+	return nil, errors.New("unreadable")
+}
+func (unreadableEventLog) Seal() (int64, error)            { return 0, nil }
+func (unreadableEventLog) Truncate(checkpoint int64) error { return nil }
+func (unreadableEventLog) Rewrite(checkpoint int64, impressions []schema.Impression) error {
+	return nil
+}
+
+func TestBatchEventQueueReplayError(t *testing.T) {
+	assertOutput := assert.New(t)
+	goroutines := runtime.NumGoroutine()
+	instance := GetVWOInstance(10, 60)
+	_, err := instance.Init(api.WithEventLog(unreadableEventLog{}))
+	assertOutput.NotNil(err)
+	assertOutput.True(runtime.NumGoroutine() <= goroutines, "Failed Init should not leave the batch queue running")
+
+	added := make(chan struct{})
+	go func() {
+		instance.BatchEventQueue.AddToBatch(schema.Impression{UID: "user", EventType: "EVENTS_PUSH"})
+		close(added)
+	}()
+	select {
+	case <-added:
+	case <-time.After(time.Second):
+		t.Fatal("Queue which could not start should drop the impressions")
+	}
+	assertOutput.Empty(instance.BatchEventQueue.GetBatchImpressions())
+	assertOutput.Nil(instance.BatchEventQueue.Close(context.Background()))
+}
+
+// recordingStalledDispatcher records the events once it is released, started is told of every dispatch
+type recordingStalledDispatcher struct {
+	mu      sync.Mutex
+	events  []schema.Event
+	started chan struct{}
+	release chan struct{}
+}
+
+func (dispatcher *recordingStalledDispatcher) Dispatch(ctx context.Context, event schema.Event) error {
+	select {
+	case dispatcher.started <- struct{}{}:
+	default:
+	}
+	<-dispatcher.release
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+	dispatcher.events = append(dispatcher.events, event)
+	return nil
+}
+
+func countUserImpressions(impressions []schema.Impression, uid string) int {
+	count := 0
+	for _, impression := range impressions {
+		if impression.UID == uid {
+			count++
+		}
+	}
+	return count
+}
+
+func TestBatchEventQueueRemovePendingImpressions(t *testing.T) {
+	assertOutput := assert.New(t)
+	dir, err := ioutil.TempDir("", "vwo-event-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	eventLog, err := storage.NewFileEventLog(dir, storage.FileEventLogConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer eventLog.Close()
+
+	dispatcher := &recordingStalledDispatcher{started: make(chan struct{}, 1), release: make(chan struct{})}
+	queue := &schema.BatchEventQueue{RequestTimeInterval: 60, EventsPerRequest: 2, EventLog: eventLog}
+	assertOutput.Nil(queue.Start(dispatcher))
+	queue.AddToBatch(schema.Impression{UID: "forgotten", EventType: "EVENTS_PUSH"})
+	queue.AddToBatch(schema.Impression{UID: "kept", EventType: "EVENTS_PUSH"})
+	<-dispatcher.started
+
+	// two batches wait to be sent and one impression stays queued
+	for i := 0; i < 2; i++ {
+		queue.AddToBatch(schema.Impression{UID: "forgotten", EventType: "EVENTS_PUSH"})
+		queue.AddToBatch(schema.Impression{UID: "kept", EventType: "EVENTS_PUSH"})
+	}
+	queue.AddToBatch(schema.Impression{UID: "forgotten", EventType: "EVENTS_PUSH"})
+	assertOutput.Equal(3, queue.RemoveUserImpressions("forgotten"), "Impressions waiting to be sent should be removed")
+
+	persisted, err := storage.NewFileEventLog(dir, storage.FileEventLogConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	impressions, err := persisted.Replay()
+	assertOutput.Nil(err)
+	assertOutput.Equal(0, countUserImpressions(impressions, "forgotten"), "Checkpoints should be rewritten")
+	assertOutput.Equal(3, countUserImpressions(impressions, "kept"))
+
+	close(dispatcher.release)
+	assertOutput.Nil(queue.Close(context.Background()))
+	forgotten := 0
+	for _, event := range dispatcher.events {
+		if event.UserID == "forgotten" {
+			forgotten++
+		}
+	}
+	assertOutput.Len(dispatcher.events, 4)
+	assertOutput.Equal(1, forgotten, "Only the impression being sent should be sent")
+}
+
+// blockingEventLog blocks the appends while block is set until it is released, blocked is told of every blocked append
+type blockingEventLog struct {
+	block   int32
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (eventLog *blockingEventLog) Append(impression schema.Impression) error {
+	if atomic.LoadInt32(&eventLog.block) == 1 {
+		eventLog.blocked <- struct{}{}
+		<-eventLog.release
+	}
+	return nil
+}
+func (eventLog *blockingEventLog) Replay() ([]schema.Impression, error) { return nil, nil }
+func (eventLog *blockingEventLog) Seal() (int64, error)                 { return 0, nil }
+func (eventLog *blockingEventLog) Truncate(checkpoint int64) error      { return nil }
+func (eventLog *blockingEventLog) Rewrite(checkpoint int64, impressions []schema.Impression) error {
+	return nil
+}
+
+func TestBatchEventQueueCloseDeadlineQueued(t *testing.T) {
+	assertOutput := assert.New(t)
+	eventLog := &blockingEventLog{blocked: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(eventLog.release)
+	queue := &schema.BatchEventQueue{RequestTimeInterval: 60, EventsPerRequest: 10, EventLog: eventLog}
+	assertOutput.Nil(queue.Start(&countingDispatcher{}))
+	for i := 0; i < 3; i++ {
+		queue.AddToBatch(schema.Impression{UID: "user", EventType: "EVENTS_PUSH"})
+	}
+	atomic.StoreInt32(&eventLog.block, 1)
+	go queue.AddToBatch(schema.Impression{UID: "user", EventType: "EVENTS_PUSH"})
+	<-eventLog.blocked
+
+	// the queue is busy appending, the close request can not be run before the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := queue.Close(ctx)
+	undelivered, ok := err.(*schema.UndeliveredEventsError)
+	if !assertOutput.True(ok) {
+		return
+	}
+	assertOutput.Equal(context.DeadlineExceeded, undelivered.Err)
+	assertOutput.Equal(3, undelivered.InFlight, "The queued events should be reported")
+}
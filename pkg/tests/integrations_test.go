@@ -41,7 +41,7 @@ func TestIntegrationsABTest(t *testing.T) {
 	assertOutput := assert.New(t)
 	instance := GetVWOClientInstance("AB_T_100_W_33_33_33")
 	instance.Integrations.CallBack = func(integrationsMap map[string]interface{}) {
-		assertOutput.Equal(len(integrationsMap), 16)
+		assertOutput.Equal(len(integrationsMap), 18)
 		assertOutput.Equal(integrationsMap["fromUserStorageService"], false)
 		assertOutput.Equal(integrationsMap["isFeatureEnabled"], nil)
 		assertOutput.Equal(integrationsMap["isUserWhitelisted"], false)
@@ -74,7 +74,7 @@ func TestIntegrationFeatureRollout(t *testing.T) {
 	instance := GetVWOClientInstance("FR_T_100_W_100")
 	userID := testdata.GetRandomUser()
 	instance.Integrations.CallBack = func(integrationsMap map[string]interface{}) {
-		assertOutput.Equal(len(integrationsMap), 15)
+		assertOutput.Equal(len(integrationsMap), 17)
 		assertOutput.Equal(integrationsMap["fromUserStorageService"], false)
 		assertOutput.Equal(integrationsMap["isFeatureEnabled"], true)
 		assertOutput.Equal(integrationsMap["isUserWhitelisted"], false)
@@ -117,3 +117,36 @@ func TestIntegrationFeatureTestCampaign(t *testing.T) {
 
 	instance.GetFeatureVariableValue("FT_100_W_33_33_33_WS_WW", "STRING_VARIABLE", "Ashley", options)
 }
+
+func TestIntegrationsForcedVariation(t *testing.T) {
+	assertOutput := assert.New(t)
+	instance := GetVWOClientInstance("AB_T_100_W_33_33_33")
+	userID, campaignKey := testdata.GetRandomUser(), "AB_T_100_W_33_33_33"
+
+	called := false
+	instance.Integrations.CallBack = func(integrationsMap map[string]interface{}) {
+		called = true
+		assertOutput.Equal(integrationsMap["isForcedVariation"], true)
+		assertOutput.Equal(integrationsMap["isUserWhitelisted"], false)
+		assertOutput.Equal(integrationsMap["variationName"], "Variation-2")
+	}
+	assertOutput.Nil(instance.SetForcedVariation(userID, campaignKey, "Variation-2"))
+	instance.GetVariationName(campaignKey, userID, nil)
+	assertOutput.True(called)
+}
+
+func TestIntegrationsHoldout(t *testing.T) {
+	assertOutput := assert.New(t)
+	instance := GetVWOClientInstance("AB_T_100_W_0_100")
+	instance.Holdout = &schema.Holdout{PercentTraffic: 100}
+	userID, campaignKey := testdata.GetRandomUser(), "AB_T_100_W_0_100"
+
+	called := false
+	instance.Integrations.CallBack = func(integrationsMap map[string]interface{}) {
+		called = true
+		assertOutput.Equal(integrationsMap["isHoldout"], true)
+		assertOutput.Equal(integrationsMap["variationName"], "Control")
+	}
+	assertOutput.Equal("Control", instance.Activate(campaignKey, userID, nil))
+	assertOutput.True(called)
+}
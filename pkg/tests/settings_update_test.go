@@ -0,0 +1,79 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/event"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+)
+
+// settingsTransport answers every request with the settings file
+type settingsTransport struct {
+	body []byte
+}
+
+func (transport settingsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(transport.body)), Request: req}, nil
+}
+
+func TestGetAndUpdateSettingsFileDispatcher(t *testing.T) {
+	assertOutput := assert.New(t)
+	instance := GetVWOInstance(1, 60)
+	instance.SettingsFile.AccountID = 12345
+	instance.SettingsFile.SDKKey = "sdkKey"
+	body, err := json.Marshal(instance.SettingsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := http.DefaultTransport
+	defer func() { http.DefaultTransport = transport }()
+	http.DefaultTransport = settingsTransport{body: body}
+
+	vwo, err := instance.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vwo.Close(context.Background())
+	initial := vwo.ActiveEventDispatcher.Load()
+
+	// the events are dispatched while the dispatcher is replaced
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			vwo.AddToBatch(schema.Impression{UID: "user", EventType: "EVENTS_PUSH"})
+		}
+		close(done)
+	}()
+	for i := 0; i < 5; i++ {
+		vwo.GetAndUpdateSettingsFile()
+	}
+	<-done
+
+	dispatcher := vwo.ActiveEventDispatcher.Load()
+	_, ok := dispatcher.(*event.HTTPDispatcher)
+	assertOutput.True(ok)
+	assertOutput.False(dispatcher == initial, "A new dispatcher should be published")
+	assertOutput.True(vwo.BatchEventQueue.Dispatcher == dispatcher, "The batch queue should use the new dispatcher")
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wingify/vwo-go-sdk/pkg/constants"
+	"github.com/wingify/vwo-go-sdk/pkg/schema"
+	"github.com/wingify/vwo-go-sdk/pkg/testdata"
+)
+
+// blockingDispatcher records the events once released, and fails those of the failing user
+type blockingDispatcher struct {
+	mu       sync.Mutex
+	release  chan struct{}
+	events   []schema.Event
+	inFlight int
+}
+
+func (dispatcher *blockingDispatcher) Dispatch(ctx context.Context, event schema.Event) error {
+	dispatcher.mu.Lock()
+	dispatcher.inFlight++
+	dispatcher.mu.Unlock()
+	select {
+	case <-dispatcher.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+	dispatcher.events = append(dispatcher.events, event)
+	if event.UserID == "failing" {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func (dispatcher *blockingDispatcher) getInFlight() int {
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+	return dispatcher.inFlight
+}
+
+func (dispatcher *blockingDispatcher) getUserIDs() []string {
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+	var userIDs []string
+	for _, event := range dispatcher.events {
+		userIDs = append(userIDs, event.UserID)
+	}
+	return userIDs
+}
+
+// getBusyPool returns a pool with a single worker busy with the first event
+func getBusyPool(t *testing.T, overflowPolicy string) (*schema.WorkerPool, *blockingDispatcher) {
+	dispatcher := &blockingDispatcher{release: make(chan struct{})}
+	pool := &schema.WorkerPool{Workers: 1, QueueSize: 2, OverflowPolicy: overflowPolicy, Logger: testdata.GetInstanceWithSettings("AB_T_50_W_50_50").Logger}
+	if err := pool.Start(dispatcher); err != nil {
+		t.Fatal(err)
+	}
+	pool.Submit(schema.Event{UserID: "first"})
+	for dispatcher.getInFlight() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	return pool, dispatcher
+}
+
+func TestWorkerPoolOverflowPolicies(t *testing.T) {
+	assertOutput := assert.New(t)
+
+	pool, dispatcher := getBusyPool(t, constants.WorkerPoolOverflowDropNewest)
+	assertOutput.True(pool.Submit(schema.Event{UserID: "second"}))
+	assertOutput.True(pool.Submit(schema.Event{UserID: "third"}))
+	assertOutput.False(pool.Submit(schema.Event{UserID: "fourth"}))
+	assertOutput.Equal(schema.WorkerPoolStats{Dropped: 1, Queued: 2}, pool.Stats())
+	close(dispatcher.release)
+	assertOutput.Nil(pool.Close(context.Background()))
+	assertOutput.Equal([]string{"first", "second", "third"}, dispatcher.getUserIDs())
+	assertOutput.Equal(schema.WorkerPoolStats{Dispatched: 3, Dropped: 1}, pool.Stats())
+
+	pool, dispatcher = getBusyPool(t, constants.WorkerPoolOverflowDropOldest)
+	for _, userID := range []string{"second", "third", "fourth", "fifth"} {
+		assertOutput.True(pool.Submit(schema.Event{UserID: userID}))
+	}
+	close(dispatcher.release)
+	assertOutput.Nil(pool.Close(context.Background()))
+	assertOutput.Equal([]string{"first", "fourth", "fifth"}, dispatcher.getUserIDs())
+	assertOutput.Equal(int64(2), pool.Stats().Dropped)
+
+	pool, dispatcher = getBusyPool(t, constants.WorkerPoolOverflowBlock)
+	pool.Submit(schema.Event{UserID: "second"})
+	pool.Submit(schema.Event{UserID: "failing"})
+	submitted := make(chan bool)
+	go func() {
+		submitted <- pool.Submit(schema.Event{UserID: "fourth"})
+	}()
+	select {
+	case <-submitted:
+		t.Fatal("Submit should block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(dispatcher.release)
+	assertOutput.True(<-submitted)
+	assertOutput.Nil(pool.Close(context.Background()))
+	assertOutput.Equal([]string{"first", "second", "failing", "fourth"}, dispatcher.getUserIDs())
+	assertOutput.Equal(schema.WorkerPoolStats{Dispatched: 3, Failed: 1}, pool.Stats())
+	assertOutput.False(pool.Submit(schema.Event{UserID: "closed"}), "Closed pool should drop the events")
+
+	assertOutput.NotNil((&schema.WorkerPool{OverflowPolicy: "unknown"}).Start(dispatcher))
+}
+
+func TestWorkerPoolClose(t *testing.T) {
+	assertOutput := assert.New(t)
+	pool, dispatcher := getBusyPool(t, constants.WorkerPoolOverflowBlock)
+	pool.Submit(schema.Event{UserID: "second"})
+	pool.Submit(schema.Event{UserID: "third"})
+	submitted := make(chan bool)
+	go func() {
+		submitted <- pool.Submit(schema.Event{UserID: "fourth"})
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := pool.Close(ctx)
+	assertOutput.False(<-submitted, "Close should unblock the calls waiting for room in the queue")
+	undelivered, ok := err.(*schema.UndeliveredEventsError)
+	assertOutput.True(ok)
+	assertOutput.Equal(context.DeadlineExceeded, undelivered.Err)
+	assertOutput.True(len(undelivered.Events)+undelivered.InFlight >= 2, "Queued events should be reported")
+	assertOutput.Empty(dispatcher.getUserIDs(), "In flight events should be canceled at the deadline")
+
+	// the events failing while closing are reported
+	pool, dispatcher = getBusyPool(t, constants.WorkerPoolOverflowBlock)
+	pool.Submit(schema.Event{UserID: "failing"})
+	close(dispatcher.release)
+	undelivered, ok = pool.Close(context.Background()).(*schema.UndeliveredEventsError)
+	assertOutput.True(ok)
+	assertOutput.Equal([]schema.Event{{UserID: "failing"}}, undelivered.Events)
+	assertOutput.NotNil(undelivered.Err)
+}
+
+func TestWorkerPoolSetDispatcherWhileBlocked(t *testing.T) {
+	assertOutput := assert.New(t)
+	pool, dispatcher := getBusyPool(t, constants.WorkerPoolOverflowBlock)
+	pool.Submit(schema.Event{UserID: "second"})
+	pool.Submit(schema.Event{UserID: "third"})
+	submitted := make(chan bool)
+	go func() {
+		submitted <- pool.Submit(schema.Event{UserID: "fourth"})
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// a settings refresh replaces the dispatcher while Submit waits for room in the queue
+	next := &blockingDispatcher{release: make(chan struct{})}
+	close(next.release)
+	replaced := make(chan struct{})
+	go func() {
+		pool.SetDispatcher(next)
+		close(replaced)
+	}()
+	select {
+	case <-replaced:
+	case <-time.After(time.Second):
+		t.Fatal("SetDispatcher should not wait for the blocked Submit")
+	}
+
+	close(dispatcher.release)
+	select {
+	case ok := <-submitted:
+		assertOutput.True(ok)
+	case <-time.After(time.Second):
+		t.Fatal("Workers should keep draining the queue")
+	}
+	assertOutput.Nil(pool.Close(context.Background()))
+	assertOutput.Equal([]string{"first"}, dispatcher.getUserIDs())
+	assertOutput.Equal([]string{"second", "third", "fourth"}, next.getUserIDs())
+}
+
+func TestWorkerPoolConcurrency(t *testing.T) {
+	assertOutput := assert.New(t)
+	dispatcher := &blockingDispatcher{release: make(chan struct{})}
+	close(dispatcher.release)
+	pool := &schema.WorkerPool{Workers: 4, QueueSize: 10, OverflowPolicy: constants.WorkerPoolOverflowDropOldest}
+	assertOutput.Nil(pool.Start(dispatcher))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				pool.Submit(schema.Event{UserID: "user"})
+			}
+		}()
+	}
+	wg.Wait()
+	assertOutput.Nil(pool.Close(context.Background()))
+	stats := pool.Stats()
+	assertOutput.Equal(int64(800), stats.Dispatched+stats.Dropped)
+	assertOutput.Equal(int(stats.Dispatched), len(dispatcher.getUserIDs()))
+}
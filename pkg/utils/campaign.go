@@ -220,6 +220,27 @@ func GetCampaignVariation(API string, campaign schema.Campaign, variationName st
 	return schema.Variation{}, fmt.Errorf(constants.ErrorMessageVariationNotFound, API, variationName, campaign.Key)
 }
 
+// GetStoredVariation function finds the variation of the stored data by its ID, or by its name for the data stored
+// without IDs or for another campaign with the same key
+func GetStoredVariation(API string, campaign schema.Campaign, userData schema.UserData) (schema.Variation, error) {
+	/*
+		 Args:
+			campaign: The running campaign
+			userData: data stored for the user
+
+		Returns:
+			schema.Variation: Variation of the stored data in the campaign
+	*/
+	if userData.VariationID != 0 && (userData.CampaignID == 0 || userData.CampaignID == campaign.ID) {
+		for _, variation := range campaign.Variations {
+			if variation.ID == userData.VariationID {
+				return variation, nil
+			}
+		}
+	}
+	return GetCampaignVariation(API, campaign, userData.VariationName)
+}
+
 // GetControlVariation returns control variation from a given campaign
 func GetControlVariation(campaign schema.Campaign) schema.Variation {
 	/*
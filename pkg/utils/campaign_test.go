@@ -80,6 +80,31 @@ func TestGetCampaignVariation(t *testing.T) {
 	assert.Empty(t, variation, "No Variations in the Campaign")
 }
 
+func TestGetStoredVariation(t *testing.T) {
+	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
+	campaign := vwoInstance.SettingsFile.Campaigns[0]
+	stored := campaign.Variations[1]
+
+	userData := schema.UserData{CampaignID: campaign.ID, VariationID: stored.ID, VariationName: "Renamed"}
+	variation, err := GetStoredVariation("", campaign, userData)
+	assert.Nil(t, err)
+	assert.Equal(t, stored, variation, "Variation should be found by ID")
+
+	userData = schema.UserData{VariationName: stored.Name}
+	variation, err = GetStoredVariation("", campaign, userData)
+	assert.Nil(t, err)
+	assert.Equal(t, stored, variation, "Data stored without IDs should be found by name")
+
+	userData = schema.UserData{CampaignID: campaign.ID + 1, VariationID: campaign.Variations[0].ID, VariationName: stored.Name}
+	variation, err = GetStoredVariation("", campaign, userData)
+	assert.Nil(t, err)
+	assert.Equal(t, stored, variation, "IDs of another campaign should not be used")
+
+	userData = schema.UserData{CampaignID: campaign.ID, VariationID: 999, VariationName: "Removed"}
+	_, err = GetStoredVariation("", campaign, userData)
+	assert.NotNil(t, err)
+}
+
 func TestGetCampaignGoal(t *testing.T) {
 	vwoInstance := testdata.GetInstanceWithSettings("AB_T_50_W_50_50")
 	campaign := vwoInstance.SettingsFile.Campaigns[0]
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import "math"
+
+const (
+	gammaEpsilon    = 1e-14
+	gammaFloatMin   = 1e-300
+	gammaIterations = 1000
+)
+
+// ChiSquareStatistic returns the Pearson chi-square statistic of the observed counts against the expected counts
+func ChiSquareStatistic(observed []int, expected []float64) float64 {
+	/*
+		Args:
+			observed: observed count of every category
+			expected: expected count of every category, categories expecting and observing 0 are skipped
+
+		Return:
+			float64: sum of (observed - expected)^2 / expected, +Inf if a category expecting 0 is observed
+	*/
+	statistic := 0.0
+	for i, expectedCount := range expected {
+		if expectedCount <= 0 {
+			if observed[i] > 0 {
+				return math.Inf(1)
+			}
+			continue
+		}
+		difference := float64(observed[i]) - expectedCount
+		statistic += difference * difference / expectedCount
+	}
+	return statistic
+}
+
+// ChiSquarePValue returns the probability of a chi-square statistic at least as large as the given one
+func ChiSquarePValue(statistic float64, degreesOfFreedom int) float64 {
+	/*
+		Args:
+			statistic: chi-square statistic
+			degreesOfFreedom: number of categories minus one
+
+		Return:
+			float64: p-value, 0 for an infinite statistic, else 1 if there are no degrees of freedom
+	*/
+	if math.IsInf(statistic, 1) {
+		return 0
+	}
+	if degreesOfFreedom < 1 || statistic <= 0 {
+		return 1
+	}
+	return upperIncompleteGamma(float64(degreesOfFreedom)/2, statistic/2)
+}
+
+// upperIncompleteGamma returns the regularized upper incomplete gamma function Q(a, x)
+func upperIncompleteGamma(a, x float64) float64 {
+	logGamma, _ := math.Lgamma(a)
+	prefix := math.Exp(-x + a*math.Log(x) - logGamma)
+
+	if x < a+1 {
+		// series representation of the lower function P(a, x)
+		term := 1 / a
+		sum := term
+		for n := 1; n < gammaIterations; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*gammaEpsilon {
+				break
+			}
+		}
+		return math.Max(0, 1-sum*prefix)
+	}
+
+	// continued fraction representation of Q(a, x), evaluated with the modified Lentz method
+	b := x + 1 - a
+	c := 1 / gammaFloatMin
+	d := 1 / b
+	h := d
+	for i := 1; i < gammaIterations; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < gammaFloatMin {
+			d = gammaFloatMin
+		}
+		c = b + an/c
+		if math.Abs(c) < gammaFloatMin {
+			c = gammaFloatMin
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < gammaEpsilon {
+			break
+		}
+	}
+	return prefix * h
+}
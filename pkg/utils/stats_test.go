@@ -0,0 +1,47 @@
+/*
+ * Copyright 2020-2022 Wingify Software Pvt. Ltd.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChiSquareStatistic(t *testing.T) {
+	actual := ChiSquareStatistic([]int{60, 40}, []float64{50, 50})
+	assert.InDelta(t, 4.0, actual, 1e-9)
+
+	actual = ChiSquareStatistic([]int{10, 0}, []float64{10, 0})
+	assert.Equal(t, 0.0, actual, "Categories expecting nothing are skipped")
+
+	actual = ChiSquareStatistic([]int{10, 1}, []float64{11, 0})
+	assert.True(t, math.IsInf(actual, 1), "Category expecting nothing is observed")
+}
+
+func TestChiSquarePValue(t *testing.T) {
+	assert.InDelta(t, 0.05, ChiSquarePValue(3.841459, 1), 1e-6)
+	assert.InDelta(t, 0.05, ChiSquarePValue(5.991465, 2), 1e-6)
+	assert.InDelta(t, 0.001, ChiSquarePValue(16.26624, 3), 1e-7)
+	assert.InDelta(t, 0.5, ChiSquarePValue(9.341818, 10), 1e-6)
+	// with 4 degrees of freedom the p-value is exp(-x/2) * (1 + x/2)
+	assert.InDelta(t, 0.9735009788, ChiSquarePValue(0.5, 4), 1e-9)
+	assert.Equal(t, 1.0, ChiSquarePValue(0, 3))
+	assert.Equal(t, 1.0, ChiSquarePValue(5, 0))
+	assert.Equal(t, 0.0, ChiSquarePValue(math.Inf(1), 0))
+}
@@ -17,6 +17,7 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/wingify/vwo-go-sdk/pkg/constants"
@@ -59,6 +60,26 @@ func ParseOptions(option interface{}) (options schema.Options) {
 		if okShouldTrackReturningUser {
 			options.ShouldTrackReturningUser = shouldTrackReturningUser
 		}
+
+		bucketingKey, okBucketingKey := optionMap["bucketingKey"].(string)
+		if okBucketingKey {
+			options.BucketingKey = bucketingKey
+		}
+
+		ctx, okContext := optionMap["context"].(context.Context)
+		if okContext {
+			options.Context = ctx
+		}
+
+		alwaysCheckSegment, okAlwaysCheckSegment := optionMap["alwaysCheckSegment"].(bool)
+		if okAlwaysCheckSegment {
+			options.AlwaysCheckSegment = alwaysCheckSegment
+		}
+
+		trackingConsent, okTrackingConsent := optionMap["trackingConsent"].(bool)
+		if okTrackingConsent {
+			options.NoTrackingConsent = !trackingConsent
+		}
 	}
 	return
 }
@@ -79,14 +100,8 @@ func ValidateStorage(storage interface{}) bool {
 	if storage == nil {
 		return true
 	}
-	_, okGet := storage.(interface {
-		Get(a, b string) schema.UserData
-	})
-	_, okSet := storage.(interface{ Set(a, b, c string) })
-	if (okGet && okSet) || storage == nil {
-		return true
-	}
-	return false
+	_, ok := schema.ToUserStorage(storage)
+	return ok
 }
 
 // ValidateActivate - validates Activate API parameters
@@ -17,6 +17,7 @@
 package utils
 
 import (
+	"context"
 	"io/ioutil"
 	"testing"
 
@@ -59,14 +60,30 @@ func TestValidateLogger(t *testing.T) {
 
 type CUserStorage interface {
 	Get(userID, campaignKey string) schema.UserData
-	Set(userID, campaignKey, variationName string)
+	Set(userID, campaignKey, variationName, goalIdentifier string)
 }
 type CUserStorageData struct{}
 
 func (us *CUserStorageData) Get(userID, campaignKey string) schema.UserData {
 	return schema.UserData{}
 }
-func (us *CUserStorageData) Set(userID, campaignKey, variationName string) {}
+func (us *CUserStorageData) Set(userID, campaignKey, variationName, goalIdentifier string) {}
+
+type TUserStorageData struct{}
+
+func (us *TUserStorageData) Get(ctx context.Context, userID, campaignKey string) (schema.UserData, error) {
+	return schema.UserData{}, nil
+}
+func (us *TUserStorageData) Set(ctx context.Context, userData schema.UserData) error {
+	return nil
+}
+
+type IUserStorageData struct{}
+
+func (us *IUserStorageData) Get(userID, campaignKey string) schema.UserData {
+	return schema.UserData{}
+}
+func (us *IUserStorageData) Set(userID, campaignKey, variationName string) {}
 
 type WUserStorage interface {
 	Getter(userID, campaignKey string) schema.UserData
@@ -87,9 +104,17 @@ func TestValidateStorage(t *testing.T) {
 	actual = ValidateStorage(correctStorage)
 	assert.True(t, actual)
 
+	typedStorage := &TUserStorageData{}
+	actual = ValidateStorage(typedStorage)
+	assert.True(t, actual)
+
 	wrongStorage := &WUserStorageData{}
 	actual = ValidateStorage(wrongStorage)
 	assert.False(t, actual)
+
+	incompleteSetStorage := &IUserStorageData{}
+	actual = ValidateStorage(incompleteSetStorage)
+	assert.False(t, actual)
 }
 
 func TestParseOptions(t *testing.T) {
@@ -107,12 +132,20 @@ func TestParseOptions(t *testing.T) {
 	data["revenueValue"] = 12
 	data["goalTypeToTrack"] = "ALL"
 	data["shouldTrackReturningUser"] = false
+	data["bucketingKey"] = "organizationID"
+	data["context"] = context.TODO()
+	data["alwaysCheckSegment"] = true
+	data["trackingConsent"] = false
 	expected = schema.Options{
 		CustomVariables:             map[string]interface{}{"a": "x"},
 		VariationTargetingVariables: map[string]interface{}{"a": "x"},
 		RevenueValue:                12,
 		GoalTypeToTrack:             "ALL",
 		ShouldTrackReturningUser:    false,
+		BucketingKey:                "organizationID",
+		Context:                     context.TODO(),
+		AlwaysCheckSegment:          true,
+		NoTrackingConsent:           true,
 	}
 	actual = ParseOptions(data)
 	assert.Equal(t, expected, actual)